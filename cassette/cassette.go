@@ -0,0 +1,259 @@
+/*
+Package cassette records Sabre exchanges to a file once and replays them in tests, so parsing is checked against real payload shapes without calling Sabre. Recorder is an http.RoundTripper; use Recorder.Transport() for SOAP calls through srvc, and Recorder.Client() for the REST havail.SabreClient.
+
+	rec, err := cassette.New("testdata/hotel_flow.json", cassette.Auto, nil) //record against cert when the file is missing, replay after
+	ctx := srvc.WithTransport(context.Background(), rec.Transport())
+	...
+	err = rec.Save()
+
+Requests are matched on SOAP MessageHeader action (method and path for REST) and the body with MessageId, Timestamp and BinarySecurityToken values and TimeStamp attributes removed. Identical requests replay in recorded order, the last one repeating once they run out. Card numbers, passwords, security codes, tokens and REST client secrets are scrubbed before anything is kept.
+*/
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+// Mode of a Recorder.
+type Mode int
+
+const (
+	// Replay answers from the cassette and never calls Sabre.
+	Replay Mode = iota
+	// Record calls Sabre and keeps every exchange, replacing the cassette on Save.
+	Record
+	// Auto replays when the cassette file exists and records otherwise.
+	Auto
+)
+
+var (
+	actionMatcher = regexp.MustCompile(`<(?:[\w.-]+:)?Action>([^<]*)</(?:[\w.-]+:)?Action>`)
+	//values that change with every request
+	volatileMatcher = regexp.MustCompile(`(<((?:[\w.-]+:)?(?:MessageId|Timestamp|BinarySecurityToken))(?:\s[^>]*)?>)[^<]*(</)`)
+	//request time attributes, e.g., OTA_HotelResRQ TimeStamp
+	volatileAttrMatcher = regexp.MustCompile(`(\s(?:TimeStamp|Timestamp)=")[^"]*(")`)
+	//secrets in REST json bodies
+	jsonSecretMatcher = regexp.MustCompile(`("(?i:access_token|client_secret|client_id|password|refresh_token)"\s*:\s*)"[^"]*"`)
+)
+
+// Interaction is one recorded exchange, bodies are scrubbed.
+type Interaction struct {
+	Action      string `json:"action"`
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Request     string `json:"request"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Response    string `json:"response"`
+}
+
+// ErrorNoInteraction is returned by Recorder.RoundTrip in replay when nothing recorded matches the request.
+type ErrorNoInteraction struct {
+	Action string
+	URL    string
+}
+
+func (e ErrorNoInteraction) Error() string {
+	return fmt.Sprintf("cassette has no interaction for action '%s' to '%s'", e.Action, e.URL)
+}
+
+/*
+Recorder records to or replays from the cassette at Path; safe for concurrent use. Redactor scrubs bodies, srvc.DefaultRedactor when nil; add PII names with srvc.NewRedactor to keep names and emails out of cassettes too.
+*/
+type Recorder struct {
+	Path     string
+	Mode     Mode              //Replay or Record, Auto is resolved by New
+	Next     http.RoundTripper //used in Record, http.DefaultTransport when nil
+	Redactor *srvc.Redactor
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New Recorder for the cassette at path. Replay (or Auto with an existing file) loads the cassette; Record starts empty and calls Sabre through next.
+func New(path string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{Path: path, Mode: mode, Next: next}
+	if mode == Auto {
+		r.Mode = Replay
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			r.Mode = Record
+		}
+	}
+	if r.Mode == Record {
+		return r, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &r.interactions); err != nil {
+		return nil, fmt.Errorf("cassette %s: %v", path, err)
+	}
+	r.used = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+// Transport for srvc posting through r.
+func (r *Recorder) Transport() *srvc.Transport {
+	return srvc.NewTransport(r.Client())
+}
+
+// Client posting through r, e.g., for havail.SabreClient.HTTPClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes recorded interactions to Path, creating the directory; nothing is written in replay. The file is swapped in with a rename so a failed run never leaves a half written cassette.
+func (r *Recorder) Save() error {
+	if r.Mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.Path), filepath.Base(r.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.Path)
+}
+
+// Scrub masks card data, passwords, tokens and REST secrets in body.
+func (r *Recorder) Scrub(body []byte) []byte {
+	out := r.Redactor.Redact(body)
+	return jsonSecretMatcher.ReplaceAll(out, []byte(`$1"****"`))
+}
+
+// RoundTrip records the exchange or replays the matching one.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	in := Interaction{
+		Action:  action(req, body),
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Request: string(r.Scrub(body)),
+	}
+	if r.Mode == Record {
+		return r.record(req, body, in)
+	}
+	return r.replay(req, in)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, in Interaction) (*http.Response, error) {
+	next := r.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	in.Status = resp.StatusCode
+	in.ContentType = resp.Header.Get("Content-Type")
+	in.Response = string(r.Scrub(rs))
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.used = append(r.used, true)
+	r.mu.Unlock()
+	//caller sees the live response, the cassette keeps the scrubbed one
+	resp.Body = io.NopCloser(bytes.NewReader(rs))
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, in Interaction) (*http.Response, error) {
+	key := normalize(in.Request)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i, rec := range r.interactions {
+		if rec.Action != in.Action || rec.Method != in.Method || normalize(rec.Request) != key {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return response(req, rec), nil
+		}
+		last = i
+	}
+	if last < 0 {
+		return nil, ErrorNoInteraction{Action: in.Action, URL: in.URL}
+	}
+	return response(req, r.interactions[last]), nil
+}
+
+// action is the SOAP MessageHeader action, or method and path for REST.
+func action(req *http.Request, body []byte) string {
+	if m := actionMatcher.FindSubmatch(body); m != nil {
+		return string(m[1])
+	}
+	return req.Method + " " + req.URL.Path
+}
+
+// normalize drops the values of elements that differ between otherwise identical requests.
+func normalize(body string) string {
+	body = volatileMatcher.ReplaceAllString(body, "$1$3")
+	return volatileAttrMatcher.ReplaceAllString(body, "$1$2")
+}
+
+func response(req *http.Request, in Interaction) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{in.ContentType}},
+		Body:          io.NopCloser(strings.NewReader(in.Response)),
+		ContentLength: int64(len(in.Response)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,189 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ailgroup/sbrweb/rest/havail"
+	"github.com/ailgroup/sbrweb/soap/htlsp"
+	"github.com/ailgroup/sbrweb/soap/itin"
+	"github.com/ailgroup/sbrweb/soap/sabresim"
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+const (
+	simSecret  = "Xk29pQw7"
+	cardNumber = "4111111111111111"
+)
+
+// simConf with a password the cassette must not record.
+func simConf() *srvc.SessionConf {
+	conf := sabresim.Conf(sabresim.DefaultURL)
+	conf.Password = simSecret
+	return conf
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// hotelFlow runs the same calls against record and replay, returning the property description.
+func hotelFlow(t *testing.T, ctx context.Context) htlsp.HotelPropDescResponse {
+	t.Helper()
+	conf := simConf()
+	binsec, err := sabresim.CreateSession(ctx, conf)
+	if err != nil {
+		t.Fatalf("CreateSession expect: nil, got: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = srvc.CallSessionValidateContext(ctx, sabresim.DefaultURL, srvc.BuildSessionValidateRequest(conf, binsec)); err != nil {
+			t.Fatalf("CallSessionValidateContext expect: nil, got: %v", err)
+		}
+	}
+	q, _ := htlsp.NewHotelSearchCriteria(htlsp.HotelRefSearch(htlsp.HotelRefCriterion{htlsp.HotelidQueryField: []string{"0012345"}}))
+	propBody, _ := htlsp.SetHotelPropDescBody(2, q, "06-07", "06-09")
+	propRS, err := htlsp.CallHotelPropDescContext(ctx, sabresim.DefaultURL, htlsp.BuildHotelPropDescRequest(conf, binsec, propBody))
+	if err != nil {
+		t.Fatalf("CallHotelPropDescContext expect: nil, got: %v", err)
+	}
+	_, err = itin.CallPNRDetailContext(ctx, sabresim.DefaultURL, itin.BuildPNRDetailsRequest(conf, binsec, itin.SetPNRDetailBody("123-456-7890", itin.CreatePersonName("Jane", "Doe"))))
+	if err != nil {
+		t.Fatalf("CallPNRDetailContext expect: nil, got: %v", err)
+	}
+	resBody := htlsp.SetHotelResBody(1)
+	resBody.NewPropertyResByRPH(propRS.Body.HotelDesc.RoomStay.RoomRates[0].RPH)
+	resBody.NewGuaranteeRes("Doe", "G", "VI", "2030-12", cardNumber)
+	if _, err = htlsp.CallHotelResContext(ctx, sabresim.DefaultURL, htlsp.BuildHotelResRequest(conf, binsec, resBody)); err != nil {
+		t.Fatalf("CallHotelResContext expect: nil, got: %v", err)
+	}
+	return propRS
+}
+
+func TestRecordReplaySOAP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "hotel_flow.json")
+	sim := sabresim.New(sabresim.Config{Username: "user", Password: simSecret})
+	rec, err := New(path, Auto, sim)
+	if err != nil || rec.Mode != Record {
+		t.Fatalf("New Auto without cassette expect: Record, got: %v %v", err, rec.Mode)
+	}
+	recorded := hotelFlow(t, srvc.WithTransport(context.Background(), rec.Transport()))
+	if err = rec.Save(); err != nil {
+		t.Fatalf("Save expect: nil, got: %v", err)
+	}
+	if n := len(rec.Interactions()); n != 6 {
+		t.Errorf("Interactions expect: 6, got: %d", n)
+	}
+
+	raw, _ := os.ReadFile(path)
+	for _, secret := range []string{simSecret, cardNumber} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("cassette expect: %s scrubbed, got: %s", secret, raw)
+		}
+	}
+	if !strings.Contains(string(raw), "XXXXXXXXXXXX1111") {
+		t.Error("cassette expect: masked card keeping last 4, got: none")
+	}
+
+	rep, err := New(path, Auto, nil)
+	if err != nil || rep.Mode != Replay {
+		t.Fatalf("New Auto with cassette expect: Replay, got: %v %v", err, rep.Mode)
+	}
+	ctx := srvc.WithTransport(context.Background(), rep.Transport())
+	replayed := hotelFlow(t, ctx)
+	got, want := replayed.Body.HotelDesc.RoomStay, recorded.Body.HotelDesc.RoomStay
+	if got.BasicPropertyInfo.HotelName != want.BasicPropertyInfo.HotelName || len(got.RoomRates) != len(want.RoomRates) {
+		t.Errorf("replayed RoomStay expect: %+v, got: %+v", want, got)
+	}
+	if got.RoomRates[0].Rates[0].HotelPricing.Amount != want.RoomRates[0].Rates[0].HotelPricing.Amount {
+		t.Errorf("replayed Amount expect: %s, got: %s", want.RoomRates[0].Rates[0].HotelPricing.Amount, got.RoomRates[0].Rates[0].HotelPricing.Amount)
+	}
+	if n := sim.Sessions(""); n != 1 {
+		t.Errorf("replay expect: no calls to Sabre, got: %d sessions", n)
+	}
+
+	_, err = itin.CallGetReservationContext(ctx, sabresim.DefaultURL, itin.BuildGetReservationRequest(simConf(), "token", "ABCDEF"))
+	if err == nil || !strings.Contains(err.Error(), "no interaction for action 'GetReservationRQ'") {
+		t.Errorf("unrecorded request expect: no interaction, got: %v", err)
+	}
+}
+
+func TestRecordReplayREST(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.json")
+	sabre := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := `{"access_token":"T1RLAQsecret","token_type":"Bearer","expires_in":604800}`
+		if strings.HasSuffix(req.URL.Path, "/locations") {
+			body = `{"GeoSearchRS":{"ApplicationResults":{"Success":[{"timeStamp":"2019-03-06T19:45:28.423-06:00"}]},"GeoSearchResults":{"Radius":1.0,"UOM":"MI","Category":"HOTEL","GeoSearchResult":[{"Distance":0.28,"Name":"HOMESTEAD DALLAS-LAS COLINAS","Id":"42006","City":"Irving","State":"TX","Country":"US"}]}}}`
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": []string{"application/json"}}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})
+	geo := havail.GeoSearchRequest{
+		GeoSearchRQ: havail.GeoSearchRQ{
+			Version: "1",
+			GeoRef: havail.GeoRef{
+				Category:   "HOTEL",
+				UOM:        "MI",
+				Radius:     1.0,
+				HTTPVerb:   http.MethodPost,
+				Endpoint:   havail.GeoLocations,
+				AddressRef: havail.AddressRef{City: "Irving", StateProv: "TX", CountryCode: "US"},
+			},
+		},
+	}
+
+	rec, _ := New(path, Record, sabre)
+	client := &havail.SabreClient{HTTPClient: rec.Client()}
+	if err := client.SetAccessToken(); err != nil || client.BasicAuthRS.AccessToken != "T1RLAQsecret" {
+		t.Fatalf("SetAccessToken expect: live token, got: %v %s", err, client.BasicAuthRS.AccessToken)
+	}
+	if _, err := client.GeoSearchFor(geo); err != nil {
+		t.Fatalf("GeoSearchFor expect: nil, got: %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save expect: nil, got: %v", err)
+	}
+	if raw, _ := os.ReadFile(path); strings.Contains(string(raw), "T1RLAQsecret") {
+		t.Errorf("cassette expect: access_token scrubbed, got: %s", raw)
+	}
+
+	rep, err := New(path, Replay, nil)
+	if err != nil {
+		t.Fatalf("New Replay expect: nil, got: %v", err)
+	}
+	client = &havail.SabreClient{HTTPClient: rep.Client()}
+	if err = client.SetAccessToken(); err != nil || client.BasicAuthRS.TokenType != "Bearer" {
+		t.Fatalf("SetAccessToken replay expect: Bearer, got: %v %+v", err, client.BasicAuthRS)
+	}
+	result, err := client.GeoSearchFor(geo)
+	if err != nil {
+		t.Fatalf("GeoSearchFor replay expect: nil, got: %v", err)
+	}
+	if hits := result.GeoSearchRS.GeoSearchResults.GeoSearchResult; len(hits) != 1 || hits[0].ID != "42006" {
+		t.Errorf("GeoSearchResult expect: 42006, got: %+v", hits)
+	}
+}
+
+func TestReplayMissing(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "none.json"), Replay, nil)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("New Replay missing cassette expect: ErrNotExist, got: %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	a := `<eb:MessageId>mid:20190301-1@z.com</eb:MessageId><eb:Timestamp>2019-03-01T10:00:00Z</eb:Timestamp><wsse:BinarySecurityToken valueType="String">Shared/IDL:A!1!0</wsse:BinarySecurityToken><OTA_HotelResRQ TimeStamp="2019-03-01T10:00:00Z"><Ref>1</Ref>`
+	b := `<eb:MessageId>mid:20190301-2@z.com</eb:MessageId><eb:Timestamp>2019-03-01T10:05:00Z</eb:Timestamp><wsse:BinarySecurityToken valueType="String">Shared/IDL:B!2!0</wsse:BinarySecurityToken><OTA_HotelResRQ TimeStamp="2019-03-01T10:00:01Z"><Ref>1</Ref>`
+	if normalize(a) != normalize(b) {
+		t.Errorf("normalize expect: equal, got: %s != %s", normalize(a), normalize(b))
+	}
+	if c := strings.Replace(b, "<Ref>1", "<Ref>2", 1); normalize(a) == normalize(c) {
+		t.Error("normalize expect: payload differences kept, got: equal")
+	}
+}
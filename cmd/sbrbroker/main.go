@@ -0,0 +1,108 @@
+/*
+Command sbrbroker owns one Sabre session pool and shares it with worker processes on the same host, see package soap/srvc/broker.
+
+	SABRE_USERNAME=7971 SABRE_PASSWORD=... sbrbroker -pcc 7TZA -url https://webservices.havail.sabre.com -size 10 -listen unix:/run/sbrbroker.sock
+
+Workers connect with broker.NewClient("unix:/run/sbrbroker.sock"). With -store and SABRE_SESSION_KEY (hex, 32 bytes) sessions survive restarts of the broker.
+*/
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ailgroup/sbrweb/soap/srvc"
+	"github.com/ailgroup/sbrweb/soap/srvc/broker"
+)
+
+func main() {
+	listen := flag.String("listen", "unix:/tmp/sbrbroker.sock", "unix:/path/to.sock or host:port, keep TCP on localhost")
+	serviceURL := flag.String("url", "https://webservices.havail.sabre.com", "Sabre web services URL")
+	pcc := flag.String("pcc", "", "pseudo city code")
+	from := flag.String("from", "sbrbroker", "From party and conversation id")
+	size := flag.Int("size", 5, "pool size")
+	max := flag.Int("max", 0, "scale up to this many sessions under load, 0 keeps size")
+	cycle := flag.Duration("cycle", 5*time.Minute, "keepalive cycle")
+	maxLease := flag.Duration("max-lease", 10*time.Minute, "reclaim sessions held longer, covers workers that die holding a session")
+	maxWait := flag.Duration("max-wait", broker.DefaultMaxWait, "bound on a single pick request")
+	store := flag.String("store", "", "file to persist sessions across restarts, needs SABRE_SESSION_KEY")
+	flag.Parse()
+
+	if *pcc == "" || os.Getenv("SABRE_USERNAME") == "" || os.Getenv("SABRE_PASSWORD") == "" {
+		log.Fatal("sbrbroker needs -pcc, SABRE_USERNAME and SABRE_PASSWORD")
+	}
+	conf := &srvc.SessionConf{
+		ServiceURL: *serviceURL,
+		From:       *from,
+		PCC:        *pcc,
+		Convid:     *from,
+		Username:   os.Getenv("SABRE_USERNAME"),
+		Password:   os.Getenv("SABRE_PASSWORD"),
+	}
+	pool := srvc.NewPool(srvc.ExpireScheme{Min: 5, Max: 10}, conf, *cycle, *size)
+	pool.MaxLease = *maxLease
+	pool.Scale.Max = *max
+	pool.Scale.PickWait = time.Second
+	pool.Scale.IdleAfter = 30 * time.Minute
+	if *store != "" {
+		key, err := hex.DecodeString(os.Getenv("SABRE_SESSION_KEY"))
+		if err != nil {
+			log.Fatalf("SABRE_SESSION_KEY: %v", err)
+		}
+		fs, err := srvc.NewFileStore(*store, key)
+		if err != nil {
+			log.Fatalf("session store: %v", err)
+		}
+		pool.Store = fs
+	}
+
+	network, addr := "tcp", *listen
+	if strings.HasPrefix(addr, "unix:") {
+		network, addr = "unix", strings.TrimPrefix(addr, "unix:")
+		_ = os.Remove(addr)
+	}
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if network == "unix" {
+		_ = os.Chmod(addr, 0660)
+	}
+
+	if err := pool.Populate(); err != nil {
+		log.Printf("populate: %v", err)
+	}
+	go pool.Keepalive()
+
+	bs := broker.NewServer(pool)
+	bs.MaxWait = *maxWait
+	server := &http.Server{Handler: bs}
+	go func() {
+		if err := server.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	log.Printf("sbrbroker serving PCC=%s size=%d on %s", *pcc, *size, *listen)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdown, cancel := context.WithTimeout(context.Background(), srvc.DefaultShutdownTimeout)
+	defer cancel()
+	//keep serving while the pool shuts down: picks fail but workers can still put leased sessions back
+	if err := pool.Shutdown(shutdown); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	_ = server.Shutdown(shutdown)
+}
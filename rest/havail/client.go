@@ -30,12 +30,21 @@ type BasicAuthRS struct {
 // SabreClient contains values for a common client to Sabre rest endpoints.
 type SabreClient struct {
 	BasicAuthRS BasicAuthRS
+	HTTPClient  *http.Client //optional, inject proxies, timeouts or test round trippers; a new http.Client when nil
 	ticker      *time.Ticker
 }
 
+// httpClient helper to return a non nil http client
+func (s *SabreClient) httpClient() *http.Client {
+	if s.HTTPClient == nil {
+		return &http.Client{}
+	}
+	return s.HTTPClient
+}
+
 // GetBasicAuthToken requests a 7day access_token from sabre
 func (s *SabreClient) SetAccessToken() error {
-	httpClient := &http.Client{}
+	httpClient := s.httpClient()
 	data := url.Values{}
 	data.Set("grant_type", "client_credentials")
 	req, err := http.NewRequest("POST", v2DevAuthTokenURL, strings.NewReader(data.Encode()))
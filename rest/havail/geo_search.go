@@ -93,7 +93,7 @@ type GeoSearchResponse struct {
 //func (c *SabreClient) GeoSearchFor(ref GeoRef) (GeoSearchRS, error) {
 func (c *SabreClient) GeoSearchFor(srch GeoSearchRequest) (*GeoSearchResponse, error) {
 	fmt.Printf("\n\nGEO-REF %+v \n\n", srch)
-	httpClient := &http.Client{}
+	httpClient := c.httpClient()
 	reqByte, _ := json.Marshal(srch)
 	fmt.Printf("\n\nJSON_MARSHAL %s \n\n", reqByte)
 	req, _ := http.NewRequest(
@@ -104,6 +104,9 @@ func (c *SabreClient) GeoSearchFor(srch GeoSearchRequest) (*GeoSearchResponse, e
 	req.Header.Add("Authorization", strings.Join([]string{c.BasicAuthRS.TokenType, c.BasicAuthRS.AccessToken}, " "))
 	req.Header.Add("Content-Type", "application/json")
 	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
 	body, _ := ioutil.ReadAll(resp.Body)
 
 	geo := &GeoSearchResponse{}
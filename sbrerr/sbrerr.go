@@ -20,18 +20,22 @@ const (
 )
 
 const (
-	ErrCallSessionCreate   = "Error CallSessionCreate::SessionCreateRQ"
-	ErrCallSessionClose    = "Error CallSessionClose::SessionCloseRQ"
-	ErrCallSessionValidate = "Error CallSessionValidate::SessionValidateRQ"
-	ErrCallHotelAvail      = "Error CallHotelAvail::OTA_HotelAvailLLSRQ"
-	ErrCallHotelPropDesc   = "Error CallHotelPropDesc::HotelPropertyDescriptionLLSRQ"
-	ErrCallHotelRateDesc   = "Error CallHotelRateDesc::HotelRateDescriptionLLSRQ"
-	ErrCallHotelRes        = "Error CallHotelRes::OTA_HotelResLLSRQ"
-	ErrCallPNRDetails      = "Error CallPNRDetails::PassengerDetailsRQ"
-	ErrCallEndTransaction  = "Error CallEndTransaction::EndTransactionLLSRQ"
-	ErrCallProfileToPNR    = "Error CallProfileToPNR::EPS_ProfileToPNRRQ"
-	ErrCallGetReservation  = "Error CallGetReservation::GetReservationRQ"
-	ErrCallMiscSegment     = "Error CallMiscSegment::MiscSegmentSellLLSRQ"
+	ErrCallSessionCreate     = "Error CallSessionCreate::SessionCreateRQ"
+	ErrCallSessionClose      = "Error CallSessionClose::SessionCloseRQ"
+	ErrCallSessionValidate   = "Error CallSessionValidate::SessionValidateRQ"
+	ErrCallHotelAvail        = "Error CallHotelAvail::OTA_HotelAvailLLSRQ"
+	ErrCallHotelPropDesc     = "Error CallHotelPropDesc::HotelPropertyDescriptionLLSRQ"
+	ErrCallHotelRateDesc     = "Error CallHotelRateDesc::HotelRateDescriptionLLSRQ"
+	ErrCallHotelRes          = "Error CallHotelRes::OTA_HotelResLLSRQ"
+	ErrCallPNRDetails        = "Error CallPNRDetails::PassengerDetailsRQ"
+	ErrCallEndTransaction    = "Error CallEndTransaction::EndTransactionLLSRQ"
+	ErrCallProfileToPNR      = "Error CallProfileToPNR::EPS_ProfileToPNRRQ"
+	ErrCallGetReservation    = "Error CallGetReservation::GetReservationRQ"
+	ErrCallMiscSegment       = "Error CallMiscSegment::MiscSegmentSellLLSRQ"
+	ErrCallIgnoreTransaction = "Error CallIgnoreTransaction::IgnoreTransactionLLSRQ"
+	ErrCallTokenCreate       = "Error CallTokenCreate::TokenCreateRQ"
+	ErrCallContextChange     = "Error CallContextChange::ContextChangeLLSRQ"
+	ErrCallSabreCommand      = "Error CallSabreCommand::SabreCommandLLSRQ"
 )
 
 var (
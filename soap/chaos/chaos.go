@@ -0,0 +1,322 @@
+/*
+Package chaos injects faults into Sabre SOAP traffic for tests: latency, connection resets, HTTP 5xx error pages, truncated (malformed) XML, SOAP faults and invalid token faults. RoundTripper wraps the real http.RoundTripper, or a sabresim.Sim, and picks faults per MessageHeader.Action either by probability or from a script.
+
+	rt := chaos.New(sim, 1)
+	rt.Inject("SessionValidateRQ", 0.2, chaos.Latency(3*time.Second))   //slow validates
+	rt.Script("OTA_HotelResLLSRQ", chaos.Truncated(), nil, chaos.Status(502)) //1st truncated, 2nd ok, 3rd bad gateway
+	rt.Inject(chaos.AnyAction, 1, chaos.InvalidToken())                 //weekly session flush
+	pool.Transport = rt.Transport()
+
+Scripts take precedence over probabilistic rules; once a script runs out the action goes back to its rules.
+*/
+package chaos
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+// AnyAction matches every request in Inject and Script.
+const AnyAction = ""
+
+var (
+	actionMatcher = regexp.MustCompile(`<eb:Action>([^<]*)</eb:Action>`)
+	convMatcher   = regexp.MustCompile(`<eb:ConversationId>([^<]*)</eb:ConversationId>`)
+	tokenMatcher  = regexp.MustCompile(`<wsse:BinarySecurityToken[^>]*>([^<]*)</wsse:BinarySecurityToken>`)
+)
+
+// Fault handles one exchange instead of, or around, next.
+type Fault func(req *http.Request, next http.RoundTripper) (*http.Response, error)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain runs faults in order, each one wrapping the rest, e.g., Chain(Latency(time.Second), Status(503)) is a slow error page.
+func Chain(faults ...Fault) Fault {
+	return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		if len(faults) == 0 {
+			return next.RoundTrip(req)
+		}
+		return faults[0](req, roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			return Chain(faults[1:]...)(r, next)
+		}))
+	}
+}
+
+// Latency delays the request by d before sending it on; it gives up early when the request context is done.
+func Latency(d time.Duration) Fault {
+	return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		wait := time.NewTimer(d)
+		defer wait.Stop()
+		select {
+		case <-wait.C:
+			return next.RoundTrip(req)
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// Reset fails the request with a connection reset by peer, without sending it.
+func Reset() Fault {
+	return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	}
+}
+
+// Status answers with an HTML error page and status code, as load balancers do during outages.
+func Status(code int) Fault {
+	return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		text := http.StatusText(code)
+		page := fmt.Sprintf("<html><head><title>%d %s</title></head><body><h1>%s</h1></body></html>", code, text, text)
+		return response(req, code, "text/html; charset=utf-8", []byte(page)), nil
+	}
+}
+
+// Truncated sends the request on and cuts the response body in half, leaving malformed XML.
+func Truncated() Fault {
+	return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return resp, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = body[:len(body)/2]
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.Header.Del("Content-Length")
+		return resp, nil
+	}
+}
+
+// SOAPFault answers with a soap fault envelope and status 500; the MessageHeader action is srvc.StatusErrorRS, as from Sabre.
+func SOAPFault(code, msg, stackTrace string) Fault {
+	return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		conv := ""
+		if m := convMatcher.FindSubmatch(requestBody(req)); m != nil {
+			conv = string(m[1])
+		}
+		return response(req, http.StatusInternalServerError, "text/xml; charset=utf-8", faultEnvelope(conv, code, msg, stackTrace)), nil
+	}
+}
+
+// InvalidToken answers with the fault Sabre sends for an expired or flushed BinarySecurityToken, see srvc.IsSessionDead.
+func InvalidToken() Fault {
+	return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		token := ""
+		if m := tokenMatcher.FindSubmatch(requestBody(req)); m != nil {
+			token = string(m[1])
+		}
+		return SOAPFault(
+			"soap-env:Client.InvalidSecurityToken",
+			"Invalid or Expired binary security token: "+token,
+			"com.sabre.universalservices.base.security.AuthenticationException: errors.session.USG_INVALID_SECURITY_TOKEN",
+		)(req, next)
+	}
+}
+
+func faultEnvelope(conv, code, msg, stackTrace string) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<soap-env:Envelope xmlns:soap-env="` + srvc.BaseNS + `"><soap-env:Header><eb:MessageHeader xmlns:eb="` + srvc.BaseEBNameSpace + `" eb:version="` + srvc.SabreEBVersion + `"><eb:ConversationId>`)
+	_ = xml.EscapeText(&b, []byte(conv))
+	b.WriteString(`</eb:ConversationId><eb:Action>` + srvc.StatusErrorRS + `</eb:Action></eb:MessageHeader></soap-env:Header><soap-env:Body><soap-env:Fault><faultcode>`)
+	_ = xml.EscapeText(&b, []byte(code))
+	b.WriteString(`</faultcode><faultstring>`)
+	_ = xml.EscapeText(&b, []byte(msg))
+	b.WriteString(`</faultstring><detail><StackTrace>`)
+	_ = xml.EscapeText(&b, []byte(stackTrace))
+	b.WriteString(`</StackTrace></detail></soap-env:Fault></soap-env:Body></soap-env:Envelope>`)
+	return b.Bytes()
+}
+
+func response(req *http.Request, status int, contentType string, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{contentType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// requestBody bytes of req, left readable for the next round tripper.
+func requestBody(req *http.Request) []byte {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, _ := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// rule is a probabilistic injection for an action.
+type rule struct {
+	action      string
+	probability float64
+	fault       Fault
+}
+
+// script faults for the next requests of an action, a nil fault lets a request through.
+type script struct {
+	action string
+	faults []Fault
+}
+
+/*
+RoundTripper injects faults into the requests it forwards to Next; safe for concurrent use. Seed makes probabilistic injection repeatable for a given order of requests.
+*/
+type RoundTripper struct {
+	Next http.RoundTripper //http.DefaultTransport when nil
+
+	mu       sync.Mutex
+	rnd      *rand.Rand
+	rules    []rule
+	scripts  []*script
+	calls    map[string]int
+	injected map[string]int
+}
+
+// New RoundTripper forwarding to next, seeding its random source with seed.
+func New(next http.RoundTripper, seed int64) *RoundTripper {
+	return &RoundTripper{
+		Next:     next,
+		rnd:      rand.New(rand.NewSource(seed)),
+		calls:    make(map[string]int),
+		injected: make(map[string]int),
+	}
+}
+
+// Transport for srvc posting through rt.
+func (rt *RoundTripper) Transport() *srvc.Transport {
+	return srvc.NewTransport(&http.Client{Transport: rt})
+}
+
+// Inject f into requests for action with probability p (0 to 1); rules are tried in the order added and the first hit wins.
+func (rt *RoundTripper) Inject(action string, p float64, f Fault) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.rules = append(rt.rules, rule{action: action, probability: p, fault: f})
+}
+
+// Script the next requests for action: the nth request gets faults[n], nil lets it through.
+func (rt *RoundTripper) Script(action string, faults ...Fault) {
+	if len(faults) == 0 {
+		return
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.scripts = append(rt.scripts, &script{action: action, faults: faults})
+}
+
+// Clear every rule and script, counts are kept.
+func (rt *RoundTripper) Clear() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.rules, rt.scripts = nil, nil
+}
+
+// Calls seen for action, every action for AnyAction.
+func (rt *RoundTripper) Calls(action string) int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return count(rt.calls, action)
+}
+
+// Injected faults for action, every action for AnyAction.
+func (rt *RoundTripper) Injected(action string) int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return count(rt.injected, action)
+}
+
+func count(m map[string]int, action string) int {
+	if action != AnyAction {
+		return m[action]
+	}
+	n := 0
+	for _, c := range m {
+		n += c
+	}
+	return n
+}
+
+func (rt *RoundTripper) next() http.RoundTripper {
+	if rt.Next == nil {
+		return http.DefaultTransport
+	}
+	return rt.Next
+}
+
+// RoundTrip forwards req, or hands it to the fault picked for its action.
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	action := ""
+	if m := actionMatcher.FindSubmatch(requestBody(req)); m != nil {
+		action = string(m[1])
+	}
+	f := rt.pick(action)
+	if f == nil {
+		return rt.next().RoundTrip(req)
+	}
+	return f(req, rt.next())
+}
+
+// pick the fault for action and count the call.
+func (rt *RoundTripper) pick(action string) Fault {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.calls[action]++
+	f, ok := rt.scripted(action)
+	if !ok {
+		for _, r := range rt.rules {
+			if (r.action == AnyAction || r.action == action) && rt.rnd.Float64() < r.probability {
+				f = r.fault
+				break
+			}
+		}
+	}
+	if f != nil {
+		rt.injected[action]++
+	}
+	return f
+}
+
+// scripted pops the next scripted fault for action, nil when the step lets the request through; ok is false when no script is left for action.
+func (rt *RoundTripper) scripted(action string) (f Fault, ok bool) {
+	for i, s := range rt.scripts {
+		if s.action != AnyAction && s.action != action {
+			continue
+		}
+		f = s.faults[0]
+		s.faults = s.faults[1:]
+		if len(s.faults) == 0 {
+			rt.scripts = append(rt.scripts[:i:i], rt.scripts[i+1:]...)
+		}
+		return f, true
+	}
+	return nil, false
+}
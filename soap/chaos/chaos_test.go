@@ -0,0 +1,234 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+	"github.com/ailgroup/sbrweb/soap/htlsp"
+	"github.com/ailgroup/sbrweb/soap/sabresim"
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+func actionRequest(action string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, sabresim.DefaultURL, strings.NewReader("<eb:Action>"+action+"</eb:Action>"))
+	return req
+}
+
+func TestScriptRetry(t *testing.T) {
+	rt := New(sabresim.New(sabresim.Config{}), 1)
+	tr := rt.Transport()
+	tr.Retry = &srvc.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	ctx := srvc.WithTransport(context.Background(), tr)
+	conf := sabresim.Conf(sabresim.DefaultURL)
+	binsec, err := sabresim.CreateSession(ctx, conf)
+	if err != nil {
+		t.Fatalf("CreateSession expect: nil, got: %v", err)
+	}
+
+	rt.Script("SessionValidateRQ", Chain(Latency(time.Millisecond), Status(http.StatusServiceUnavailable)), Reset())
+	rs, err := srvc.CallSessionValidateContext(ctx, sabresim.DefaultURL, srvc.BuildSessionValidateRequest(conf, binsec))
+	if err != nil || rs.Body.SessionValidateRS.Status != "Approved" {
+		t.Fatalf("SessionValidate after retries expect: Approved, got: %v %s", err, rs.Body.SessionValidateRS.Status)
+	}
+	if n := rt.Calls("SessionValidateRQ"); n != 3 {
+		t.Errorf("Calls expect: 3, got: %d", n)
+	}
+	if n := rt.Injected("SessionValidateRQ"); n != 2 {
+		t.Errorf("Injected expect: 2, got: %d", n)
+	}
+	if n := rt.Calls(AnyAction); n != 4 {
+		t.Errorf("Calls any expect: 4, got: %d", n)
+	}
+
+	//script ran out, validate goes through untouched
+	if _, err = srvc.CallSessionValidateContext(ctx, sabresim.DefaultURL, srvc.BuildSessionValidateRequest(conf, binsec)); err != nil {
+		t.Errorf("SessionValidate after script expect: nil, got: %v", err)
+	}
+	if n := rt.Injected(AnyAction); n != 2 {
+		t.Errorf("Injected any expect: 2, got: %d", n)
+	}
+}
+
+func TestInvalidToken(t *testing.T) {
+	rt := New(sabresim.New(sabresim.Config{}), 1)
+	ctx := srvc.WithTransport(context.Background(), rt.Transport())
+	conf := sabresim.Conf(sabresim.DefaultURL)
+	binsec, err := sabresim.CreateSession(ctx, conf)
+	if err != nil {
+		t.Fatalf("CreateSession expect: nil, got: %v", err)
+	}
+
+	rt.Inject(AnyAction, 1, InvalidToken())
+	rs, _ := srvc.CallSessionValidateContext(ctx, sabresim.DefaultURL, srvc.BuildSessionValidateRequest(conf, binsec))
+	if !rs.Body.Fault.SessionDead() {
+		t.Errorf("SessionDead expect: true, got: false for %v", rs.Body.Fault.Format())
+	}
+	if rs.Header.MessageHeader.Action != srvc.StatusErrorRS {
+		t.Errorf("Action expect: %s, got: %s", srvc.StatusErrorRS, rs.Header.MessageHeader.Action)
+	}
+	if !strings.Contains(rs.Body.Fault.String, binsec) {
+		t.Errorf("Fault.String expect: token %s, got: %s", binsec, rs.Body.Fault.String)
+	}
+
+	q, _ := htlsp.NewHotelSearchCriteria(htlsp.HotelRefSearch(htlsp.HotelRefCriterion{htlsp.CityQueryField: []string{"DFW"}}))
+	_, err = htlsp.CallHotelAvailContext(ctx, sabresim.DefaultURL, htlsp.BuildHotelAvailRequest(conf, binsec, htlsp.SetHotelAvailBody(1, q, "06-07", "06-09")))
+	if !srvc.IsSessionDead(err) {
+		t.Errorf("IsSessionDead expect: true, got: false for %v", err)
+	}
+}
+
+func TestSessionFlushDo(t *testing.T) {
+	sim := sabresim.New(sabresim.Config{})
+	rt := New(sim, 1)
+	conf := sabresim.Conf(sabresim.DefaultURL)
+	pool := srvc.NewPool(srvc.ExpireScheme{Min: 10, Max: 14}, conf, time.Minute, 1)
+	pool.Transport = rt.Transport()
+	if err := pool.Populate(); err != nil {
+		t.Fatalf("Populate expect: nil, got: %v", err)
+	}
+	defer pool.Close()
+
+	//Sabre flushed the session between keepalives, Do replaces it and tries again
+	rt.Script("OTA_HotelAvailLLSRQ", InvalidToken())
+	ctx := srvc.WithTransport(context.Background(), pool.Transport)
+	q, _ := htlsp.NewHotelSearchCriteria(htlsp.HotelRefSearch(htlsp.HotelRefCriterion{htlsp.CityQueryField: []string{"DFW"}}))
+	tokens := []string{}
+	err := pool.Do(ctx, func(sess srvc.Session) error {
+		tokens = append(tokens, sess.BinSecTokCached)
+		_, err := htlsp.CallHotelAvailContext(ctx, sabresim.DefaultURL, htlsp.BuildHotelAvailRequest(conf, sess.BinSecTokCached, htlsp.SetHotelAvailBody(1, q, "06-07", "06-09")))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Do expect: nil, got: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0] == tokens[1] {
+		t.Errorf("Do sessions expect: 2 different tokens, got: %v", tokens)
+	}
+	if n := rt.Calls("OTA_HotelAvailLLSRQ"); n != 2 {
+		t.Errorf("Calls expect: 2, got: %d", n)
+	}
+	if n := rt.Calls("SessionCreateRQ"); n != 2 {
+		t.Errorf("SessionCreateRQ calls expect: 2, got: %d", n)
+	}
+}
+
+func TestLatencyDeadline(t *testing.T) {
+	rt := New(sabresim.New(sabresim.Config{}), 1)
+	rt.Inject("SessionCreateRQ", 1, Latency(time.Minute))
+	ctx, cancel := context.WithTimeout(srvc.WithTransport(context.Background(), rt.Transport()), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := srvc.CallSessionCreateContext(ctx, sabresim.DefaultURL, srvc.BuildSessionCreateRequest(sabresim.Conf(sabresim.DefaultURL)))
+	var service sbrerr.ErrorSabreService
+	if !errors.As(err, &service) {
+		t.Errorf("CallSessionCreateContext expect: ErrorSabreService, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Latency expect: cut short by deadline, got: %v", elapsed)
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	rt := New(sabresim.New(sabresim.Config{}), 1)
+	rt.Script("SessionCreateRQ", Truncated())
+	ctx := srvc.WithTransport(context.Background(), rt.Transport())
+
+	_, err := srvc.CallSessionCreateContext(ctx, sabresim.DefaultURL, srvc.BuildSessionCreateRequest(sabresim.Conf(sabresim.DefaultURL)))
+	var parse sbrerr.ErrorSabreXML
+	if !errors.As(err, &parse) {
+		t.Errorf("CallSessionCreateContext expect: ErrorSabreXML, got: %v", err)
+	}
+	if _, err = sabresim.CreateSession(ctx, sabresim.Conf(sabresim.DefaultURL)); err != nil {
+		t.Errorf("CreateSession after truncated expect: nil, got: %v", err)
+	}
+}
+
+func TestFaultResponses(t *testing.T) {
+	ok := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return response(req, http.StatusOK, "text/xml", []byte("<ok/>")), nil
+	})
+	sample := []struct {
+		fault  Fault
+		status int
+		body   string
+	}{
+		{Status(http.StatusBadGateway), http.StatusBadGateway, "<h1>Bad Gateway</h1>"},
+		{SOAPFault("soap-env:Server.Busy", "a < b & c", "trace"), http.StatusInternalServerError, "<faultstring>a &lt; b &amp; c</faultstring>"},
+		{Chain(), http.StatusOK, "<ok/>"},
+	}
+	for _, s := range sample {
+		resp, err := s.fault(actionRequest("SessionValidateRQ"), ok)
+		if err != nil {
+			t.Fatalf("fault expect: response, got: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != s.status || !bytes.Contains(body, []byte(s.body)) {
+			t.Errorf("response expect: %d %s, got: %d %s", s.status, s.body, resp.StatusCode, body)
+		}
+	}
+
+	_, err := Reset()(actionRequest("SessionValidateRQ"), ok)
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("Reset expect: connection reset, got: %v", err)
+	}
+}
+
+func TestInjectSeeded(t *testing.T) {
+	ok := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return response(req, http.StatusOK, "text/xml", nil), nil
+	})
+	run := func() []int {
+		rt := New(ok, 7)
+		rt.Inject("SessionValidateRQ", 0.5, Status(http.StatusBadGateway))
+		codes := []int{}
+		for i := 0; i < 200; i++ {
+			resp, _ := rt.RoundTrip(actionRequest("SessionValidateRQ"))
+			codes = append(codes, resp.StatusCode)
+		}
+		//other actions never hit
+		if resp, _ := rt.RoundTrip(actionRequest("SessionCreateRQ")); resp.StatusCode != http.StatusOK {
+			t.Errorf("SessionCreateRQ expect: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+		if n := rt.Injected("SessionValidateRQ"); n < 60 || n > 140 {
+			t.Errorf("Injected expect: about 100 of 200, got: %d", n)
+		}
+		return codes
+	}
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("same seed expect: same faults, got: differ at request %d", i)
+		}
+	}
+}
+
+func TestScriptOverInject(t *testing.T) {
+	ok := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return response(req, http.StatusOK, "text/xml", nil), nil
+	})
+	rt := New(ok, 1)
+	rt.Inject(AnyAction, 1, Status(http.StatusBadGateway))
+	rt.Script("SessionValidateRQ", nil, Status(http.StatusServiceUnavailable))
+
+	expect := []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusBadGateway}
+	for i, code := range expect {
+		resp, err := rt.RoundTrip(actionRequest("SessionValidateRQ"))
+		if err != nil {
+			t.Fatalf("request %d expect: response, got: %v", i+1, err)
+		}
+		if resp.StatusCode != code {
+			t.Errorf("request %d expect: %d, got: %d", i+1, code, resp.StatusCode)
+		}
+	}
+	if n := rt.Injected("SessionValidateRQ"); n != 2 {
+		t.Errorf("Injected expect: 2, got: %d", n)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Package cryptic sends Sabre host (cryptic) commands with SabreCommandLLSRQ and parses the screens that come back. It is meant for fallbacks not covered by a structured service, e.g., displaying a PNR (*A), a hotel description (HOD) or counting queues (QC/).
+
+	c := srvc.NewClient(conf, nil)
+	screen, err := cryptic.RunPaged(ctx, c, sess.BinSecTokCached, "*A", 5)
+	for _, line := range screen.Lines {...}
+
+Commands run in the AAA workspace of the session like any entry made in Sabre Red; a command that changes a PNR needs an EndTransaction or IgnoreTransaction like any other, and none are retried by srvc.Transport.
+*/
+package cryptic
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+const (
+	sabreCommandNS      = "http://webservices.sabre.com/sabreXML/2003/07"
+	sabreCommandVersion = "1.8.1"
+	outputScreen        = "SCREEN"
+)
+
+// HostCommandElem holds the cryptic entry and how the screen should be returned.
+type HostCommandElem struct {
+	Output      string `xml:"Output,attr"`
+	CDATA       bool   `xml:"CDATA,attr"`
+	HostCommand string `xml:"HostCommand"`
+}
+
+// SabreCommandRQ root element
+type SabreCommandRQ struct {
+	XMLName           xml.Name        `xml:"SabreCommandLLSRQ"`
+	XMLNS             string          `xml:"xmlns,attr"`
+	ReturnHostCommand bool            `xml:"ReturnHostCommand,attr"`
+	Version           string          `xml:"Version,attr"`
+	Request           HostCommandElem `xml:"Request"`
+}
+
+// SabreCommandRS root element, Response is the raw screen
+type SabreCommandRS struct {
+	XMLName  xml.Name `xml:"SabreCommandLLSRS"`
+	Version  string   `xml:"Version,attr"`
+	Response string   `xml:"Response"`
+	Errors   []struct {
+		Code     string `xml:"ErrorCode,attr"`
+		Severity string `xml:"Severity,attr"`
+		Message  string `xml:"ErrorMessage,attr"`
+		Info     string `xml:"ErrorInfo>Message"`
+	} `xml:"Errors>Error"`
+}
+
+// ResultErr for SabreCommandRS, errors mean the command was not run; an error screen is not one of them, see Screen.Err.
+func (r SabreCommandRS) ResultErr() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	var msg string
+	for i, e := range r.Errors {
+		msg += fmt.Sprintf("Error%d:Code-%s:Severity-%s:Msg|%s %s", i, e.Code, e.Severity, e.Message, e.Info)
+	}
+	return sbrerr.NewErrorSabreResult(msg, sbrerr.NotProcessed)
+}
+
+// SabreCommandRequest soap envelope for SabreCommandRQ
+type SabreCommandRequest = srvc.Request[SabreCommandRQ]
+
+// SabreCommandResponse soap envelope for SabreCommandRS
+type SabreCommandResponse = srvc.Response[SabreCommandRS]
+
+var sabreCommandOp = srvc.Operation[SabreCommandRQ, SabreCommandRS]{
+	Service:    srvc.ServiceElem{Value: "SabreCommandLLSRQ", Type: srvc.ServiceTypeSabreXML},
+	Action:     "SabreCommandLLSRQ",
+	AppMessage: sbrerr.ErrCallSabreCommand,
+}
+
+// BuildSabreCommand payload for command.
+func BuildSabreCommand(command string) SabreCommandRQ {
+	return SabreCommandRQ{
+		XMLNS:             sabreCommandNS,
+		ReturnHostCommand: true,
+		Version:           sabreCommandVersion,
+		Request: HostCommandElem{
+			Output:      outputScreen,
+			CDATA:       true,
+			HostCommand: command,
+		},
+	}
+}
+
+// BuildSabreCommandRequest soap envelope running command on session token binsec.
+func BuildSabreCommandRequest(c *srvc.SessionConf, binsec, command string) SabreCommandRequest {
+	return sabreCommandOp.Build(c, binsec, BuildSabreCommand(command))
+}
+
+// Run command on session token binsec and return its screen. The error is from the call, else the error line of the screen (ErrorScreen).
+func Run(ctx context.Context, c *srvc.Client, binsec, command string) (Screen, error) {
+	resp, err := sabreCommandOp.Call(ctx, c, binsec, BuildSabreCommand(command))
+	if err != nil {
+		return Screen{Command: command}, err
+	}
+	s := NewScreen(command, resp.Body.Payload.Response)
+	return s, s.Err()
+}
+
+// RunPaged is Run followed by MoveDown while the screen has more, up to maxPages screens in all, joined into one Screen.
+func RunPaged(ctx context.Context, c *srvc.Client, binsec, command string, maxPages int) (Screen, error) {
+	s, err := Run(ctx, c, binsec, command)
+	for page := 1; err == nil && s.More() && page < maxPages; page++ {
+		var next Screen
+		next, err = Run(ctx, c, binsec, MoveDown)
+		s = s.Append(next)
+	}
+	return s, err
+}
@@ -0,0 +1,121 @@
+package cryptic
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+var (
+	sampleConf = &srvc.SessionConf{
+		From:     "www.z.com",
+		PCC:      "7TZA",
+		Convid:   "fds8789h|dev@z.com",
+		Username: "773400",
+		Password: "PASSWORD_GOES_HER",
+	}
+	samplebinsectoken = `Shared/IDL:IceSess\/SessMgr:1\.0.IDL/Common/!ICESMS\/RESE!ICESMSLB\/RES.LB!-3177016070087638144!110012!0`
+
+	sampleSabreCommandRS = `<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="1.0" soap-env:mustUnderstand="1"><eb:CPAId>7TZA</eb:CPAId><eb:Action>SabreCommandLLSRS</eb:Action></eb:MessageHeader></soap-env:Header><soap-env:Body><SabreCommandLLSRS xmlns="http://webservices.sabre.com/sabreXML/2003/07" Version="1.8.1"><Response><![CDATA[%s]]></Response></SabreCommandLLSRS></soap-env:Body></soap-env:Envelope>`
+
+	sampleSabreCommandRSError = []byte(`<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Body><SabreCommandLLSRS xmlns="http://webservices.sabre.com/sabreXML/2003/07" Version="1.8.1"><Errors><Error ErrorCode="ERR.SWS.HOST.ERROR_IN_RESPONSE" Severity="High" ErrorMessage="Host error"><ErrorInfo><Message>NO HOST CONNECTION</Message></ErrorInfo></Error></Errors></SabreCommandLLSRS></soap-env:Body></soap-env:Envelope>`)
+
+	sampleHostCommandReg = regexp.MustCompile(`<HostCommand>([^<]*)</HostCommand>`)
+)
+
+// serverScreens answers each host command with its screen, recording the commands.
+func serverScreens(screens map[string]string, got *[]string) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				b, _ := io.ReadAll(rq.Body)
+				cmd := string(sampleHostCommandReg.FindSubmatch(b)[1])
+				*got = append(*got, cmd)
+				_, _ = fmt.Fprintf(rs, sampleSabreCommandRS, screens[cmd])
+			},
+		),
+	)
+}
+
+func TestBuildSabreCommandRequest(t *testing.T) {
+	b, err := xml.Marshal(BuildSabreCommandRequest(sampleConf, samplebinsectoken, "*A"))
+	if err != nil {
+		t.Error("Error marshal sabre command", err)
+	}
+	for _, want := range []string{
+		`<eb:Service eb:type="sabreXML">SabreCommandLLSRQ</eb:Service>`,
+		`<eb:Action>SabreCommandLLSRQ</eb:Action>`,
+		`<soap-env:Body><SabreCommandLLSRQ xmlns="http://webservices.sabre.com/sabreXML/2003/07" ReturnHostCommand="true" Version="1.8.1"><Request Output="SCREEN" CDATA="true"><HostCommand>*A</HostCommand></Request></SabreCommandLLSRQ></soap-env:Body>`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("SabreCommand request expect to contain: %s\n got: %s", want, b)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	var got []string
+	server := serverScreens(map[string]string{"QC/": "\nQUEUE COUNT\n 100     12\n", "HOD": sampleScreenFormat}, &got)
+	defer server.Close()
+	c := srvc.NewClient(&srvc.SessionConf{ServiceURL: server.URL, PCC: "7TZA"}, nil)
+
+	s, err := Run(context.Background(), c, samplebinsectoken, "QC/")
+	if err != nil {
+		t.Error("Run should not return error", err)
+	}
+	if s.Command != "QC/" || len(s.Lines) != 2 || s.Lines[1] != " 100     12" {
+		t.Errorf("Run screen expect: %s, got: %q", "QUEUE COUNT", s.Lines)
+	}
+
+	s, err = Run(context.Background(), c, samplebinsectoken, "HOD")
+	var es ErrorScreen
+	if !errors.As(err, &es) || len(s.Lines) != 1 || s.Lines[0] != "¥FORMAT¥" {
+		t.Errorf("Run error screen expect: %T with screen, got: %v %q", es, err, s.Lines)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rs http.ResponseWriter, rq *http.Request) {
+		_, _ = rs.Write(sampleSabreCommandRSError)
+	}))
+	defer server.Close()
+	c := srvc.NewClient(&srvc.SessionConf{ServiceURL: server.URL, PCC: "7TZA"}, nil)
+
+	_, err := Run(context.Background(), c, samplebinsectoken, "*A")
+	var resErr sbrerr.ErrorSabreResult
+	if !errors.As(err, &resErr) || !strings.Contains(resErr.AppMessage, "NO HOST CONNECTION") {
+		t.Errorf("Run expect: %T with host message, got: %T %v", resErr, err, err)
+	}
+}
+
+func TestRunPaged(t *testing.T) {
+	var got []string
+	server := serverScreens(map[string]string{"*A": sampleScreenPNR, MoveDown: sampleScreenPNRPage2}, &got)
+	defer server.Close()
+	c := srvc.NewClient(&srvc.SessionConf{ServiceURL: server.URL, PCC: "7TZA"}, nil)
+
+	s, err := RunPaged(context.Background(), c, samplebinsectoken, "*A", 5)
+	if err != nil {
+		t.Error("RunPaged should not return error", err)
+	}
+	if strings.Join(got, ",") != "*A,MD" || len(s.Lines) != 10 {
+		t.Errorf("RunPaged expect: %s and %d lines, got: %v and %d lines", "*A,MD", 10, got, len(s.Lines))
+	}
+
+	//capped at maxPages
+	got = nil
+	s, _ = RunPaged(context.Background(), c, samplebinsectoken, "*A", 1)
+	if len(got) != 1 || !s.More() {
+		t.Errorf("RunPaged maxPages 1 expect: 1 call with More, got: %v More %t", got, s.More())
+	}
+}
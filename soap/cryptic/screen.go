@@ -0,0 +1,141 @@
+package cryptic
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+// MoveDown is the host entry for the next page of a screen.
+const MoveDown = "MD"
+
+var (
+	// MoreMarkers end the last line of a screen continued on the next page, see MoveDown.
+	MoreMarkers = []string{"‡", "¥"}
+
+	// ErrorPrefixes start the first line of common host error screens.
+	ErrorPrefixes = []string{
+		"FORMAT",
+		"INVALID",
+		"NOT AUTH",
+		"UNABLE",
+		"NO ITIN",
+		"NO PNR",
+		"NO DATA",
+		"NO MORE",
+		"RESTRICTED",
+		"VERIFY",
+		"SIGN IN",
+		"CHECK ",
+		"ENTRY ERROR",
+	}
+
+	lineSplitter = regexp.MustCompile(`\r\n|\r|\n`)
+)
+
+// ErrorScreen is returned by Run when the host answers a command with an error line.
+type ErrorScreen struct {
+	Command string
+	Line    string
+}
+
+func (e ErrorScreen) Error() string {
+	return fmt.Sprintf("host command '%s': %s", e.Command, e.Line)
+}
+
+// Screen returned by Sabre for a host command.
+type Screen struct {
+	Command string
+	Raw     string
+	Lines   []string
+}
+
+// NewScreen for the raw response of command.
+func NewScreen(command, raw string) Screen {
+	return Screen{Command: command, Raw: raw, Lines: SplitLines(raw)}
+}
+
+// SplitLines of a raw screen: end of item marks removed, trailing blanks trimmed, leading and trailing blank lines dropped.
+func SplitLines(raw string) []string {
+	raw = strings.ReplaceAll(raw, srvc.ESA, "")
+	lines := lineSplitter.Split(raw, -1)
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// trimMore removes a trailing MoreMarker from line, ok when there was one.
+func trimMore(line string) (string, bool) {
+	for _, m := range MoreMarkers {
+		if strings.HasSuffix(line, m) {
+			return strings.TrimRight(strings.TrimSuffix(line, m), " "), true
+		}
+	}
+	return line, false
+}
+
+// More true when the screen continues on the next page, see MoveDown. Error screens (e.g., ¥FORMAT¥) never do.
+func (s Screen) More() bool {
+	if len(s.Lines) == 0 || s.Err() != nil {
+		return false
+	}
+	_, more := trimMore(s.Lines[len(s.Lines)-1])
+	return more
+}
+
+// Append next page to s, dropping the more marker and the blank line it may leave.
+func (s Screen) Append(next Screen) Screen {
+	lines := append([]string{}, s.Lines...)
+	if n := len(lines); n > 0 {
+		if last, more := trimMore(lines[n-1]); more {
+			lines[n-1] = last
+			if last == "" {
+				lines = lines[:n-1]
+			}
+		}
+	}
+	return Screen{
+		Command: s.Command,
+		Raw:     s.Raw + "\n" + next.Raw,
+		Lines:   append(lines, next.Lines...),
+	}
+}
+
+// Err is ErrorScreen when the first line of the screen is a host error (see ErrorPrefixes), nil otherwise.
+func (s Screen) Err() error {
+	if len(s.Lines) == 0 {
+		return nil
+	}
+	first, _ := trimMore(strings.TrimLeft(s.Lines[0], "‡¥* "))
+	for _, p := range ErrorPrefixes {
+		if strings.HasPrefix(first, p) {
+			return ErrorScreen{Command: s.Command, Line: s.Lines[0]}
+		}
+	}
+	return nil
+}
+
+// Find lines of the screen matching re with their submatches.
+func (s Screen) Find(re *regexp.Regexp) [][]string {
+	var found [][]string
+	for _, l := range s.Lines {
+		if m := re.FindStringSubmatch(l); m != nil {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// String of the screen, one line each.
+func (s Screen) String() string {
+	return strings.Join(s.Lines, "\n")
+}
@@ -0,0 +1,85 @@
+package cryptic
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	sampleScreenPNR = "\n 1.1SMITH/JOHN MR\n 1 OTH YY 18MAY F GK1  XXX/RETENTION\n 2 HHL HI GK1 AUS IN18MAY-OUT20MAY   2NT 12345 HOLIDAY INN 1K1KRAC\n        109.00USD/BC-JD/AGT12345678/SI-@CXL 01 DAY PRIOR ARRIVAL@/CF-\n‡  \n"
+
+	sampleScreenPNRPage2 = "TKT/TIME LIMIT\n  1.TAW7TZA18MAY/\nPHONES\n  1.AUS512-555-1212-A\nRECEIVED FROM - WEB\n7TZA.7TZA*AWS 1045/15MAY18 ABCDEF H\n"
+
+	sampleScreenFormat = "¥FORMAT¥\r\n"
+
+	sampleScreenLinesTests = []struct {
+		name  string
+		raw   string
+		lines int
+		more  bool
+		err   bool
+	}{
+		{"pnr first page", sampleScreenPNR, 5, true, false},
+		{"pnr last page", sampleScreenPNRPage2, 6, false, false},
+		{"format error", sampleScreenFormat, 1, false, true},
+		{"no data", "*NO DATA*", 1, false, true},
+		{"esa marks", "QUEUE COUNT" + "\u0087" + "\n 100     12\n", 2, false, false},
+		{"empty", "\n\n", 0, false, false},
+		{"check in text not at top", "HOTEL INFO\nCHECK IN 3PM", 2, false, false},
+	}
+)
+
+func TestScreen(t *testing.T) {
+	for _, tc := range sampleScreenLinesTests {
+		s := NewScreen("*A", tc.raw)
+		if len(s.Lines) != tc.lines {
+			t.Errorf("%s Lines expect: %d, got: %d %q", tc.name, tc.lines, len(s.Lines), s.Lines)
+		}
+		if s.More() != tc.more {
+			t.Errorf("%s More expect: %t, got: %t", tc.name, tc.more, s.More())
+		}
+		if err := s.Err(); (err != nil) != tc.err {
+			t.Errorf("%s Err expect error: %t, got: %v", tc.name, tc.err, err)
+		}
+		for _, l := range s.Lines {
+			if strings.HasSuffix(l, " ") || strings.Contains(l, "\u0087") {
+				t.Errorf("%s line not cleaned: %q", tc.name, l)
+			}
+		}
+	}
+}
+
+func TestScreenErr(t *testing.T) {
+	err := NewScreen("*A", sampleScreenFormat).Err()
+	var es ErrorScreen
+	if !errors.As(err, &es) {
+		t.Fatalf("Err expect: %T, got: %T", es, err)
+	}
+	if es.Command != "*A" || es.Line != "¥FORMAT¥" {
+		t.Errorf("ErrorScreen expect: %s %s, got: %s %s", "*A", "¥FORMAT¥", es.Command, es.Line)
+	}
+}
+
+func TestScreenAppend(t *testing.T) {
+	s := NewScreen("*A", sampleScreenPNR).Append(NewScreen(MoveDown, sampleScreenPNRPage2))
+	if s.Command != "*A" {
+		t.Errorf("Append Command expect: %s, got: %s", "*A", s.Command)
+	}
+	if len(s.Lines) != 10 {
+		t.Errorf("Append Lines expect: %d, got: %d %q", 10, len(s.Lines), s.Lines)
+	}
+	if s.More() {
+		t.Error("Append of last page should not have More")
+	}
+	for _, l := range s.Lines {
+		if strings.Contains(l, "‡") {
+			t.Errorf("Append should drop more marker, got: %q", l)
+		}
+	}
+	found := s.Find(regexp.MustCompile(`^\s*(\d+)\.AUS([\d-]+)-A$`))
+	if len(found) != 1 || found[0][2] != "512-555-1212" {
+		t.Errorf("Find phone expect: %s, got: %v", "512-555-1212", found)
+	}
+}
@@ -7,10 +7,8 @@ package htlsp
 */
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -97,32 +95,8 @@ func SetHotelAvailBody(guestCount int, query *HotelSearchCriteria, arrive, depar
 func BuildHotelAvailRequest(c *srvc.SessionConf, binsec string, otaHotelAvail HotelAvailBody) HotelAvailRequest {
 	return HotelAvailRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "OTA_HotelAvailRQ", Type: "sabreXML"},
-				Action:         "OTA_HotelAvailLLSRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
-		Body: otaHotelAvail,
+		Header:   hotelAvailOp.Header(c, binsec),
+		Body:     otaHotelAvail,
 	}
 }
 
@@ -130,32 +104,8 @@ func BuildHotelAvailRequest(c *srvc.SessionConf, binsec string, otaHotelAvail Ho
 func HOTStar(c *srvc.SessionConf, binsec string) HotelAvailRequest {
 	return HotelAvailRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "OTA_HotelAvailRQ", Type: "sabreXML"},
-				Action:         "OTA_HotelAvailLLSRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
-		Body: setPaginateAvailBody(),
+		Header:   hotelAvailOp.Header(c, binsec),
+		Body:     setPaginateAvailBody(),
 	}
 }
 
@@ -207,42 +157,34 @@ type HotelAvailResponse struct {
 		HotelAvail OTAHotelAvailRS
 		Fault      srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
+}
+
+var hotelAvailOp = srvc.Operation[HotelAvailRequest, HotelAvailResponse]{
+	Service:    srvc.ServiceElem{Value: "OTA_HotelAvailRQ", Type: srvc.ServiceTypeSabreXML},
+	Action:     "OTA_HotelAvailLLSRQ",
+	AppMessage: sbrerr.ErrCallHotelAvail,
+}
+
+// Fault for srvc.FaultChecker
+func (r HotelAvailResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
+
+// ResultErr for srvc.ResultChecker
+func (r HotelAvailResponse) ResultErr() error {
+	if !r.Body.HotelAvail.Result.Ok() {
+		return r.Body.HotelAvail.Result.ErrFormat()
+	}
+	return nil
 }
 
 // CallHotelAvail to sabre web services
 func CallHotelAvail(serviceURL string, req HotelAvailRequest) (HotelAvailResponse, error) {
-	//allocate return types
-	availResp := HotelAvailResponse{}
-	//construct payload
-	byteReq, _ := xml.Marshal(req)
-	srvc.LogSoap.Printf("CallHotelAvail-REQUEST: %s\n\n", byteReq)
-
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		availResp.ErrorSabreService = sbrerr.NewErrorSabreService(err.Error(), sbrerr.ErrCallHotelAvail, sbrerr.BadService)
-		return availResp, availResp.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, _ = io.Copy(bodyBuffer, resp.Body)
-	srvc.LogSoap.Printf("CallHotelAvail-RESPONSE: %s\n\n", bodyBuffer)
-	resp.Body.Close()
-
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &availResp)
-	if err != nil {
-		availResp.ErrorSabreXML = sbrerr.NewErrorSabreXML(err.Error(), sbrerr.ErrCallHotelAvail, sbrerr.BadParse)
-		return availResp, availResp.ErrorSabreXML
-	}
-	if !availResp.Body.Fault.Ok() {
-		return availResp, availResp.Body.Fault.Format()
-	}
-	if !availResp.Body.HotelAvail.Result.Ok() {
-		return availResp, availResp.Body.HotelAvail.Result.ErrFormat()
-	}
-	return availResp, nil
+	return CallHotelAvailContext(context.Background(), serviceURL, req)
+}
+
+// CallHotelAvailContext is CallHotelAvail bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallHotelAvailContext(ctx context.Context, serviceURL string, req HotelAvailRequest) (HotelAvailResponse, error) {
+	return hotelAvailOp.Send(ctx, serviceURL, req)
 }
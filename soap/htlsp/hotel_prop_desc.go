@@ -7,11 +7,9 @@ package htlsp
 */
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
@@ -87,32 +85,8 @@ func SetHotelPropDescBody(guestCount int, query *HotelSearchCriteria, arrive, de
 func BuildHotelPropDescRequest(c *srvc.SessionConf, binsec string, propDesc HotelPropDescBody) HotelPropDescRequest {
 	return HotelPropDescRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "HotelPropertyDescription", Type: "sabreXML"},
-				Action:         "HotelPropertyDescriptionLLSRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
-		Body: propDesc,
+		Header:   hotelPropDescOp.Header(c, binsec),
+		Body:     propDesc,
 	}
 }
 
@@ -136,8 +110,7 @@ type HotelPropDescResponse struct {
 		HotelDesc HotelPropertyDescriptionRS
 		Fault     srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
 }
 
 // SetRoomMetaData builds a b64 encoded string cache of rate request for later retrieval. See NewParsedRoomMeta for this data is parsed.
@@ -165,37 +138,31 @@ func (r *HotelPropDescResponse) SetRoomMetaData(guest int, arrive, depart, hotel
 	}
 }
 
+var hotelPropDescOp = srvc.Operation[HotelPropDescRequest, HotelPropDescResponse]{
+	Service:    srvc.ServiceElem{Value: "HotelPropertyDescription", Type: srvc.ServiceTypeSabreXML},
+	Action:     "HotelPropertyDescriptionLLSRQ",
+	AppMessage: sbrerr.ErrCallHotelPropDesc,
+}
+
+// Fault for srvc.FaultChecker
+func (r HotelPropDescResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
+
+// ResultErr for srvc.ResultChecker
+func (r HotelPropDescResponse) ResultErr() error {
+	if !r.Body.HotelDesc.Result.Ok() {
+		return r.Body.HotelDesc.Result.ErrFormat()
+	}
+	return nil
+}
+
 // CallHotelPropDesc to sabre web services retrieve hotel rates using HotelPropertyDescriptionLLSRQ.
 func CallHotelPropDesc(serviceURL string, req HotelPropDescRequest) (HotelPropDescResponse, error) {
-	propResp := HotelPropDescResponse{}
-	byteReq, _ := xml.Marshal(req)
-	srvc.LogSoap.Printf("CallHotelPropDesc-REQUEST %s\n\n", byteReq)
+	return CallHotelPropDescContext(context.Background(), serviceURL, req)
+}
 
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		propResp.ErrorSabreService = sbrerr.NewErrorSabreService(err.Error(), sbrerr.ErrCallHotelPropDesc, sbrerr.BadService)
-		return propResp, propResp.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, _ = io.Copy(bodyBuffer, resp.Body)
-	srvc.LogSoap.Printf("CallHotelPropDesc-RESPONSE %s\n\n", bodyBuffer)
-	resp.Body.Close()
-
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &propResp)
-	if err != nil {
-		propResp.ErrorSabreXML = sbrerr.NewErrorSabreXML(err.Error(), sbrerr.ErrCallHotelPropDesc, sbrerr.BadParse)
-		return propResp, propResp.ErrorSabreXML
-	}
-	if !propResp.Body.Fault.Ok() {
-		return propResp, propResp.Body.Fault.Format()
-	}
-	if !propResp.Body.HotelDesc.Result.Ok() {
-		return propResp, propResp.Body.HotelDesc.Result.ErrFormat()
-	}
-	//propResp.SetTrackedEncode()
-	return propResp, nil
+// CallHotelPropDescContext is CallHotelPropDesc bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallHotelPropDescContext(ctx context.Context, serviceURL string, req HotelPropDescRequest) (HotelPropDescResponse, error) {
+	return hotelPropDescOp.Send(ctx, serviceURL, req)
 }
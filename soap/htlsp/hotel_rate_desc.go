@@ -1,10 +1,8 @@
 package htlsp
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -54,32 +52,8 @@ func SetHotelRateDescBody(rpc *RatePlanCandidates) (HotelRateDescBody, error) {
 func BuildHotelRateDescRequest(c *srvc.SessionConf, binsec string, body HotelRateDescBody) HotelRateDescRequest {
 	return HotelRateDescRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "HotelRateDescriptionLLSRQ", Type: "sabreXML"},
-				Action:         "HotelRateDescriptionLLSRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
-		Body: body,
+		Header:   hotelRateDescOp.Header(c, binsec),
+		Body:     body,
 	}
 }
 
@@ -103,39 +77,34 @@ type HotelRateDescResponse struct {
 		HotelDesc HotelRateDescriptionRS
 		Fault     srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
 }
 
-// CallHotelRateDesc to sabre web services retrieve hotel rates using HotelRateDescriptionLLSRQ. This call only supports requests that contain an RPH from a previous hotel_property_desc call, see BuildHotelRateDescRequest.
-func CallHotelRateDesc(serviceURL string, req HotelRateDescRequest) (HotelRateDescResponse, error) {
-	rateResp := HotelRateDescResponse{}
-	byteReq, _ := xml.Marshal(req)
+var hotelRateDescOp = srvc.Operation[HotelRateDescRequest, HotelRateDescResponse]{
+	Service:    srvc.ServiceElem{Value: "HotelRateDescriptionLLSRQ", Type: srvc.ServiceTypeSabreXML},
+	Action:     "HotelRateDescriptionLLSRQ",
+	AppMessage: sbrerr.ErrCallHotelRateDesc,
+}
 
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		rateResp.ErrorSabreService = sbrerr.NewErrorSabreService(err.Error(), sbrerr.ErrCallHotelRateDesc, sbrerr.BadService)
-		return rateResp, rateResp.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	io.Copy(bodyBuffer, resp.Body)
-	resp.Body.Close()
+// Fault for srvc.FaultChecker
+func (r HotelRateDescResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
 
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &rateResp)
-	if err != nil {
-		rateResp.ErrorSabreXML = sbrerr.NewErrorSabreXML(err.Error(), sbrerr.ErrCallHotelRateDesc, sbrerr.BadParse)
-		return rateResp, rateResp.ErrorSabreXML
+// ResultErr for srvc.ResultChecker
+func (r HotelRateDescResponse) ResultErr() error {
+	if !r.Body.HotelDesc.Result.Ok() {
+		return r.Body.HotelDesc.Result.ErrFormat()
 	}
-	if !rateResp.Body.Fault.Ok() {
-		return rateResp, rateResp.Body.Fault.Format()
-	}
-	if !rateResp.Body.HotelDesc.Result.Ok() {
-		return rateResp, rateResp.Body.HotelDesc.Result.ErrFormat()
-	}
-	//rateResp.SetTrackedEncode()
-	return rateResp, nil
+	return nil
+}
+
+// CallHotelRateDesc to sabre web services retrieve hotel rates using HotelRateDescriptionLLSRQ. This call only supports requests that contain an RPH from a previous hotel_property_desc call, see BuildHotelRateDescRequest.
+func CallHotelRateDesc(serviceURL string, req HotelRateDescRequest) (HotelRateDescResponse, error) {
+	return CallHotelRateDescContext(context.Background(), serviceURL, req)
+}
+
+// CallHotelRateDescContext is CallHotelRateDesc bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallHotelRateDescContext(ctx context.Context, serviceURL string, req HotelRateDescRequest) (HotelRateDescResponse, error) {
+	return hotelRateDescOp.Send(ctx, serviceURL, req)
 }
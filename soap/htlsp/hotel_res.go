@@ -1,10 +1,8 @@
 package htlsp
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -215,32 +213,8 @@ func SetHotelResBody(units int) HotelRsrvBody {
 func BuildHotelResRequest(c *srvc.SessionConf, binsec string, body HotelRsrvBody) HotelRsrvRequest {
 	return HotelRsrvRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "OTA_HotelRes", Type: "sabreXML"},
-				Action:         "OTA_HotelResLLSRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
-		Body: body,
+		Header:   hotelResOp.Header(c, binsec),
+		Body:     body,
 	}
 }
 
@@ -278,35 +252,31 @@ type HotelRsrvResponse struct {
 	}
 }
 
+var hotelResOp = srvc.Operation[HotelRsrvRequest, HotelRsrvResponse]{
+	Service:    srvc.ServiceElem{Value: "OTA_HotelRes", Type: srvc.ServiceTypeSabreXML},
+	Action:     "OTA_HotelResLLSRQ",
+	AppMessage: sbrerr.ErrCallHotelRes,
+}
+
+// Fault for srvc.FaultChecker
+func (r HotelRsrvResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
+
+// ResultErr for srvc.ResultChecker
+func (r HotelRsrvResponse) ResultErr() error {
+	if !r.Body.HotelRes.Result.Ok() {
+		return r.Body.HotelRes.Result.ErrFormat()
+	}
+	return nil
+}
+
 // CallHotelAvail to sabre web services
 func CallHotelRes(serviceURL string, req HotelRsrvRequest) (HotelRsrvResponse, error) {
-	resResp := HotelRsrvResponse{}
-	//construct payload
-	byteReq, _ := xml.Marshal(req)
-	srvc.LogSoap.Printf("\n\nCallHotelResPAYLOAD: %s\n\n", byteReq)
-
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		return resResp, sbrerr.NewErrorSabreService(err.Error(), sbrerr.ErrCallHotelAvail, sbrerr.BadService)
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, _ = io.Copy(bodyBuffer, resp.Body)
-	resp.Body.Close()
-
-	srvc.LogSoap.Printf("\n\nCallHotelResRESPONSE: %s\n\n", bodyBuffer)
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &resResp)
-	if err != nil {
-		return resResp, sbrerr.NewErrorSabreXML(err.Error(), sbrerr.ErrCallHotelAvail, sbrerr.BadParse)
-	}
-	if !resResp.Body.Fault.Ok() {
-		return resResp, sbrerr.NewErrorSoapFault(resResp.Body.Fault.Format().Error())
-	}
-	if !resResp.Body.HotelRes.Result.Ok() {
-		return resResp, resResp.Body.HotelRes.Result.ErrFormat()
-	}
-	return resResp, nil
+	return CallHotelResContext(context.Background(), serviceURL, req)
+}
+
+// CallHotelResContext is CallHotelRes bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallHotelResContext(ctx context.Context, serviceURL string, req HotelRsrvRequest) (HotelRsrvResponse, error) {
+	return hotelResOp.Send(ctx, serviceURL, req)
 }
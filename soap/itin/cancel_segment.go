@@ -1,11 +1,8 @@
 package itin
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"fmt"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -56,31 +53,7 @@ func BuildCancelSegmentRequest(c *srvc.SessionConf, binsec, typ, endnumber, numb
 	}
 	return CancelSeqmentRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "OTA_CancelRQ", Type: "sabreXML"},
-				Action:         "OTA_CancelRQ", //OTA_CancelLLSRQ
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
+		Header:   cancelSegmentOp.Header(c, binsec), //OTA_CancelLLSRQ
 		Body: CancelSegmentBody{
 			CancelSegmentRQ: CancelSegmentRQ{
 				Version: "2.0.2",
@@ -105,8 +78,7 @@ type CancelSegmentResponse struct {
 		CancelSegmentRS CancelSegmentRS
 		Fault           srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
 }
 
 // Ok check for errors on get reservations requests.
@@ -114,65 +86,35 @@ func (r *CancelSegmentRS) Ok() bool {
 	return len(r.Errors.Error) > 0
 }
 
-// CallGetReservation to execute GetReservationRequest, which must be done in order to finish the booking transaction.
-func CallCancelSegment(serviceURL string, req GetReservationRequest) (CancelSegmentResponse, error) {
-	cSeg := CancelSegmentResponse{}
-	byteReq, _ := xml.Marshal(req)
-
-	//-----------------------------------
-	fmt.Printf("\n\n CallCancelSegment RAW REQUEST: %s\n\n", byteReq)
-
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		cSeg.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallGetReservation,
-			sbrerr.BadService,
-		)
-		return cSeg, cSeg.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// note ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, err = io.Copy(bodyBuffer, resp.Body)
-	//close body no defer
-	resp.Body.Close()
-	//handle and return error if bad body
-	if err != nil {
-		cSeg.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallGetReservation,
-			sbrerr.BadParse,
-		)
-		return cSeg, cSeg.ErrorSabreService
-	}
+var cancelSegmentOp = srvc.Operation[GetReservationRequest, CancelSegmentResponse]{
+	Service:    srvc.ServiceElem{Value: "OTA_CancelRQ", Type: srvc.ServiceTypeSabreXML},
+	Action:     "OTA_CancelRQ",
+	AppMessage: sbrerr.ErrCallGetReservation,
+}
 
-	//-----------------------------------
-	fmt.Printf("\n\n CallCancelSegment RAW RESPONSE: %s\n\n", bodyBuffer.Bytes())
-
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &cSeg)
-	if err != nil {
-		cSeg.ErrorSabreXML = sbrerr.NewErrorSabreXML(
-			err.Error(),
-			sbrerr.ErrCallGetReservation,
-			sbrerr.BadParse,
-		)
-		return cSeg, cSeg.ErrorSabreXML
-	}
-	if !cSeg.Body.Fault.Ok() {
-		return cSeg, sbrerr.NewErrorSoapFault(cSeg.Body.Fault.Format().ErrMessage)
-	}
+// Fault for srvc.FaultChecker
+func (r CancelSegmentResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
 
+// ResultErr for srvc.ResultChecker
+func (r CancelSegmentResponse) ResultErr() error {
 	// does this even return AppResults ??
-	if !cSeg.Body.CancelSegmentRS.AppResults.Ok() {
-		return cSeg, cSeg.Body.CancelSegmentRS.AppResults.ErrFormat()
+	if !r.Body.CancelSegmentRS.AppResults.Ok() {
+		return r.Body.CancelSegmentRS.AppResults.ErrFormat()
 	}
-
-	if !cSeg.Body.CancelSegmentRS.Ok() {
-		return cSeg, cSeg.Body.CancelSegmentRS.Errors.Format()
+	if !r.Body.CancelSegmentRS.Ok() {
+		return r.Body.CancelSegmentRS.Errors.Format()
 	}
+	return nil
+}
+
+// CallGetReservation to execute GetReservationRequest, which must be done in order to finish the booking transaction.
+func CallCancelSegment(serviceURL string, req GetReservationRequest) (CancelSegmentResponse, error) {
+	return CallCancelSegmentContext(context.Background(), serviceURL, req)
+}
 
-	return cSeg, nil
+// CallCancelSegmentContext is CallCancelSegment bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallCancelSegmentContext(ctx context.Context, serviceURL string, req GetReservationRequest) (CancelSegmentResponse, error) {
+	return cancelSegmentOp.Send(ctx, serviceURL, req)
 }
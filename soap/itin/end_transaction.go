@@ -1,10 +1,8 @@
 package itin
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -55,31 +53,7 @@ type EndTransactionRQ struct {
 func BuildEndTransactionRequest(c *srvc.SessionConf, binsec string) EndTransactionRequest {
 	return EndTransactionRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "EndTransactionRQ", Type: "sabreXML"},
-				Action:         "EndTransactionLLSRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
+		Header:   endTransactionOp.Header(c, binsec),
 		Body: EndTransactionBody{
 			EndTransactionRQ: EndTransactionRQ{
 				XMLNS:   srvc.BaseWebServicesNS,
@@ -111,59 +85,34 @@ type EndTransactionResponse struct {
 		EndTransactionRS EndTransactionRS
 		Fault            srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
 }
 
-// CallEndTransaction to execute EndTransactionRequest, which must be done in order to finish the booking transaction.
-func CallEndTransaction(serviceURL string, req EndTransactionRequest) (EndTransactionResponse, error) {
-	endT := EndTransactionResponse{}
-	byteReq, _ := xml.Marshal(req)
-	srvc.LogSoap.Printf("CallEndTransaction-REQUEST %s \n\n", byteReq)
-
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		endT.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallEndTransaction,
-			sbrerr.BadService,
-		)
-		return endT, endT.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// note ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, err = io.Copy(bodyBuffer, resp.Body)
-	srvc.LogSoap.Printf("CallEndTransaction-RESPONSE %s \n\n", bodyBuffer)
-	//close body no defer
-	resp.Body.Close()
-	//handle and return error if bad body
-	if err != nil {
-		endT.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallEndTransaction,
-			sbrerr.BadParse,
-		)
-		return endT, endT.ErrorSabreService
-	}
+var endTransactionOp = srvc.Operation[EndTransactionRequest, EndTransactionResponse]{
+	Service:    srvc.ServiceElem{Value: "EndTransactionRQ", Type: srvc.ServiceTypeSabreXML},
+	Action:     "EndTransactionLLSRQ",
+	AppMessage: sbrerr.ErrCallEndTransaction,
+}
 
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &endT)
-	if err != nil {
-		endT.ErrorSabreXML = sbrerr.NewErrorSabreXML(
-			err.Error(),
-			sbrerr.ErrCallEndTransaction,
-			sbrerr.BadParse,
-		)
-		return endT, endT.ErrorSabreXML
-	}
-	if !endT.Body.Fault.Ok() {
-		return endT, sbrerr.NewErrorSoapFault(endT.Body.Fault.Format().ErrMessage)
-	}
+// Fault for srvc.FaultChecker
+func (r EndTransactionResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
 
-	if !endT.Body.EndTransactionRS.AppResults.Ok() {
-		return endT, endT.Body.EndTransactionRS.AppResults.ErrFormat()
+// ResultErr for srvc.ResultChecker
+func (r EndTransactionResponse) ResultErr() error {
+	if !r.Body.EndTransactionRS.AppResults.Ok() {
+		return r.Body.EndTransactionRS.AppResults.ErrFormat()
 	}
-	return endT, nil
+	return nil
+}
+
+// CallEndTransaction to execute EndTransactionRequest, which must be done in order to finish the booking transaction.
+func CallEndTransaction(serviceURL string, req EndTransactionRequest) (EndTransactionResponse, error) {
+	return CallEndTransactionContext(context.Background(), serviceURL, req)
+}
+
+// CallEndTransactionContext is CallEndTransaction bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallEndTransactionContext(ctx context.Context, serviceURL string, req EndTransactionRequest) (EndTransactionResponse, error) {
+	return endTransactionOp.Send(ctx, serviceURL, req)
 }
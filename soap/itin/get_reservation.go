@@ -1,11 +1,9 @@
 package itin
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -90,31 +88,7 @@ type GetReservationRQ struct {
 func BuildGetReservationRequest(c *srvc.SessionConf, binsec, locator string) GetReservationRequest {
 	return GetReservationRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "GetReservationRQ", Type: "sabreXML"},
-				Action:         "GetReservationRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
+		Header:   getReservationOp.Header(c, binsec),
 		Body: GetReservationBody{
 			GetReservationRQ: GetReservationRQ{
 				XMLNS:   "http://webservices.sabre.com/pnrbuilder/v1_19",
@@ -177,8 +151,7 @@ type GetReservationResponse struct {
 		GetReservationRS GetReservationRS
 		Fault            srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
 }
 
 // Ok check for errors on get reservations requests.
@@ -201,58 +174,31 @@ func (p *PNRErrors) Format() sbrerr.ErrorSabreService {
 	}
 }
 
-// CallGetReservation to execute GetReservationRequest, which must be done in order to finish the booking transaction.
-func CallGetReservation(serviceURL string, req GetReservationRequest) (GetReservationResponse, error) {
-	getRes := GetReservationResponse{}
-	byteReq, _ := xml.Marshal(req)
-
-	srvc.LogSoap.Printf("\n\nCallGetReservation-REQUEST: %s\n\n", byteReq)
-
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		getRes.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallGetReservation,
-			sbrerr.BadService,
-		)
-		return getRes, getRes.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// note ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, err = io.Copy(bodyBuffer, resp.Body)
-	//close body no defer
-	resp.Body.Close()
-	//handle and return error if bad body
-	if err != nil {
-		getRes.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallGetReservation,
-			sbrerr.BadParse,
-		)
-		return getRes, getRes.ErrorSabreService
-	}
+var getReservationOp = srvc.Operation[GetReservationRequest, GetReservationResponse]{
+	Service:    srvc.ServiceElem{Value: "GetReservationRQ", Type: srvc.ServiceTypeSabreXML},
+	Action:     "GetReservationRQ",
+	AppMessage: sbrerr.ErrCallGetReservation,
+}
 
-	srvc.LogSoap.Printf("\n\nCallGetReservation-RESPONSE: %s\n\n", bodyBuffer.Bytes())
+// Fault for srvc.FaultChecker
+func (r GetReservationResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
 
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &getRes)
-	if err != nil {
-		getRes.ErrorSabreXML = sbrerr.NewErrorSabreXML(
-			err.Error(),
-			sbrerr.ErrCallGetReservation,
-			sbrerr.BadParse,
-		)
-		return getRes, getRes.ErrorSabreXML
-	}
-	if !getRes.Body.Fault.Ok() {
-		return getRes, sbrerr.NewErrorSoapFault(getRes.Body.Fault.Format().ErrMessage)
+// ResultErr for srvc.ResultChecker
+func (r GetReservationResponse) ResultErr() error {
+	if !r.Body.GetReservationRS.Ok() {
+		return r.Body.GetReservationRS.Errors.Format()
 	}
+	return nil
+}
 
-	if !getRes.Body.GetReservationRS.Ok() {
-		return getRes, getRes.Body.GetReservationRS.Errors.Format()
-	}
+// CallGetReservation to execute GetReservationRequest, which must be done in order to finish the booking transaction.
+func CallGetReservation(serviceURL string, req GetReservationRequest) (GetReservationResponse, error) {
+	return CallGetReservationContext(context.Background(), serviceURL, req)
+}
 
-	return getRes, nil
+// CallGetReservationContext is CallGetReservation bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallGetReservationContext(ctx context.Context, serviceURL string, req GetReservationRequest) (GetReservationResponse, error) {
+	return getReservationOp.Send(ctx, serviceURL, req)
 }
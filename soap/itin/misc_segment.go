@@ -1,10 +1,8 @@
 package itin
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -81,31 +79,7 @@ type MiscSegText struct {
 func BuildMiscSegmentRequest(c *srvc.SessionConf, binsec string, seg MiscSegment) MiscSegmentRequest {
 	return MiscSegmentRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "MiscSegmentSellLLSRQ", Type: "sabreXML"},
-				Action:         "MiscSegmentSellLLSRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
+		Header:   miscSegmentOp.Header(c, binsec),
 		Body: MiscSegmentBody{
 			MiscSegmentRQ: MiscSegmentRQ{
 				XMLNS:  srvc.BaseWebServicesNS,
@@ -133,63 +107,34 @@ type MiscSegmentResponse struct {
 		MiscSegmentRS MiscSegmentRS
 		Fault         srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
 }
 
-// CallMiscSegment to execute MiscSegmentRequest, which is done in order to add more segments to existing PNR.
-func CallMiscSegment(serviceURL string, req MiscSegmentRequest) (MiscSegmentResponse, error) {
-	miscS := MiscSegmentResponse{}
-	byteReq, _ := xml.Marshal(req)
-	srvc.LogSoap.Printf("CallMiscSegment-REQUEST %s \n\n", byteReq)
-
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		miscS.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallMiscSegment,
-			sbrerr.BadService,
-		)
-		return miscS, miscS.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// note ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, err = io.Copy(bodyBuffer, resp.Body)
-	srvc.LogSoap.Printf("CallMiscSegment-RESPONSE %s \n\n", bodyBuffer)
-	//close body no defer
-	resp.Body.Close()
-	//handle and return error if bad body
-	if err != nil {
-		miscS.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallMiscSegment,
-			sbrerr.BadParse,
-		)
-		srvc.LogSoap.Printf("CallMiscSegment-Unmarshal %v \n\n", miscS.ErrorSabreService)
-		return miscS, miscS.ErrorSabreService
-	}
+var miscSegmentOp = srvc.Operation[MiscSegmentRequest, MiscSegmentResponse]{
+	Service:    srvc.ServiceElem{Value: "MiscSegmentSellLLSRQ", Type: srvc.ServiceTypeSabreXML},
+	Action:     "MiscSegmentSellLLSRQ",
+	AppMessage: sbrerr.ErrCallMiscSegment,
+}
 
-	//marshal bytes sabre response body into miscS response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &miscS)
-	if err != nil {
-		miscS.ErrorSabreXML = sbrerr.NewErrorSabreXML(
-			err.Error(),
-			sbrerr.ErrCallMiscSegment,
-			sbrerr.BadParse,
-		)
-		srvc.LogSoap.Printf("CallMiscSegment-Unmarshal %v \n\n", miscS.ErrorSabreXML)
-		return miscS, miscS.ErrorSabreXML
-	}
-	if !miscS.Body.Fault.Ok() {
-		srvc.LogSoap.Printf("CallMiscSegment-Fault %v \n\n", sbrerr.NewErrorSoapFault(miscS.Body.Fault.Format().ErrMessage))
-		return miscS, sbrerr.NewErrorSoapFault(miscS.Body.Fault.Format().ErrMessage)
-	}
+// Fault for srvc.FaultChecker
+func (r MiscSegmentResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
 
-	if !miscS.Body.MiscSegmentRS.AppResults.Ok() {
-		srvc.LogSoap.Printf("CallMiscSegment-AppResults %v \n\n", miscS.Body.MiscSegmentRS.AppResults)
-		return miscS, miscS.Body.MiscSegmentRS.AppResults.ErrFormat()
+// ResultErr for srvc.ResultChecker
+func (r MiscSegmentResponse) ResultErr() error {
+	if !r.Body.MiscSegmentRS.AppResults.Ok() {
+		return r.Body.MiscSegmentRS.AppResults.ErrFormat()
 	}
-	return miscS, nil
+	return nil
+}
+
+// CallMiscSegment to execute MiscSegmentRequest, which is done in order to add more segments to existing PNR.
+func CallMiscSegment(serviceURL string, req MiscSegmentRequest) (MiscSegmentResponse, error) {
+	return CallMiscSegmentContext(context.Background(), serviceURL, req)
+}
+
+// CallMiscSegmentContext is CallMiscSegment bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallMiscSegmentContext(ctx context.Context, serviceURL string, req MiscSegmentRequest) (MiscSegmentResponse, error) {
+	return miscSegmentOp.Send(ctx, serviceURL, req)
 }
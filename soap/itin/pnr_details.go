@@ -1,10 +1,8 @@
 package itin
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -273,32 +271,8 @@ func SetPNRDetailBody(phone string, person PersonName) PassengerDetailBody {
 func BuildPNRDetailsRequest(c *srvc.SessionConf, binsec string, body PassengerDetailBody) PNRDetailsRequest {
 	return PNRDetailsRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "PassengerDetailsRQ", Type: "sabreXML"},
-				Action:         "PassengerDetailsRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
-		Body: body,
+		Header:   pnrDetailsOp.Header(c, binsec),
+		Body:     body,
 	}
 }
 
@@ -318,49 +292,34 @@ type PNRDetailsResponse struct {
 		PassengerDetailsRS PassengerDetailsRS
 		Fault              srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
 }
 
-// CallPNRDetailsRequest creates a new PNR or updates an existing PNR, saving the content you pass in the Sabre system. The system assigns a record locator for a new PNR, and returns the record locator of an existing PNR. When the processing of the service is complete, the content remains in the Sabre work area. Previous calls required are hotel_property_desc OR hotel_rate_desc call, see BuildPNRDetailsRequest.
-func CallPNRDetail(serviceURL string, req PNRDetailsRequest) (PNRDetailsResponse, error) {
-	pnrResp := PNRDetailsResponse{}
-	byteReq, _ := xml.Marshal(req)
-	srvc.LogSoap.Printf("CallPNRDetail-REQUEST\n\n %s\n\n", byteReq)
+var pnrDetailsOp = srvc.Operation[PNRDetailsRequest, PNRDetailsResponse]{
+	Service:    srvc.ServiceElem{Value: "PassengerDetailsRQ", Type: srvc.ServiceTypeSabreXML},
+	Action:     "PassengerDetailsRQ",
+	AppMessage: sbrerr.ErrCallPNRDetails,
+}
 
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		pnrResp.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallPNRDetails,
-			sbrerr.BadService,
-		)
-		return pnrResp, pnrResp.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, _ = io.Copy(bodyBuffer, resp.Body)
-	srvc.LogSoap.Printf("CallPNRDetail-RESPONSE\n\n %s\n\n", bodyBuffer)
-	resp.Body.Close()
+// Fault for srvc.FaultChecker
+func (r PNRDetailsResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
 
-	//fmt.Printf("\n\n:CallPNRDetail Response: %s\n\n", bodyBuffer)
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &pnrResp)
-	if err != nil {
-		pnrResp.ErrorSabreXML = sbrerr.NewErrorSabreXML(
-			err.Error(),
-			sbrerr.ErrCallPNRDetails,
-			sbrerr.BadParse,
-		)
-		return pnrResp, pnrResp.ErrorSabreXML
-	}
-	if !pnrResp.Body.Fault.Ok() {
-		return pnrResp, sbrerr.NewErrorSoapFault(pnrResp.Body.Fault.String)
-	}
-	if !pnrResp.Body.PassengerDetailsRS.AppResults.Ok() {
-		return pnrResp, pnrResp.Body.PassengerDetailsRS.AppResults.ErrFormat()
+// ResultErr for srvc.ResultChecker
+func (r PNRDetailsResponse) ResultErr() error {
+	if !r.Body.PassengerDetailsRS.AppResults.Ok() {
+		return r.Body.PassengerDetailsRS.AppResults.ErrFormat()
 	}
-	return pnrResp, nil
+	return nil
+}
+
+// CallPNRDetailsRequest creates a new PNR or updates an existing PNR, saving the content you pass in the Sabre system. The system assigns a record locator for a new PNR, and returns the record locator of an existing PNR. When the processing of the service is complete, the content remains in the Sabre work area. Previous calls required are hotel_property_desc OR hotel_rate_desc call, see BuildPNRDetailsRequest.
+func CallPNRDetail(serviceURL string, req PNRDetailsRequest) (PNRDetailsResponse, error) {
+	return CallPNRDetailContext(context.Background(), serviceURL, req)
+}
+
+// CallPNRDetailContext is CallPNRDetail bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallPNRDetailContext(ctx context.Context, serviceURL string, req PNRDetailsRequest) (PNRDetailsResponse, error) {
+	return pnrDetailsOp.Send(ctx, serviceURL, req)
 }
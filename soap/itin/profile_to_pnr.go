@@ -1,11 +1,9 @@
 package itin
 
 import (
-	"bytes"
+	"context"
 	"encoding/xml"
 	"errors"
-	"io"
-	"net/http"
 
 	"github.com/ailgroup/sbrweb/sbrerr"
 	"github.com/ailgroup/sbrweb/soap/srvc"
@@ -106,31 +104,7 @@ type ProfileToPNRRQ struct {
 func BuildProfileToPNRRequest(c *srvc.SessionConf, binsec string, fp *FilterPath) ProfileToPNRRequest {
 	return ProfileToPNRRequest{
 		Envelope: srvc.CreateEnvelope(),
-		Header: srvc.SessionHeader{
-			MessageHeader: srvc.MessageHeader{
-				MustUnderstand: srvc.SabreMustUnderstand,
-				EbVersion:      srvc.SabreEBVersion,
-				From: srvc.FromElem{
-					PartyID: srvc.CreatePartyID(c.From, srvc.PartyIDTypeURN),
-				},
-				To: srvc.ToElem{
-					PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN),
-				},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        srvc.ServiceElem{Value: "EPS_ProfileToPNR", Type: "sabreXML"},
-				Action:         "EPS_ProfileToPNRRQ",
-				MessageData: srvc.MessageDataElem{
-					MessageID: srvc.GenerateMessageID(),
-					Timestamp: srvc.SabreTimeNowFmt(),
-				},
-			},
-			Security: srvc.Security{
-				XMLNSWsseBase:       srvc.BaseWsse,
-				XMLNSWsu:            srvc.BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
+		Header:   profileToPNROp.Header(c, binsec),
 		Body: ProfileToPNRBody{
 			ProfileToPNRRQ: ProfileToPNRRQ{
 				//http://www.sabre.com/eps/schemas
@@ -169,59 +143,34 @@ type ProfileToPNRResponse struct {
 		ProfileToPNRRS ProfileToPNRRS
 		Fault          srvc.SOAPFault
 	}
-	ErrorSabreService sbrerr.ErrorSabreService
-	ErrorSabreXML     sbrerr.ErrorSabreXML
+	srvc.CallErrors
 }
 
-// CallProfileToPNR to execute ProfileToPNRRequest, which must be done in order to finish the booking transaction.
-func CallProfileToPNR(serviceURL string, req ProfileToPNRRequest) (ProfileToPNRResponse, error) {
-	endT := ProfileToPNRResponse{}
-	byteReq, _ := xml.Marshal(req)
-	srvc.LogSoap.Printf("CallProfileToPNR-REQUEST %s \n\n", byteReq)
-
-	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
-	if err != nil {
-		endT.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallProfileToPNR,
-			sbrerr.BadService,
-		)
-		return endT, endT.ErrorSabreService
-	}
-	// parse payload body into []byte buffer from net Response.ReadCloser
-	// note ioutil.ReadAll(resp.Body) has no cap on size and can create memory problems
-	bodyBuffer := new(bytes.Buffer)
-	_, err = io.Copy(bodyBuffer, resp.Body)
-	srvc.LogSoap.Printf("CallProfileToPNR-RESPONSE %s \n\n", bodyBuffer)
-	//close body no defer
-	resp.Body.Close()
-	//handle and return error if bad body
-	if err != nil {
-		endT.ErrorSabreService = sbrerr.NewErrorSabreService(
-			err.Error(),
-			sbrerr.ErrCallProfileToPNR,
-			sbrerr.BadParse,
-		)
-		return endT, endT.ErrorSabreService
-	}
+var profileToPNROp = srvc.Operation[ProfileToPNRRequest, ProfileToPNRResponse]{
+	Service:    srvc.ServiceElem{Value: "EPS_ProfileToPNR", Type: srvc.ServiceTypeSabreXML},
+	Action:     "EPS_ProfileToPNRRQ",
+	AppMessage: sbrerr.ErrCallProfileToPNR,
+}
 
-	//marshal bytes sabre response body into availResp response struct
-	err = xml.Unmarshal(bodyBuffer.Bytes(), &endT)
-	if err != nil {
-		endT.ErrorSabreXML = sbrerr.NewErrorSabreXML(
-			err.Error(),
-			sbrerr.ErrCallProfileToPNR,
-			sbrerr.BadParse,
-		)
-		return endT, endT.ErrorSabreXML
-	}
-	if !endT.Body.Fault.Ok() {
-		return endT, sbrerr.NewErrorSoapFault(endT.Body.Fault.Format().ErrMessage)
-	}
+// Fault for srvc.FaultChecker
+func (r ProfileToPNRResponse) Fault() srvc.SOAPFault {
+	return r.Body.Fault
+}
 
-	if !endT.Body.ProfileToPNRRS.ResponseMessage.Ok() {
-		return endT, errors.New("CallProfileToPNR no Success")
+// ResultErr for srvc.ResultChecker
+func (r ProfileToPNRResponse) ResultErr() error {
+	if !r.Body.ProfileToPNRRS.ResponseMessage.Ok() {
+		return errors.New("CallProfileToPNR no Success")
 	}
-	return endT, nil
+	return nil
+}
+
+// CallProfileToPNR to execute ProfileToPNRRequest, which must be done in order to finish the booking transaction.
+func CallProfileToPNR(serviceURL string, req ProfileToPNRRequest) (ProfileToPNRResponse, error) {
+	return CallProfileToPNRContext(context.Background(), serviceURL, req)
+}
+
+// CallProfileToPNRContext is CallProfileToPNR bound to ctx for cancellation and deadlines, posting through the srvc.Transport on ctx (see srvc.WithTransport).
+func CallProfileToPNRContext(ctx context.Context, serviceURL string, req ProfileToPNRRequest) (ProfileToPNRResponse, error) {
+	return profileToPNROp.Send(ctx, serviceURL, req)
 }
@@ -0,0 +1,458 @@
+package sabresim
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+	"github.com/ailgroup/sbrweb/soap/htlsp"
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+// Hotel in the Sim inventory.
+type Hotel struct {
+	Chain string
+	Code  string
+	Name  string
+	City  string
+	Rooms []Room
+}
+
+// Room rate offered by a Hotel.
+type Room struct {
+	Type             string  //IATA characteristic, e.g., A1K
+	Amount           float64 //nightly rate
+	Currency         string
+	RateDescRequired bool //HRD_RequiredForSell, HotelRateDescription must run before OTA_HotelRes
+}
+
+// DefaultHotels inventory used when Config.Hotels is nil.
+var DefaultHotels = []Hotel{
+	{Chain: "HI", Code: "0012345", Name: "HOLIDAY INN DFW AIRPORT", City: "DFW", Rooms: []Room{
+		{Type: "A1K", Amount: 129, Currency: "USD"},
+		{Type: "A2D", Amount: 139, Currency: "USD"},
+		{Type: "B1K", Amount: 99, Currency: "USD", RateDescRequired: true},
+	}},
+	{Chain: "MC", Code: "0023456", Name: "MARRIOTT DFW AIRPORT NORTH", City: "DFW", Rooms: []Room{
+		{Type: "A1K", Amount: 189, Currency: "USD"},
+		{Type: "A1Q", Amount: 179, Currency: "USD", RateDescRequired: true},
+	}},
+	{Chain: "HH", Code: "0034567", Name: "HILTON DFW LAKES", City: "DFW", Rooms: []Room{
+		{Type: "A1K", Amount: 159, Currency: "USD"},
+	}},
+	{Chain: "HY", Code: "0045678", Name: "HYATT REGENCY DFW", City: "DFW", Rooms: []Room{
+		{Type: "A1K", Amount: 209, Currency: "USD"},
+		{Type: "C1K", Amount: 259, Currency: "USD", RateDescRequired: true},
+	}},
+	{Chain: "CY", Code: "0056789", Name: "COURTYARD LAS VEGAS CONVENTION CTR", City: "LAS", Rooms: []Room{
+		{Type: "A1K", Amount: 119, Currency: "USD"},
+	}},
+}
+
+// workspace AAA state of a session.
+type workspace struct {
+	avail   []Hotel          //last availability search
+	next    int              //index of the next availability page
+	offers  map[string]offer //rates by RPH from the last property description
+	names   []Name
+	phones  []string
+	hotels  []HotelSegment
+	locator string //PNR retrieved into the workspace
+}
+
+// pending true when the workspace holds changes not yet ended.
+func (ws *workspace) pending() bool {
+	return len(ws.names) > 0 || len(ws.phones) > 0 || len(ws.hotels) > 0
+}
+
+// clear the PNR being built or changed, like an ignore or end.
+func (ws *workspace) clear() {
+	ws.names, ws.phones, ws.hotels, ws.locator = nil, nil, nil, ""
+}
+
+// offer is a room rate from a property description that can be described and sold by RPH.
+type offer struct {
+	hotel     Hotel
+	room      Room
+	start     time.Time
+	end       time.Time
+	guests    int
+	described bool
+}
+
+func (o offer) nights() int {
+	return int(o.end.Sub(o.start).Hours()/24 + 0.5)
+}
+
+func (o offer) total() float64 {
+	return o.room.Amount * float64(o.nights())
+}
+
+// appResults stl:ApplicationResults
+type appResults struct {
+	XMLName xml.Name    `xml:"ApplicationResults"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Status  string      `xml:"status,attr"`
+	Success *appSuccess `xml:"Success"`
+	Error   *appError   `xml:"Error"`
+}
+
+type appSuccess struct {
+	Timestamp   string `xml:"timeStamp,attr"`
+	HostCommand string `xml:"SystemSpecificResults>HostCommand,omitempty"`
+}
+
+type appError struct {
+	Type      string `xml:"type,attr"`
+	Timestamp string `xml:"timeStamp,attr"`
+	Message   string `xml:"SystemSpecificResults>Message"`
+}
+
+// complete results echoing the host command Sabre would have run.
+func complete(now time.Time, hostCommand string) appResults {
+	return appResults{
+		XMLNS:   stlNS,
+		Status:  sbrerr.StatusComplete(),
+		Success: &appSuccess{Timestamp: now.Format(time.RFC3339), HostCommand: hostCommand},
+	}
+}
+
+// notProcessed results with a host error message.
+func notProcessed(now time.Time, msg string) appResults {
+	return appResults{
+		XMLNS:  stlNS,
+		Status: sbrerr.StatusNotProcess(),
+		Error:  &appError{Type: "BusinessLogic", Timestamp: now.Format(time.RFC3339), Message: msg},
+	}
+}
+
+type rateRange struct {
+	CurrencyCode string `xml:"CurrencyCode,attr"`
+	Max          string `xml:"Max,attr"`
+	Min          string `xml:"Min,attr"`
+}
+
+type propertyInfo struct {
+	XMLName            xml.Name   `xml:"BasicPropertyInfo"`
+	ChainCode          string     `xml:"ChainCode,attr"`
+	HotelCityCode      string     `xml:"HotelCityCode,attr"`
+	HotelCode          string     `xml:"HotelCode,attr"`
+	HotelName          string     `xml:"HotelName,attr"`
+	ConfirmationNumber string     `xml:"ConfirmationNumber,omitempty"`
+	RateRange          *rateRange `xml:"RateRange"`
+}
+
+func newPropertyInfo(h Hotel) propertyInfo {
+	return propertyInfo{ChainCode: h.Chain, HotelCityCode: h.City, HotelCode: h.Code, HotelName: h.Name}
+}
+
+type availOption struct {
+	RPH  string `xml:"RPH,attr"`
+	Info propertyInfo
+}
+
+type additionalAvail struct {
+	Ind bool `xml:"Ind,attr"`
+}
+
+type hotelAvailRS struct {
+	XMLName         xml.Name `xml:"OTA_HotelAvailRS"`
+	XMLNS           string   `xml:"xmlns,attr"`
+	Version         string   `xml:"Version,attr"`
+	Result          appResults
+	AdditionalAvail *additionalAvail `xml:"AdditionalAvail"`
+	Options         []availOption    `xml:"AvailabilityOptions>AvailabilityOption"`
+}
+
+type rate struct {
+	Amount       string `xml:"Amount,attr"`
+	CurrencyCode string `xml:"CurrencyCode,attr"`
+	RequiredHRD  bool   `xml:"HRD_RequiredForSell,attr"`
+	Total        struct {
+		Amount string `xml:"Amount,attr"`
+	} `xml:"HotelTotalPricing"`
+}
+
+type roomRate struct {
+	RPH   string `xml:"RPH,attr"`
+	IATA  string `xml:"IATA_CharacteristicIdentification,attr"`
+	Rates []rate `xml:"Rates>Rate"`
+}
+
+type timeSpan struct {
+	Start string `xml:"Start,attr"`
+	End   string `xml:"End,attr"`
+}
+
+type roomStay struct {
+	XMLName   xml.Name `xml:"RoomStay"`
+	Info      propertyInfo
+	RoomRates []roomRate `xml:"RoomRates>RoomRate"`
+	TimeSpan  timeSpan   `xml:"TimeSpan"`
+}
+
+// hotelDescRS HotelPropertyDescriptionRS and HotelRateDescriptionRS payload.
+type hotelDescRS struct {
+	XMLName  xml.Name
+	XMLNS    string `xml:"xmlns,attr"`
+	Version  string `xml:"Version,attr"`
+	Result   appResults
+	RoomStay *roomStay
+}
+
+type hotelResult struct {
+	XMLName   xml.Name `xml:"Hotel"`
+	Info      propertyInfo
+	RoomRates []roomRate `xml:"RoomRates>RoomRate"`
+	TimeSpan  timeSpan   `xml:"TimeSpan"`
+}
+
+type hotelResRS struct {
+	XMLName xml.Name `xml:"OTA_HotelResRS"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Version string   `xml:"Version,attr"`
+	Result  appResults
+	Hotel   *hotelResult
+}
+
+// rph normalizes reference place holders, 1 and 001 are the same line.
+func rph(n string) string {
+	i, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil {
+		return n
+	}
+	return fmt.Sprintf("%03d", i)
+}
+
+func amount(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func (o offer) roomRate(line string) roomRate {
+	r := rate{Amount: amount(o.room.Amount), CurrencyCode: o.room.Currency, RequiredHRD: o.room.RateDescRequired}
+	r.Total.Amount = amount(o.total())
+	return roomRate{RPH: line, IATA: o.room.Type, Rates: []rate{r}}
+}
+
+func (o offer) timeSpan() timeSpan {
+	return timeSpan{Start: o.start.Format(srvc.TimeFormatMD), End: o.end.Format(srvc.TimeFormatMD)}
+}
+
+// stay parses the month-day TimeSpan of a request into the next such dates on or after now.
+func stay(ts *htlsp.TimeSpan, now time.Time) (time.Time, time.Time, bool) {
+	if ts == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start, ok := monthDay(ts.Arrive, now)
+	if !ok {
+		return start, start, false
+	}
+	end, ok := monthDay(ts.Depart, start)
+	return start, end, ok && end.After(start)
+}
+
+func monthDay(md string, from time.Time) (time.Time, bool) {
+	for _, layout := range []string{srvc.TimeFormatMD, srvc.TimeFormatMDTHM, srvc.TimeFormatMDHM} {
+		t, err := time.Parse(layout, md)
+		if err != nil {
+			continue
+		}
+		day := time.Date(from.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Before(time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)) {
+			day = day.AddDate(1, 0, 0)
+		}
+		return day, true
+	}
+	return time.Time{}, false
+}
+
+// hotelRefs of a request search criteria; htlsp.Criterion marshals HotelRef elements but unmarshals them from HotelRefs.
+type hotelRefs struct {
+	Refs []htlsp.HotelRef `xml:"AvailRequestSegment>HotelSearchCriteria>Criterion>HotelRef"`
+}
+
+// search inventory by the HotelRef city and hotel codes; no refs matches every hotel.
+func (s *Sim) search(refs []htlsp.HotelRef) []Hotel {
+	if len(refs) == 0 {
+		return append([]Hotel(nil), s.conf.Hotels...)
+	}
+	found := []Hotel{}
+	for _, h := range s.conf.Hotels {
+		for _, ref := range refs {
+			if (ref.HotelCode != "" && ref.HotelCode == h.Code) || (ref.HotelCityCode != "" && ref.HotelCityCode == h.City) {
+				found = append(found, h)
+				break
+			}
+		}
+	}
+	return found
+}
+
+func (s *Sim) hotelAvail(c *call) any {
+	rq, q := htlsp.OTAHotelAvailRQ{}, hotelRefs{}
+	if f := c.decode(&rq); f != nil {
+		return f
+	}
+	_ = c.decode(&q)
+	rs := hotelAvailRS{XMLNS: hotelNS, Version: "2.3.0"}
+	ws := &c.sess.ws
+	hc := "HOT*"
+	if rq.Avail.AdditionalAvail == nil || !rq.Avail.AdditionalAvail.Ind {
+		ws.avail, ws.next = s.search(q.Refs), 0
+		hc = "HOT"
+		if len(ws.avail) > 0 {
+			hc += ws.avail[0].City
+		}
+		if rq.Avail.TimeSpan != nil {
+			hc += "/" + rq.Avail.TimeSpan.Arrive + "-" + rq.Avail.TimeSpan.Depart
+		}
+		if len(ws.avail) == 0 {
+			rs.Result = notProcessed(c.now, "NO AVAIL")
+			return rs
+		}
+	}
+	if ws.next >= len(ws.avail) {
+		rs.Result = notProcessed(c.now, "NO MORE DATA")
+		return rs
+	}
+	end := ws.next + s.conf.PageSize
+	if end > len(ws.avail) {
+		end = len(ws.avail)
+	}
+	for i, h := range ws.avail[ws.next:end] {
+		info := newPropertyInfo(h)
+		if len(h.Rooms) > 0 {
+			min, max := h.Rooms[0].Amount, h.Rooms[0].Amount
+			for _, r := range h.Rooms {
+				if r.Amount < min {
+					min = r.Amount
+				}
+				if r.Amount > max {
+					max = r.Amount
+				}
+			}
+			info.RateRange = &rateRange{CurrencyCode: h.Rooms[0].Currency, Max: amount(max), Min: amount(min)}
+		}
+		rs.Options = append(rs.Options, availOption{RPH: fmt.Sprintf("%03d", ws.next+i+1), Info: info})
+	}
+	ws.next = end
+	rs.Result = complete(c.now, hc)
+	rs.AdditionalAvail = &additionalAvail{Ind: ws.next < len(ws.avail)}
+	return rs
+}
+
+func (s *Sim) hotelPropDesc(c *call) any {
+	rq, q := htlsp.HotelPropDescRQ{}, hotelRefs{}
+	if f := c.decode(&rq); f != nil {
+		return f
+	}
+	_ = c.decode(&q)
+	rs := hotelDescRS{XMLName: xml.Name{Local: "HotelPropertyDescriptionRS"}, XMLNS: hotelNS, Version: "2.3.0"}
+	var hotel *Hotel
+	if len(q.Refs) > 0 {
+		for i, h := range s.conf.Hotels {
+			if h.Code == q.Refs[0].HotelCode {
+				hotel = &s.conf.Hotels[i]
+			}
+		}
+	}
+	if hotel == nil {
+		rs.Result = notProcessed(c.now, "INVALID PROPERTY NUMBER")
+		return rs
+	}
+	start, end, ok := stay(rq.Avail.TimeSpan, c.now)
+	if !ok {
+		rs.Result = notProcessed(c.now, "CK DATE")
+		return rs
+	}
+	guests := 1
+	if rq.Avail.GuestCounts != nil && rq.Avail.GuestCounts.Count > 0 {
+		guests = rq.Avail.GuestCounts.Count
+	}
+	ws := &c.sess.ws
+	ws.offers = make(map[string]offer)
+	rs.RoomStay = &roomStay{Info: newPropertyInfo(*hotel)}
+	for i, room := range hotel.Rooms {
+		line := fmt.Sprintf("%03d", i+1)
+		o := offer{hotel: *hotel, room: room, start: start, end: end, guests: guests}
+		ws.offers[line] = o
+		rs.RoomStay.RoomRates = append(rs.RoomStay.RoomRates, o.roomRate(line))
+		rs.RoomStay.TimeSpan = o.timeSpan()
+	}
+	rs.Result = complete(c.now, fmt.Sprintf("HOD%s/%s-%s%d", hotel.Code, rq.Avail.TimeSpan.Arrive, rq.Avail.TimeSpan.Depart, guests))
+	return rs
+}
+
+func (s *Sim) hotelRateDesc(c *call) any {
+	rq := htlsp.HotelRateDescRQ{}
+	if f := c.decode(&rq); f != nil {
+		return f
+	}
+	rs := hotelDescRS{XMLName: xml.Name{Local: "HotelRateDescriptionRS"}, XMLNS: hotelNS, Version: "2.3.0"}
+	rpc := rq.Avail.RatePlanCandidates
+	if rpc == nil || len(rpc.RatePlans) == 0 {
+		rs.Result = notProcessed(c.now, "FORMAT")
+		return rs
+	}
+	line := rph(rpc.RatePlans[0].RPH)
+	o, ok := c.sess.ws.offers[line]
+	if !ok {
+		rs.Result = notProcessed(c.now, "INVALID LINE NUMBER")
+		return rs
+	}
+	o.described = true
+	c.sess.ws.offers[line] = o
+	rs.RoomStay = &roomStay{Info: newPropertyInfo(o.hotel), RoomRates: []roomRate{o.roomRate(line)}, TimeSpan: o.timeSpan()}
+	rs.Result = complete(c.now, "HOT*"+line)
+	return rs
+}
+
+func (s *Sim) hotelRes(c *call) any {
+	rq := htlsp.OTAHotelResRQ{}
+	if f := c.decode(&rq); f != nil {
+		return f
+	}
+	rs := hotelResRS{XMLNS: hotelNS, Version: "2.2.0"}
+	ws := &c.sess.ws
+	if len(ws.names) == 0 && ws.locator == "" {
+		rs.Result = notProcessed(c.now, "NEED NAME IN PNR")
+		return rs
+	}
+	line := rph(rq.Hotel.BasicPropertyRes.RPH)
+	o, ok := ws.offers[line]
+	if !ok {
+		rs.Result = notProcessed(c.now, "INVALID LINE NUMBER")
+		return rs
+	}
+	if o.room.RateDescRequired && !o.described {
+		rs.Result = notProcessed(c.now, "HOTEL RATE DESCRIPTION REQUIRED BEFORE SELL")
+		return rs
+	}
+	units := rq.Hotel.RoomType.NumberOfUnits
+	if units < 1 {
+		units = 1
+	}
+	seg := HotelSegment{
+		Chain:        o.hotel.Chain,
+		Code:         o.hotel.Code,
+		Name:         o.hotel.Name,
+		City:         o.hotel.City,
+		RoomType:     o.room.Type,
+		Units:        units,
+		Guests:       o.guests,
+		Start:        o.start,
+		End:          o.end,
+		Amount:       o.total() * float64(units),
+		Currency:     o.room.Currency,
+		Confirmation: fmt.Sprintf("%08d", s.rnd.Intn(100000000)),
+	}
+	ws.hotels = append(ws.hotels, seg)
+	info := newPropertyInfo(o.hotel)
+	info.ConfirmationNumber = seg.Confirmation
+	rs.Hotel = &hotelResult{Info: info, RoomRates: []roomRate{o.roomRate(line)}, TimeSpan: o.timeSpan()}
+	rs.Result = complete(c.now, fmt.Sprintf("0H%d%s", units, line))
+	return rs
+}
@@ -0,0 +1,304 @@
+package sabresim
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ailgroup/sbrweb/soap/itin"
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+const (
+	pnrNS         = "http://webservices.sabre.com/pnrbuilder/v1_19"
+	locatorLetter = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+)
+
+// Name of a passenger.
+type Name struct {
+	First string
+	Last  string
+}
+
+// HotelSegment sold by OTA_HotelRes.
+type HotelSegment struct {
+	Chain        string
+	Code         string
+	Name         string
+	City         string
+	RoomType     string
+	Units        int
+	Guests       int
+	Start        time.Time
+	End          time.Time
+	Amount       float64 //total for the stay and every unit
+	Currency     string
+	Confirmation string
+}
+
+// PNR stored by EndTransaction.
+type PNR struct {
+	Locator      string
+	PCC          string
+	Created      time.Time
+	Updated      time.Time
+	ReceivedFrom string
+	Names        []Name
+	Phones       []string
+	Hotels       []HotelSegment
+}
+
+func (p *PNR) copy() PNR {
+	cp := *p
+	cp.Names = append([]Name(nil), p.Names...)
+	cp.Phones = append([]string(nil), p.Phones...)
+	cp.Hotels = append([]HotelSegment(nil), p.Hotels...)
+	return cp
+}
+
+// locator not yet issued.
+func (s *Sim) locator() string {
+	for {
+		b := make([]byte, 6)
+		for i := range b {
+			b[i] = locatorLetter[s.rnd.Intn(len(locatorLetter))]
+		}
+		if _, taken := s.pnrs[string(b)]; !taken {
+			return string(b)
+		}
+	}
+}
+
+type personName struct {
+	NameNumber string `xml:"NameNumber,attr"`
+	GivenName  string `xml:"GivenName"`
+	Surname    string `xml:"Surname"`
+}
+
+type itineraryRef struct {
+	XMLName xml.Name `xml:"ItineraryRef"`
+	ID      string   `xml:"ID,attr,omitempty"`
+	Source  struct {
+		PseudoCityCode string `xml:"PseudoCityCode,attr"`
+		CreateDateTime string `xml:"CreateDateTime,attr,omitempty"`
+	} `xml:"Source"`
+}
+
+type passengerDetailsRS struct {
+	XMLName      xml.Name `xml:"PassengerDetailsRS"`
+	XMLNS        string   `xml:"xmlns,attr"`
+	Result       appResults
+	Names        []personName `xml:"TravelItineraryReadRS>TravelItinerary>CustomerInfo>PersonName"`
+	ItineraryRef itineraryRef `xml:"TravelItineraryReadRS>TravelItinerary>ItineraryRef"`
+}
+
+type endTransactionRS struct {
+	XMLName      xml.Name `xml:"EndTransactionRS"`
+	XMLNS        string   `xml:"xmlns,attr"`
+	Version      string   `xml:"Version,attr"`
+	Result       appResults
+	ItineraryRef *itineraryRef
+}
+
+type ignoreTransactionRS struct {
+	XMLName xml.Name `xml:"IgnoreTransactionRS"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Version string   `xml:"Version,attr"`
+	Result  appResults
+}
+
+type pnrError struct {
+	Code     string `xml:"Code"`
+	Message  string `xml:"Message"`
+	Severity string `xml:"Severity"`
+}
+
+type passenger struct {
+	ID        string `xml:"id,attr"`
+	NameType  string `xml:"nameType,attr"`
+	LastName  string `xml:"LastName"`
+	FirstName string `xml:"FirstName"`
+}
+
+type hotelReservation struct {
+	NumberInParty    string `xml:"NumberInParty,attr"`
+	LineNumber       string `xml:"LineNumber"`
+	LineType         string `xml:"LineType"`
+	LineStatus       string `xml:"LineStatus"`
+	RoomTypeCode     string `xml:"RoomType>RoomTypeCode"`
+	NumberOfUnits    string `xml:"RoomType>NumberOfUnits"`
+	AmountBeforeTax  string `xml:"RoomRates>AmountBeforeTax"`
+	CurrencyCode     string `xml:"RoomRates>CurrencyCode"`
+	GuestCount       string `xml:"GuestCounts>GuestCount"`
+	TimeSpanStart    string `xml:"TimeSpanStart"`
+	TimeSpanDuration string `xml:"TimeSpanDuration"`
+	TimeSpanEnd      string `xml:"TimeSpanEnd"`
+	ChainCode        string `xml:"ChainCode"`
+	HotelCode        string `xml:"HotelCode"`
+	HotelCityCode    string `xml:"HotelCityCode"`
+	HotelName        string `xml:"HotelName"`
+}
+
+type segment struct {
+	Sequence string `xml:"sequence,attr"`
+	ID       string `xml:"id,attr"`
+	Hotel    struct {
+		ID          string           `xml:"id,attr"`
+		Sequence    string           `xml:"sequence,attr"`
+		Reservation hotelReservation `xml:"Reservation"`
+	} `xml:"Hotel"`
+}
+
+type reservation struct {
+	XMLName         xml.Name    `xml:"Reservation"`
+	NumberInParty   int         `xml:"numberInParty,attr"`
+	NumberInSegment int         `xml:"numberInSegment,attr"`
+	RecordLocator   string      `xml:"BookingDetails>RecordLocator"`
+	CreationTime    string      `xml:"BookingDetails>CreationTimestamp"`
+	UpdateTime      string      `xml:"BookingDetails>UpdateTimestamp"`
+	PseudoCityCode  string      `xml:"POS>Source>PseudoCityCode,omitempty"`
+	Passengers      []passenger `xml:"PassengerReservation>Passengers>Passenger"`
+	Segments        []segment   `xml:"PassengerReservation>Segments>Segment"`
+	ReceivedFrom    string      `xml:"ReceivedFrom>Name"`
+}
+
+type getReservationRS struct {
+	XMLName     xml.Name   `xml:"GetReservationRS"`
+	XMLNS       string     `xml:"xmlns,attr"`
+	Version     string     `xml:"Version,attr"`
+	Errors      []pnrError `xml:"Errors>Error"`
+	Reservation *reservation
+}
+
+func newReservation(p *PNR) *reservation {
+	r := &reservation{
+		NumberInParty:   len(p.Names),
+		NumberInSegment: len(p.Hotels),
+		RecordLocator:   p.Locator,
+		CreationTime:    p.Created.Format(srvc.StandardTimeFormatter),
+		UpdateTime:      p.Updated.Format(srvc.StandardTimeFormatter),
+		PseudoCityCode:  p.PCC,
+		ReceivedFrom:    p.ReceivedFrom,
+	}
+	for i, n := range p.Names {
+		r.Passengers = append(r.Passengers, passenger{ID: strconv.Itoa(i + 1), NameType: "S", LastName: n.Last, FirstName: n.First})
+	}
+	for i, h := range p.Hotels {
+		seg := segment{Sequence: strconv.Itoa(i + 1), ID: strconv.Itoa(i + 1)}
+		seg.Hotel.ID, seg.Hotel.Sequence = seg.ID, seg.Sequence
+		seg.Hotel.Reservation = hotelReservation{
+			NumberInParty:    strconv.Itoa(h.Guests),
+			LineNumber:       strconv.Itoa(i + 1),
+			LineType:         "HHL",
+			LineStatus:       "HK",
+			RoomTypeCode:     h.RoomType,
+			NumberOfUnits:    strconv.Itoa(h.Units),
+			AmountBeforeTax:  amount(h.Amount),
+			CurrencyCode:     h.Currency,
+			GuestCount:       strconv.Itoa(h.Guests),
+			TimeSpanStart:    h.Start.Format("2006-01-02T15:04:05"),
+			TimeSpanDuration: strconv.Itoa(int(h.End.Sub(h.Start).Hours()/24 + 0.5)),
+			TimeSpanEnd:      h.End.Format("2006-01-02T15:04:05"),
+			ChainCode:        h.Chain,
+			HotelCode:        h.Code,
+			HotelCityCode:    h.City,
+			HotelName:        h.Name,
+		}
+		r.Segments = append(r.Segments, seg)
+	}
+	return r
+}
+
+func (s *Sim) passengerDetails(c *call) any {
+	rq := itin.PassengerDetailsRQ{}
+	if f := c.decode(&rq); f != nil {
+		return f
+	}
+	rs := passengerDetailsRS{XMLNS: "http://services.sabre.com/sp/pd/v3_3"}
+	cust := rq.TravelItinInfo.Customer
+	if cust.PersonName.Last.Val == "" {
+		rs.Result = notProcessed(c.now, "NEED SURNAME")
+		return rs
+	}
+	ws := &c.sess.ws
+	name := Name{Last: cust.PersonName.Last.Val}
+	if cust.PersonName.First != nil {
+		name.First = cust.PersonName.First.Val
+	}
+	ws.names = append(ws.names, name)
+	for _, num := range cust.ContactNumbers {
+		ws.phones = append(ws.phones, num.Phone)
+	}
+	names := ws.names
+	if p, ok := s.pnrs[ws.locator]; ok {
+		names = append(append([]Name(nil), p.Names...), ws.names...)
+	}
+	for i, n := range names {
+		rs.Names = append(rs.Names, personName{NameNumber: fmt.Sprintf("%d.1", i+1), GivenName: n.First, Surname: n.Last})
+	}
+	rs.ItineraryRef.ID = ws.locator
+	rs.ItineraryRef.Source.PseudoCityCode = c.sess.pcc
+	rs.Result = complete(c.now, "")
+	return rs
+}
+
+func (s *Sim) endTransaction(c *call) any {
+	rq := itin.EndTransactionRQ{}
+	if f := c.decode(&rq); f != nil {
+		return f
+	}
+	rs := endTransactionRS{XMLNS: srvc.BaseWebServicesNS, Version: "2.0.9"}
+	ws := &c.sess.ws
+	p, ok := s.pnrs[ws.locator]
+	switch {
+	case !ok && len(ws.names) == 0:
+		rs.Result = notProcessed(c.now, "NEED NAME IN PNR")
+		return rs
+	case !ok && len(ws.hotels) == 0:
+		rs.Result = notProcessed(c.now, "NEED ITINERARY")
+		return rs
+	case !ok:
+		p = &PNR{Locator: s.locator(), PCC: c.sess.pcc, Created: c.now}
+		s.pnrs[p.Locator] = p
+	}
+	p.Updated = c.now
+	p.ReceivedFrom = rq.Source.ReceivedFrom
+	p.Names = append(p.Names, ws.names...)
+	p.Phones = append(p.Phones, ws.phones...)
+	p.Hotels = append(p.Hotels, ws.hotels...)
+	ws.clear()
+	rs.ItineraryRef = &itineraryRef{ID: p.Locator}
+	rs.ItineraryRef.Source.PseudoCityCode = p.PCC
+	rs.ItineraryRef.Source.CreateDateTime = p.Created.Format(srvc.TimeFormatMDTHM)
+	rs.Result = complete(c.now, "E")
+	return rs
+}
+
+func (s *Sim) getReservation(c *call) any {
+	rq := itin.GetReservationRQ{}
+	if f := c.decode(&rq); f != nil {
+		return f
+	}
+	rs := getReservationRS{XMLNS: pnrNS, Version: "1.19.0"}
+	ws := &c.sess.ws
+	p, ok := s.pnrs[rq.Locator.Val]
+	switch {
+	case !ok:
+		rs.Errors = []pnrError{{Code: "ERR.SWS.CLIENT.NOT_FOUND", Message: "NO RECORD LOCATOR FOUND: " + rq.Locator.Val, Severity: "ERROR"}}
+		return rs
+	case rq.RequestType.Val != "Stateful":
+	case ws.pending() && ws.locator != p.Locator:
+		rs.Errors = []pnrError{{Code: "ERR.SWS.CLIENT.UPDATES_OUTSTANDING", Message: "FINISH OR IGNORE CURRENT TRANSACTION", Severity: "ERROR"}}
+		return rs
+	default:
+		ws.locator = p.Locator
+	}
+	rs.Reservation = newReservation(p)
+	return rs
+}
+
+func (s *Sim) ignoreTransaction(c *call) any {
+	c.sess.ws.clear()
+	return ignoreTransactionRS{XMLNS: srvc.BaseWebServicesNS, Version: "2.0.0", Result: complete(c.now, "I")}
+}
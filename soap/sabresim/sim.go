@@ -0,0 +1,413 @@
+/*
+Package sabresim is an in-process, stateful fake of the Sabre SOAP services used by srvc, htlsp and itin. It routes on MessageHeader.Action, keeps sessions with their AAA workspace, and stores the PNRs it ends, so a whole booking flow runs with no network.
+
+	sim := sabresim.New(sabresim.Config{MaxSessions: 4})
+	ctx := srvc.WithTransport(context.Background(), sim.Transport())
+	createRS, err := srvc.CallSessionCreateContext(ctx, "http://sabresim", srvc.BuildSessionCreateRequest(conf))
+	availRS, err := htlsp.CallHotelAvailContext(ctx, "http://sabresim", htlsp.BuildHotelAvailRequest(conf, binsec, body))
+	...
+
+Sim is also an http.Handler for httptest.NewServer or a local development server. Supported actions:
+
+	SessionCreateRQ, SessionValidateRQ, SessionCloseRQ
+	OTA_HotelAvailLLSRQ (AdditionalAvail paging), HotelPropertyDescriptionLLSRQ, HotelRateDescriptionLLSRQ, OTA_HotelResLLSRQ
+	PassengerDetailsRQ, EndTransactionLLSRQ, GetReservationRQ, IgnoreTransactionLLSRQ
+
+Anything else gets a SOAP fault. Faults are sent with status 500 like Sabre does; a srvc.Transport with a Breaker or Retry tells them from outages by the soap-env:Fault body and neither retries nor counts them.
+*/
+package sabresim
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+const (
+	DefaultSessionTTL = 15 * time.Minute
+	DefaultPageSize   = 10
+	DefaultURL        = "http://sabresim.local/websvc" //any service URL works with Sim.Transport, this one reads well in logs and cassettes
+
+	FaultInvalidToken   = "soap-env:Client.InvalidSecurityToken"
+	FaultAuthentication = "soap-env:Client.AuthenticationFailed"
+	FaultSessionLimit   = "soap-env:Client.ReachedSessionLimit"
+	FaultInvalidAction  = "soap-env:Client.InvalidAction"
+	FaultInvalidMessage = "soap-env:Client.InvalidEbXmlMessage"
+
+	stlNS     = "http://services.sabre.com/STL/v01"
+	hotelNS   = "http://webservices.sabre.com/sabreXML/2011/10"
+	tokenType = "Shared/IDL:IceSess\\/SessMgr:1\\.0.IDL/Common/!ICESMS\\/RESB!ICESMSLB\\/RES.LB!"
+)
+
+// Config of a Sim, the zero value is usable.
+type Config struct {
+	Username    string           //when set, SessionCreate must send it
+	Password    string           //when set, SessionCreate must send it
+	SessionTTL  time.Duration    //idle time before a token expires, DefaultSessionTTL when 0
+	MaxSessions int              //open sessions per PCC, 0 no limit
+	PageSize    int              //availability options per page, DefaultPageSize when 0
+	Hotels      []Hotel          //inventory, DefaultHotels when nil
+	Now         func() time.Time //clock, time.Now when nil; lets tests expire sessions
+}
+
+// Sim is a fake Sabre; safe for concurrent use.
+type Sim struct {
+	conf     Config
+	mu       sync.Mutex
+	rnd      *rand.Rand
+	sessions map[string]*session
+	pnrs     map[string]*PNR
+}
+
+// session is one BinarySecurityToken and its AAA workspace.
+type session struct {
+	token    string
+	pcc      string
+	lastUsed time.Time
+	ws       workspace
+}
+
+// New Sim for conf.
+func New(conf Config) *Sim {
+	if conf.SessionTTL <= 0 {
+		conf.SessionTTL = DefaultSessionTTL
+	}
+	if conf.PageSize <= 0 {
+		conf.PageSize = DefaultPageSize
+	}
+	if conf.Hotels == nil {
+		conf.Hotels = DefaultHotels
+	}
+	if conf.Now == nil {
+		conf.Now = time.Now
+	}
+	return &Sim{
+		conf:     conf,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		sessions: make(map[string]*session),
+		pnrs:     make(map[string]*PNR),
+	}
+}
+
+// Transport posting to the Sim in process; any service URL works.
+func (s *Sim) Transport() *srvc.Transport {
+	return srvc.NewTransport(&http.Client{Transport: s})
+}
+
+// Conf for serviceURL with the Username "user" and Password "pass"; set Config to match when it checks credentials.
+func Conf(serviceURL string) *srvc.SessionConf {
+	return &srvc.SessionConf{
+		ServiceURL: serviceURL,
+		From:       "www.z.com",
+		PCC:        "7TZA",
+		Convid:     "cid:sabresim|www.z.com",
+		Username:   "user",
+		Password:   "pass",
+	}
+}
+
+// CreateSession for conf through the Transport in ctx, returning the BinarySecurityToken; a fault is returned as its sbrerr.ErrorSoapFault.
+func CreateSession(ctx context.Context, conf *srvc.SessionConf) (string, error) {
+	rs, err := srvc.CallSessionCreateContext(ctx, conf.ServiceURL, srvc.BuildSessionCreateRequest(conf))
+	if err != nil {
+		return "", err
+	}
+	if !rs.Body.Fault.Ok() {
+		return "", rs.Body.Fault.Format()
+	}
+	return rs.Header.Security.BinarySecurityToken.Value, nil
+}
+
+// Sessions open for pcc, every PCC when pcc is empty; expired sessions are not counted.
+func (s *Sim) Sessions(pcc string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.conf.Now())
+	n := 0
+	for _, sess := range s.sessions {
+		if pcc == "" || sess.pcc == pcc {
+			n++
+		}
+	}
+	return n
+}
+
+// Reservation stored under locator by EndTransaction.
+func (s *Sim) Reservation(locator string) (PNR, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pnr, ok := s.pnrs[locator]
+	if !ok {
+		return PNR{}, false
+	}
+	return pnr.copy(), true
+}
+
+// RoundTrip serves r in process, see Transport.
+func (s *Sim) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	status, out := s.handle(body)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/xml; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(out)),
+		ContentLength: int64(len(out)),
+		Request:       r,
+	}, nil
+}
+
+// ServeHTTP answers soap posts.
+func (s *Sim) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "sabresim only accepts POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status, out := s.handle(body)
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	defer r.Body.Close()
+	return io.ReadAll(r.Body)
+}
+
+// requestEnvelope any session based request; the payload is decoded by the action handler.
+type requestEnvelope struct {
+	Header srvc.SessionHeaderUnmarsh
+	Body   struct {
+		Payload []byte `xml:",innerxml"`
+	}
+}
+
+// responseEnvelope any response; Payload marshals under its own XMLName.
+type responseEnvelope struct {
+	srvc.Envelope
+	Header srvc.SessionHeader
+	Body   struct {
+		XMLName xml.Name `xml:"soap-env:Body"`
+		Payload any
+	}
+}
+
+// fault soap-env:Fault payload.
+type fault struct {
+	XMLName    xml.Name `xml:"soap-env:Fault"`
+	Code       string   `xml:"faultcode"`
+	String     string   `xml:"faultstring"`
+	StackTrace string   `xml:"detail>StackTrace"`
+}
+
+func newFault(code, msg string) *fault {
+	return &fault{Code: code, String: msg, StackTrace: "sabresim: " + code}
+}
+
+// call is one request being handled, sess is nil for SessionCreate until a session is made.
+type call struct {
+	header  srvc.SessionHeaderUnmarsh
+	payload []byte
+	now     time.Time
+	sess    *session
+}
+
+// decode payload into rq, a fault when it does not parse.
+func (c *call) decode(rq any) *fault {
+	if err := xml.Unmarshal(c.payload, rq); err != nil {
+		return newFault(FaultInvalidMessage, "unable to parse "+c.header.MessageHeader.Action+": "+err.Error())
+	}
+	return nil
+}
+
+type handler func(s *Sim, c *call) any
+
+// handlers by MessageHeader.Action
+var handlers = map[string]handler{
+	"SessionCreateRQ":               (*Sim).sessionCreate,
+	"SessionValidateRQ":             (*Sim).sessionValidate,
+	"SessionCloseRQ":                (*Sim).sessionClose,
+	"OTA_HotelAvailLLSRQ":           (*Sim).hotelAvail,
+	"HotelPropertyDescriptionLLSRQ": (*Sim).hotelPropDesc,
+	"HotelRateDescriptionLLSRQ":     (*Sim).hotelRateDesc,
+	"OTA_HotelResLLSRQ":             (*Sim).hotelRes,
+	"PassengerDetailsRQ":            (*Sim).passengerDetails,
+	"EndTransactionLLSRQ":           (*Sim).endTransaction,
+	"GetReservationRQ":              (*Sim).getReservation,
+	"IgnoreTransactionLLSRQ":        (*Sim).ignoreTransaction,
+}
+
+// handle one soap request, returning http status and response envelope.
+func (s *Sim) handle(body []byte) (int, []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rq := requestEnvelope{}
+	c := &call{now: s.conf.Now()}
+	var rs any
+	if err := xml.Unmarshal(body, &rq); err != nil {
+		rs = newFault(FaultInvalidMessage, "unable to parse envelope: "+err.Error())
+	} else {
+		c.header, c.payload = rq.Header, rq.Body.Payload
+		rs = s.route(c)
+	}
+	out, err := xml.Marshal(s.envelope(c, rs))
+	if err != nil {
+		return http.StatusInternalServerError, []byte(err.Error())
+	}
+	if _, ok := rs.(*fault); ok {
+		return http.StatusInternalServerError, out
+	}
+	return http.StatusOK, out
+}
+
+// route to the action handler, every action but SessionCreate needs a live token.
+func (s *Sim) route(c *call) any {
+	action := c.header.MessageHeader.Action
+	h, ok := handlers[action]
+	if !ok {
+		return newFault(FaultInvalidAction, "unsupported action: "+action)
+	}
+	if action != "SessionCreateRQ" {
+		sess, f := s.session(c)
+		if f != nil {
+			return f
+		}
+		c.sess = sess
+	}
+	return h(s, c)
+}
+
+// envelope for rs, the header echoes the request conversation and carries the session token.
+func (s *Sim) envelope(c *call, rs any) responseEnvelope {
+	mh := c.header.MessageHeader
+	env := responseEnvelope{
+		Envelope: srvc.CreateEnvelope(),
+		Header: srvc.SessionHeader{
+			MessageHeader: srvc.MessageHeader{
+				MustUnderstand: srvc.SabreMustUnderstand,
+				EbVersion:      srvc.SabreEBVersion,
+				From:           srvc.FromElem{PartyID: srvc.CreatePartyID(srvc.SabreToBase, srvc.PartyIDTypeURN)},
+				To:             srvc.ToElem{PartyID: srvc.CreatePartyID(mh.From.PartyID.Value, srvc.PartyIDTypeURN)},
+				CPAID:          mh.CPAID,
+				ConversationID: mh.ConversationID,
+				Service:        srvc.ServiceElem{Value: mh.Service, Type: srvc.ServiceTypeSabreXML},
+				Action:         strings.TrimSuffix(mh.Action, "RQ") + "RS",
+				MessageData: srvc.MessageDataElem{
+					MessageID: srvc.GenerateMessageID(),
+					Timestamp: c.now.UTC().Format(srvc.StandardTimeFormatter),
+				},
+			},
+			Security: srvc.Security{
+				XMLNSWsseBase: srvc.BaseWsse,
+				XMLNSWsu:      srvc.BaseWsuNameSpace,
+			},
+		},
+	}
+	if c.sess != nil {
+		env.Header.Security.BinarySecurityToken = c.sess.token
+	}
+	//Sabre answers every fault with ErrorRS, the session pool keys on it
+	if _, ok := rs.(*fault); ok {
+		env.Header.MessageHeader.Action = srvc.StatusErrorRS
+	}
+	env.Body.Payload = rs
+	return env
+}
+
+// session for the request token; a fault when the token is unknown, closed or expired.
+func (s *Sim) session(c *call) (*session, *fault) {
+	token := c.header.Security.BinarySecurityToken.Value
+	sess, ok := s.sessions[token]
+	if ok && c.now.Sub(sess.lastUsed) > s.conf.SessionTTL {
+		delete(s.sessions, token)
+		ok = false
+	}
+	if !ok {
+		return nil, newFault(FaultInvalidToken, "Invalid or Expired binary security token: "+token)
+	}
+	sess.lastUsed = c.now
+	return sess, nil
+}
+
+// expire sessions idle longer than SessionTTL.
+func (s *Sim) expire(now time.Time) {
+	for token, sess := range s.sessions {
+		if now.Sub(sess.lastUsed) > s.conf.SessionTTL {
+			delete(s.sessions, token)
+		}
+	}
+}
+
+// sessionStatus SessionCreateRS, SessionValidateRS and SessionCloseRS payload.
+type sessionStatus struct {
+	XMLName        xml.Name
+	XMLNS          string `xml:"xmlns,attr,omitempty"`
+	Version        string `xml:"version,attr"`
+	Status         string `xml:"status,attr"`
+	ConversationID string `xml:"ConversationId,omitempty"`
+}
+
+func (s *Sim) sessionCreate(c *call) any {
+	user := c.header.Security.UserNameToken
+	if (s.conf.Username != "" && user.Username != s.conf.Username) || (s.conf.Password != "" && user.Password != s.conf.Password) {
+		return newFault(FaultAuthentication, "Authentication failed")
+	}
+	pcc := user.Organization
+	if pcc == "" {
+		pcc = c.header.MessageHeader.CPAID
+	}
+	s.expire(c.now)
+	if s.conf.MaxSessions > 0 {
+		open := 0
+		for _, sess := range s.sessions {
+			if sess.pcc == pcc {
+				open++
+			}
+		}
+		if open >= s.conf.MaxSessions {
+			return newFault(FaultSessionLimit, fmt.Sprintf("Session limit of %d reached for %s", s.conf.MaxSessions, pcc))
+		}
+	}
+	token := fmt.Sprintf("%s-%d!%d!0", tokenType, s.rnd.Int63(), s.rnd.Intn(10000000))
+	c.sess = &session{token: token, pcc: pcc, lastUsed: c.now}
+	s.sessions[token] = c.sess
+	return sessionStatus{
+		XMLName:        xml.Name{Local: "SessionCreateRS"},
+		XMLNS:          "http://www.opentravel.org/OTA/2002/11",
+		Version:        "1",
+		Status:         "Approved",
+		ConversationID: c.header.MessageHeader.ConversationID,
+	}
+}
+
+func (s *Sim) sessionValidate(c *call) any {
+	return sessionStatus{XMLName: xml.Name{Local: "SessionValidateRS"}, Version: "1", Status: "Approved"}
+}
+
+func (s *Sim) sessionClose(c *call) any {
+	delete(s.sessions, c.sess.token)
+	return sessionStatus{XMLName: xml.Name{Local: "SessionCloseRS"}, Version: "1", Status: "Approved"}
+}
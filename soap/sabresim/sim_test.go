@@ -0,0 +1,336 @@
+package sabresim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+	"github.com/ailgroup/sbrweb/soap/htlsp"
+	"github.com/ailgroup/sbrweb/soap/itin"
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+// clock for expiring sessions in tests
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestBookingFlow(t *testing.T) {
+	sim := New(Config{PageSize: 2, Username: "user", Password: "pass"})
+	ctx := srvc.WithTransport(context.Background(), sim.Transport())
+	conf := Conf(DefaultURL)
+
+	binsec, err := CreateSession(ctx, conf)
+	if err != nil {
+		t.Fatalf("CreateSession expect: nil, got: %v", err)
+	}
+	if binsec == "" {
+		t.Fatal("BinarySecurityToken expect: token, got: empty")
+	}
+
+	//availability, 4 DFW hotels paged by 2
+	q, _ := htlsp.NewHotelSearchCriteria(htlsp.HotelRefSearch(htlsp.HotelRefCriterion{htlsp.CityQueryField: []string{"DFW"}}))
+	availRS, err := htlsp.CallHotelAvailContext(ctx, DefaultURL, htlsp.BuildHotelAvailRequest(conf, binsec, htlsp.SetHotelAvailBody(2, q, "06-07", "06-09")))
+	if err != nil {
+		t.Fatalf("CallHotelAvailContext expect: nil, got: %v", err)
+	}
+	opts := availRS.Body.HotelAvail.AvailOpts.AvailableOptions
+	if len(opts) != 2 || opts[0].RPH != "001" || opts[0].PropertyInfo.HotelCode != "0012345" {
+		t.Fatalf("AvailableOptions expect: 2 starting with 001 0012345, got: %+v", opts)
+	}
+	availRS, err = htlsp.CallHotelAvailContext(ctx, DefaultURL, htlsp.HOTStar(conf, binsec))
+	if err != nil {
+		t.Fatalf("CallHotelAvailContext page 2 expect: nil, got: %v", err)
+	}
+	opts = availRS.Body.HotelAvail.AvailOpts.AvailableOptions
+	if len(opts) != 2 || opts[1].RPH != "004" || opts[1].PropertyInfo.HotelCityCode != "DFW" {
+		t.Errorf("AvailableOptions page 2 expect: 2 ending with 004 DFW, got: %+v", opts)
+	}
+	_, err = htlsp.CallHotelAvailContext(ctx, DefaultURL, htlsp.HOTStar(conf, binsec))
+	if err == nil {
+		t.Error("CallHotelAvailContext page 3 expect: NO MORE DATA, got: nil")
+	}
+
+	//property description, 2 nights
+	q, _ = htlsp.NewHotelSearchCriteria(htlsp.HotelRefSearch(htlsp.HotelRefCriterion{htlsp.HotelidQueryField: []string{"0012345"}}))
+	propBody, _ := htlsp.SetHotelPropDescBody(2, q, "06-07", "06-09")
+	propRS, err := htlsp.CallHotelPropDescContext(ctx, DefaultURL, htlsp.BuildHotelPropDescRequest(conf, binsec, propBody))
+	if err != nil {
+		t.Fatalf("CallHotelPropDescContext expect: nil, got: %v", err)
+	}
+	rates := propRS.Body.HotelDesc.RoomStay.RoomRates
+	if len(rates) != 3 {
+		t.Fatalf("RoomRates expect: 3, got: %d", len(rates))
+	}
+	if rates[0].Rates[0].HotelPricing.Amount != "258.00" {
+		t.Errorf("HotelTotalPricing.Amount expect: 258.00, got: %s", rates[0].Rates[0].HotelPricing.Amount)
+	}
+	sell := rates[2]
+	if sell.Rates[0].HRD_RequiredForSell != "true" {
+		t.Fatalf("HRD_RequiredForSell expect: true, got: %s", sell.Rates[0].HRD_RequiredForSell)
+	}
+
+	//selling before a name is in the PNR fails
+	resBody := htlsp.SetHotelResBody(1)
+	resBody.NewPropertyResByRPH(sell.RPH)
+	resRS, _ := htlsp.CallHotelResContext(ctx, DefaultURL, htlsp.BuildHotelResRequest(conf, binsec, resBody))
+	if resRS.Body.HotelRes.Result.Ok() {
+		t.Error("HotelRes without name expect: NotProcessed, got: ok")
+	}
+
+	pnrRS, err := itin.CallPNRDetailContext(ctx, DefaultURL, itin.BuildPNRDetailsRequest(conf, binsec, itin.SetPNRDetailBody("123-456-7890", itin.CreatePersonName("Jane", "Doe"))))
+	if err != nil {
+		t.Fatalf("CallPNRDetailContext expect: nil, got: %v", err)
+	}
+	if name := pnrRS.Body.PassengerDetailsRS.TravelItineraryReadRS.TravelItinerary.Customer.PersonName; name.Last.Val != "Doe" {
+		t.Errorf("PersonName.Surname expect: Doe, got: %s", name.Last.Val)
+	}
+
+	//selling a rate that needs HotelRateDescription first fails
+	resRS, _ = htlsp.CallHotelResContext(ctx, DefaultURL, htlsp.BuildHotelResRequest(conf, binsec, resBody))
+	if resRS.Body.HotelRes.Result.Ok() {
+		t.Error("HotelRes without rate description expect: NotProcessed, got: ok")
+	}
+	rpc := &htlsp.RatePlanCandidates{}
+	rpc.SetRatePlans([]htlsp.RatePlan{{RPH: sell.RPH}})
+	rateBody, _ := htlsp.SetHotelRateDescBody(rpc)
+	if _, err = htlsp.CallHotelRateDescContext(ctx, DefaultURL, htlsp.BuildHotelRateDescRequest(conf, binsec, rateBody)); err != nil {
+		t.Fatalf("CallHotelRateDescContext expect: nil, got: %v", err)
+	}
+	resRS, err = htlsp.CallHotelResContext(ctx, DefaultURL, htlsp.BuildHotelResRequest(conf, binsec, resBody))
+	if err != nil {
+		t.Fatalf("CallHotelResContext expect: nil, got: %v", err)
+	}
+	confirmation := resRS.Body.HotelRes.Hotel.BasicProperty.ConfirmationNumber.Val
+	if confirmation == "" {
+		t.Error("ConfirmationNumber expect: number, got: empty")
+	}
+
+	endRS, err := itin.CallEndTransactionContext(ctx, DefaultURL, itin.BuildEndTransactionRequest(conf, binsec))
+	if err != nil {
+		t.Fatalf("CallEndTransactionContext expect: nil, got: %v", err)
+	}
+	locator := endRS.Body.EndTransactionRS.ItineraryRef.ID
+	if len(locator) != 6 {
+		t.Fatalf("ItineraryRef.ID expect: 6 letter locator, got: %s", locator)
+	}
+
+	getRS, err := itin.CallGetReservationContext(ctx, DefaultURL, itin.BuildGetReservationRequest(conf, binsec, locator))
+	if err != nil {
+		t.Fatalf("CallGetReservationContext expect: nil, got: %v", err)
+	}
+	res := getRS.Body.GetReservationRS.Reservation
+	if res.BookingDetails.RecordLocator != locator {
+		t.Errorf("RecordLocator expect: %s, got: %s", locator, res.BookingDetails.RecordLocator)
+	}
+	if len(res.PassengerReservation.Passengers) != 1 || res.PassengerReservation.Passengers[0].LastName != "Doe" {
+		t.Errorf("Passengers expect: Doe, got: %+v", res.PassengerReservation.Passengers)
+	}
+	if h := res.PassengerReservation.Segments.Hotel.Reservation; h.HotelCode != "0012345" || h.RoomType.RoomTypeCode != "B1K" || h.TimeSpanDuration != "2" {
+		t.Errorf("Hotel segment expect: 0012345 B1K 2 nights, got: %+v", h)
+	}
+
+	pnr, ok := sim.Reservation(locator)
+	if !ok {
+		t.Fatalf("Reservation %s expect: stored, got: none", locator)
+	}
+	if pnr.PCC != conf.PCC || pnr.ReceivedFrom != conf.From || len(pnr.Hotels) != 1 || pnr.Hotels[0].Confirmation != confirmation || pnr.Hotels[0].Amount != 198 {
+		t.Errorf("Reservation expect: PCC, received from, hotel %s for 198, got: %+v", confirmation, pnr)
+	}
+
+	_, err = itin.CallGetReservationContext(ctx, DefaultURL, itin.BuildGetReservationRequest(conf, binsec, "ZZZZZZ"))
+	if err == nil {
+		t.Error("CallGetReservationContext unknown locator expect: error, got: nil")
+	}
+
+	if _, err = srvc.CallSessionCloseContext(ctx, DefaultURL, srvc.BuildSessionCloseRequest(conf, binsec)); err != nil {
+		t.Fatalf("CallSessionCloseContext expect: nil, got: %v", err)
+	}
+	if n := sim.Sessions(""); n != 0 {
+		t.Errorf("Sessions after close expect: 0, got: %d", n)
+	}
+}
+
+func TestEndTransactionEmptyWorkspace(t *testing.T) {
+	sim := New(Config{})
+	ctx := srvc.WithTransport(context.Background(), sim.Transport())
+	conf := Conf(DefaultURL)
+	binsec, err := CreateSession(ctx, conf)
+	if err != nil {
+		t.Fatalf("CreateSession expect: nil, got: %v", err)
+	}
+
+	_, err = itin.CallEndTransactionContext(ctx, DefaultURL, itin.BuildEndTransactionRequest(conf, binsec))
+	var result sbrerr.ErrorSabreResult
+	if !errors.As(err, &result) {
+		t.Fatalf("CallEndTransactionContext expect: ErrorSabreResult, got: %v", err)
+	}
+
+	_, err = itin.CallPNRDetailContext(ctx, DefaultURL, itin.BuildPNRDetailsRequest(conf, binsec, itin.SetPNRDetailBody("123-456-7890", itin.CreatePersonName("Jane", "Doe"))))
+	if err != nil {
+		t.Fatalf("CallPNRDetailContext expect: nil, got: %v", err)
+	}
+	if _, err = srvc.CallIgnoreTransactionContext(ctx, DefaultURL, srvc.BuildIgnoreTransactionRequest(conf, binsec)); err != nil {
+		t.Fatalf("CallIgnoreTransactionContext expect: nil, got: %v", err)
+	}
+	_, err = itin.CallEndTransactionContext(ctx, DefaultURL, itin.BuildEndTransactionRequest(conf, binsec))
+	if err == nil {
+		t.Error("CallEndTransactionContext after ignore expect: NEED NAME IN PNR, got: nil")
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	clk := &clock{now: time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)}
+	sim := New(Config{SessionTTL: time.Minute, Now: clk.Now})
+	ctx := srvc.WithTransport(context.Background(), sim.Transport())
+	conf := Conf(DefaultURL)
+	binsec, err := CreateSession(ctx, conf)
+	if err != nil {
+		t.Fatalf("CreateSession expect: nil, got: %v", err)
+	}
+
+	clk.Add(50 * time.Second)
+	rs, err := srvc.CallSessionValidateContext(ctx, DefaultURL, srvc.BuildSessionValidateRequest(conf, binsec))
+	if err != nil || rs.Body.SessionValidateRS.Status != "Approved" {
+		t.Fatalf("SessionValidate expect: Approved, got: %v %s", err, rs.Body.SessionValidateRS.Status)
+	}
+	//validate refreshed the token
+	clk.Add(50 * time.Second)
+	rs, _ = srvc.CallSessionValidateContext(ctx, DefaultURL, srvc.BuildSessionValidateRequest(conf, binsec))
+	if !rs.Body.Fault.Ok() {
+		t.Fatalf("SessionValidate expect: Approved, got: %s", rs.Body.Fault.Code)
+	}
+
+	clk.Add(2 * time.Minute)
+	rs, _ = srvc.CallSessionValidateContext(ctx, DefaultURL, srvc.BuildSessionValidateRequest(conf, binsec))
+	if rs.Body.Fault.Code != FaultInvalidToken {
+		t.Errorf("Fault.Code expect: %s, got: %s", FaultInvalidToken, rs.Body.Fault.Code)
+	}
+	if !rs.Body.Fault.SessionDead() {
+		t.Errorf("SessionDead expect: true, got: false for %v", rs.Body.Fault.Format())
+	}
+	if n := sim.Sessions(conf.PCC); n != 0 {
+		t.Errorf("Sessions expect: 0, got: %d", n)
+	}
+}
+
+// roundTripFunc counts requests passed on to a Sim.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestInvalidTokenNotFailure(t *testing.T) {
+	sim := New(Config{})
+	var calls int
+	tr := srvc.NewTransport(&http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return sim.RoundTrip(r)
+	})})
+	tr.Retry = &srvc.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	tr.Breaker = srvc.NewCircuitBreaker(2, time.Minute)
+	ctx := srvc.WithTransport(context.Background(), tr)
+	conf := Conf(DefaultURL)
+
+	for i := 0; i < 3; i++ {
+		rs, err := srvc.CallSessionValidateContext(ctx, DefaultURL, srvc.BuildSessionValidateRequest(conf, "no-such-token"))
+		if err != nil {
+			t.Fatalf("SessionValidate expect: fault, got: %v", err)
+		}
+		if !rs.Body.Fault.SessionDead() {
+			t.Fatalf("SessionDead expect: true, got: false for %v", rs.Body.Fault.Format())
+		}
+	}
+	if calls != 3 {
+		t.Errorf("SessionValidate attempts (faults not retried) expect: %d, got: %d", 3, calls)
+	}
+	if st := tr.Breaker.State(DefaultURL); st != srvc.CircuitClosed {
+		t.Errorf("Breaker.State expect: %v, got: %v", srvc.CircuitClosed, st)
+	}
+	//pool heals: a new session is not blocked by the breaker
+	if _, err := CreateSession(ctx, conf); err != nil {
+		t.Errorf("CreateSession expect: nil, got: %v", err)
+	}
+}
+
+func TestSessionLimit(t *testing.T) {
+	sim := New(Config{MaxSessions: 1, Username: "user", Password: "pass"})
+	ctx := srvc.WithTransport(context.Background(), sim.Transport())
+	conf := Conf(DefaultURL)
+	binsec, err := CreateSession(ctx, conf)
+	if err != nil {
+		t.Fatalf("CreateSession expect: nil, got: %v", err)
+	}
+
+	var fault sbrerr.ErrorSoapFault
+	if _, err = CreateSession(ctx, conf); !errors.As(err, &fault) || fault.FaultCode != FaultSessionLimit {
+		t.Errorf("FaultCode expect: %s, got: %v", FaultSessionLimit, err)
+	}
+	other := Conf(DefaultURL)
+	other.PCC = "AB12"
+	if _, err = CreateSession(ctx, other); err != nil {
+		t.Errorf("other PCC expect: nil, got: %v", err)
+	}
+
+	_, _ = srvc.CallSessionCloseContext(ctx, DefaultURL, srvc.BuildSessionCloseRequest(conf, binsec))
+	if _, err = CreateSession(ctx, conf); err != nil {
+		t.Errorf("after close expect: nil, got: %v", err)
+	}
+
+	bad := Conf(DefaultURL)
+	bad.Password = "wrong"
+	if _, err = CreateSession(ctx, bad); !errors.As(err, &fault) || fault.FaultCode != FaultAuthentication {
+		t.Errorf("FaultCode expect: %s, got: %v", FaultAuthentication, err)
+	}
+}
+
+func TestSessionPoolHTTP(t *testing.T) {
+	sim := New(Config{MaxSessions: 3})
+	ts := httptest.NewServer(sim)
+	defer ts.Close()
+	conf := Conf(DefaultURL)
+	conf.ServiceURL = ts.URL
+
+	pool := srvc.NewPool(srvc.ExpireScheme{Min: 10, Max: 14}, conf, time.Minute, 3)
+	if err := pool.Populate(); err != nil {
+		t.Fatalf("Populate expect: nil, got: %v", err)
+	}
+	if n := sim.Sessions(conf.PCC); n != 3 {
+		t.Errorf("Sessions expect: 3, got: %d", n)
+	}
+	err := pool.Do(context.Background(), func(sess srvc.Session) error {
+		_, err := srvc.CallSessionValidate(ts.URL, srvc.BuildSessionValidateRequest(conf, sess.BinSecTokCached))
+		return err
+	})
+	if err != nil {
+		t.Errorf("Do expect: nil, got: %v", err)
+	}
+	pool.Close()
+	if n := sim.Sessions(conf.PCC); n != 0 {
+		t.Errorf("Sessions after Close expect: 0, got: %d", n)
+	}
+
+	_, err = itin.CallEndTransaction(ts.URL, itin.BuildEndTransactionRequest(conf, "no-such-token"))
+	if err == nil {
+		t.Error("CallEndTransaction bad token expect: fault, got: nil")
+	}
+}
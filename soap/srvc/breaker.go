@@ -0,0 +1,127 @@
+package srvc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState of a CircuitBreaker for one service URL.
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota //requests go through
+	CircuitOpen                         //requests fail fast with ErrorCircuitOpen
+	CircuitHalfOpen                     //cooldown over, the next request is a trial
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// ErrorCircuitOpen is returned by Transport.Post without calling Sabre while the circuit for URL is open.
+type ErrorCircuitOpen struct {
+	URL   string
+	Until time.Time
+}
+
+func (e ErrorCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit open for '%s' until %s", e.URL, e.Until.Format(time.RFC3339))
+}
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+/*
+CircuitBreaker fails fast during Sabre brownouts instead of hammering the endpoint: after Threshold consecutive failed requests (network errors, HTTP 429, HTTP 5xx other than soap faults) to a service URL, requests to it fail with ErrorCircuitOpen for Cooldown. Then one trial request is let through; success closes the circuit, failure opens it for another Cooldown.
+
+	t.Breaker = srvc.NewCircuitBreaker(5, 30*time.Second)
+
+SessionPool.Stats reports the state for the pool ServiceURL.
+*/
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// NewCircuitBreaker opening after threshold consecutive failures for cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown, circuits: make(map[string]*circuit)}
+}
+
+func (b *CircuitBreaker) circuit(url string) *circuit {
+	if b.circuits == nil {
+		b.circuits = make(map[string]*circuit)
+	}
+	c, ok := b.circuits[url]
+	if !ok {
+		c = &circuit{}
+		b.circuits[url] = c
+	}
+	return c
+}
+
+// State of the circuit for url; CircuitClosed on a nil breaker.
+func (b *CircuitBreaker) State(url string) CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(url)
+	switch {
+	case b.Threshold <= 0 || c.failures < b.Threshold:
+		return CircuitClosed
+	case time.Now().Before(c.openUntil):
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// allow a request to url, ErrorCircuitOpen when the circuit is open. A half open circuit lets one trial through per Cooldown.
+func (b *CircuitBreaker) allow(url string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(url)
+	if b.Threshold <= 0 || c.failures < b.Threshold {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(c.openUntil) {
+		return ErrorCircuitOpen{URL: url, Until: c.openUntil}
+	}
+	c.openUntil = now.Add(b.Cooldown)
+	return nil
+}
+
+// record the outcome of a request to url.
+func (b *CircuitBreaker) record(url string, ok bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(url)
+	if ok {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if b.Threshold > 0 && c.failures >= b.Threshold {
+		c.openUntil = time.Now().Add(b.Cooldown)
+	}
+}
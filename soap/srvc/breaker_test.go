@@ -0,0 +1,73 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int64
+	server := serverFailFirst(3, &calls)
+	defer server.Close()
+	tr := &Transport{Client: &http.Client{}, Breaker: NewCircuitBreaker(2, 50*time.Millisecond)}
+	ctx := WithTransport(context.Background(), tr)
+	post := func() error {
+		resp, err := tr.Post(ctx, server.URL, []byte(`<eb:Action>SessionValidateRQ</eb:Action>`))
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	sampleSessionConf.ServiceURL = server.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	p.Transport = tr
+	if p.Stats().Circuit != CircuitClosed.String() {
+		t.Errorf("Stats.Circuit expect: %s, got: %s", CircuitClosed, p.Stats().Circuit)
+	}
+
+	_ = post()
+	_ = post()
+	if tr.Breaker.State(server.URL) != CircuitOpen {
+		t.Errorf("State after 2 failures expect: %s, got: %s", CircuitOpen, tr.Breaker.State(server.URL))
+	}
+	err := post()
+	var open ErrorCircuitOpen
+	if !errors.As(err, &open) || open.URL != server.URL {
+		t.Errorf("Post on open circuit expect: ErrorCircuitOpen, got: %T %v", err, err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Post on open circuit should not call Sabre, calls expect: %d, got: %d", 2, calls.Load())
+	}
+	if p.Stats().Circuit != CircuitOpen.String() {
+		t.Errorf("Stats.Circuit expect: %s, got: %s", CircuitOpen, p.Stats().Circuit)
+	}
+
+	//failed trial opens again
+	time.Sleep(60 * time.Millisecond)
+	if tr.Breaker.State(server.URL) != CircuitHalfOpen {
+		t.Errorf("State after cooldown expect: %s, got: %s", CircuitHalfOpen, tr.Breaker.State(server.URL))
+	}
+	_ = post()
+	if err := post(); !errors.As(err, &open) {
+		t.Errorf("Post after failed trial expect: ErrorCircuitOpen, got: %v", err)
+	}
+
+	//successful trial closes
+	time.Sleep(60 * time.Millisecond)
+	if err := post(); err != nil {
+		t.Error("trial Post should not return error", err)
+	}
+	if tr.Breaker.State(server.URL) != CircuitClosed || calls.Load() != 4 {
+		t.Errorf("State after successful trial expect: %s 4 calls, got: %s %d calls", CircuitClosed, tr.Breaker.State(server.URL), calls.Load())
+	}
+
+	//other urls are not affected
+	if tr.Breaker.State(serverCreateRQ.URL) != CircuitClosed {
+		t.Errorf("State of other url expect: %s, got: %s", CircuitClosed, tr.Breaker.State(serverCreateRQ.URL))
+	}
+}
@@ -0,0 +1,192 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+var (
+	sampleCreateRS = `<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="2.0.0" soap-env:mustUnderstand="1"><eb:CPAId>7TZA</eb:CPAId><eb:Action>SessionCreateRS</eb:Action></eb:MessageHeader><wsse:Security xmlns:wsse="http://schemas.xmlsoap.org/ws/2002/12/secext"><wsse:BinarySecurityToken valueType="String" EncodingType="wsse:Base64Binary">Shared/IDL:IceSess\/SessMgr:1\.0.IDL/Common/!ICESMS\/RESE!ICESMSLB\/RES.LB!-3177016070087638144!%d!0</wsse:BinarySecurityToken></wsse:Security></soap-env:Header><soap-env:Body><SessionCreateRS xmlns="http://www.opentravel.org/OTA/2002/11" version="1" status="Approved"/></soap-env:Body></soap-env:Envelope>`
+	sampleCloseRS  = `<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="2.0.0" soap-env:mustUnderstand="1"><eb:Action>SessionCloseRS</eb:Action></eb:MessageHeader></soap-env:Header><soap-env:Body><SessionCloseRS xmlns="http://www.opentravel.org/OTA/2002/11" version="1" status="Approved"/></soap-env:Body></soap-env:Envelope>`
+	sampleDeadErr  = sbrerr.NewErrorSoapFault("Invalid or Expired binary security token: Shared/IDL:IceSess")
+)
+
+// serverSabre answers session create with a new token each time, and session close.
+func serverSabre() *httptest.Server {
+	var tokens atomic.Int64
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				body := new(bytes.Buffer)
+				_, _ = body.ReadFrom(rq.Body)
+				if strings.Contains(body.String(), "<eb:Action>SessionCloseRQ</eb:Action>") {
+					_, _ = rs.Write([]byte(sampleCloseRS))
+					return
+				}
+				_, _ = fmt.Fprintf(rs, sampleCreateRS, tokens.Add(1))
+			},
+		),
+	)
+}
+
+func samplePool(t *testing.T, size int) *srvc.SessionPool {
+	sabre := serverSabre()
+	t.Cleanup(sabre.Close)
+	conf := &srvc.SessionConf{ServiceURL: sabre.URL, From: "z.com", PCC: "7TZA", Convid: "fds8789h|dev@z.com", Username: "7971", Password: "pass"}
+	p := srvc.NewPool(srvc.ExpireScheme{Min: 5, Max: 10}, conf, time.Minute, size)
+	if err := p.Populate(); err != nil {
+		t.Fatal("Populate should not return error", err)
+	}
+	return p
+}
+
+// serveUnix broker for pool on a unix socket, returns a client for it.
+func serveUnix(t *testing.T, pool *srvc.SessionPool) *Client {
+	//unix socket paths are short, t.TempDir may be too long
+	dir, err := os.MkdirTemp("", "sbrb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	sock := filepath.Join(dir, "b.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(pool)
+	server := &http.Server{Handler: s}
+	go func() { _ = server.Serve(l) }()
+	t.Cleanup(func() { _ = server.Close() })
+	c := NewClient("unix:" + sock)
+	c.Holder = "worker-1"
+	return c
+}
+
+func TestBrokerPickPutUnix(t *testing.T) {
+	pool := samplePool(t, 2)
+	defer pool.Close()
+	c := serveUnix(t, pool)
+
+	one := c.Pick()
+	two, err := c.PickContext(context.Background())
+	if err != nil {
+		t.Fatal("PickContext should not return error", err)
+	}
+	if one.ID == "" || one.BinSecTokCached == "" || one.ID == two.ID {
+		t.Errorf("Pick expect two distinct sessions with tokens, got: %+v %+v", one, two)
+	}
+	st, err := c.Stats(context.Background())
+	if err != nil || st.Leased != 2 || st.Configured != 2 {
+		t.Errorf("Stats Leased expect: %d, got: %d %v", 2, st.Leased, err)
+	}
+	if leases := pool.Leases(); leases[0].Holder != "worker-1" {
+		t.Errorf("Lease holder expect: %s, got: %s", "worker-1", leases[0].Holder)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err = c.PickContext(ctx)
+	var timeout srvc.ErrorPickTimeout
+	if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("PickContext on exhausted broker expect: ErrorPickTimeout, got: %T %v", err, err)
+	}
+
+	c.Put(one)
+	c.Put(two)
+	c.Put(two) //not leased anymore, logged
+	if pool.Stats().Leased != 0 || len(pool.Sessions) != 2 {
+		t.Errorf("Put Leased and queued expect: 0 2, got: %d %d", pool.Stats().Leased, len(pool.Sessions))
+	}
+}
+
+func TestBrokerPutReclaimed(t *testing.T) {
+	pool := samplePool(t, 2)
+	defer pool.Close()
+	pool.MaxLease = time.Millisecond
+	c := serveUnix(t, pool)
+
+	leaked := c.Pick()
+	time.Sleep(2 * pool.MaxLease)
+	if reclaimed := pool.ReclaimOverdue(); len(reclaimed) != 1 {
+		t.Fatalf("ReclaimOverdue expect: 1, got: %v", reclaimed)
+	}
+	other := c.Pick()
+	if err := c.post(context.Background(), "/put", putRequest{ID: leaked.ID}, nil); err != nil {
+		t.Errorf("put reclaimed session expect: nil, got: %v", err)
+	}
+	if pool.Stats().Leased != 1 || len(pool.Sessions) != 1 {
+		t.Errorf("reclaimed session closed, not queued, expect: 1 leased 1 queued, got: %d %d", pool.Stats().Leased, len(pool.Sessions))
+	}
+	c.Put(other)
+	if len(pool.Sessions) != 2 || pool.PoolSizeCounter() != 2 {
+		t.Errorf("Sessions and PoolSizeCounter expect: 2 2, got: %d %d", len(pool.Sessions), pool.PoolSizeCounter())
+	}
+}
+
+func TestBrokerDoReplacesDead(t *testing.T) {
+	pool := samplePool(t, 1)
+	defer pool.Close()
+	s := NewServer(pool)
+	server := httptest.NewServer(s)
+	defer server.Close()
+	c := NewClient(strings.TrimPrefix(server.URL, "http://"))
+
+	seen := []srvc.Session{}
+	err := c.Do(context.Background(), func(sess srvc.Session) error {
+		seen = append(seen, sess)
+		if len(seen) == 1 {
+			return sampleDeadErr
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error("Do should not return error", err)
+	}
+	if len(seen) != 2 || seen[0].ID == seen[1].ID || seen[0].BinSecTokCached == seen[1].BinSecTokCached {
+		t.Fatalf("Do expect retry on a replaced session, got: %+v", seen)
+	}
+	if pool.Stats().Leased != 0 || len(pool.Sessions) != 1 {
+		t.Errorf("Do Leased and queued expect: 0 1, got: %d %d", pool.Stats().Leased, len(pool.Sessions))
+	}
+	if sess := <-pool.Sessions; sess.ID != seen[1].ID {
+		t.Errorf("pool should hold replacement expect: %s, got: %s", seen[1].ID, sess.ID)
+	} else {
+		pool.Put(sess)
+	}
+
+	boom := errors.New("boom")
+	if err := c.Do(context.Background(), func(srvc.Session) error { return boom }); err != boom {
+		t.Errorf("Do expect: %v, got: %v", boom, err)
+	}
+	if pool.Stats().Leased != 0 {
+		t.Errorf("Do failed Leased expect: %d, got: %d", 0, pool.Stats().Leased)
+	}
+}
+
+func TestBrokerPoolClosed(t *testing.T) {
+	pool := samplePool(t, 1)
+	c := serveUnix(t, pool)
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Error("Shutdown should not return error", err)
+	}
+	if _, err := c.PickContext(context.Background()); !errors.Is(err, srvc.ErrPoolClosed) {
+		t.Errorf("PickContext after Shutdown expect: %v, got: %v", srvc.ErrPoolClosed, err)
+	}
+	if sess := c.Pick(); sess.ID != "" {
+		t.Errorf("Pick after Shutdown expect empty session, got: %+v", sess)
+	}
+}
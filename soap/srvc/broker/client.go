@@ -0,0 +1,197 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+// Client leases sessions from a broker Server, implementing Pool.
+type Client struct {
+	URL    string       //base URL of the broker, http://sbrbroker for unix sockets
+	HTTP   *http.Client //dials the unix socket when created with NewClient("unix:...")
+	Holder string       //lease holder shown in broker SessionPool.Leases, defaults to host:pid
+	Logger srvc.Logger  //optional, nothing is logged when nil
+}
+
+// NewClient for a broker at addr: "unix:/path/to.sock", "host:port" or a base URL.
+func NewClient(addr string) *Client {
+	host, _ := os.Hostname()
+	c := &Client{
+		URL:    addr,
+		HTTP:   &http.Client{},
+		Holder: fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		path := strings.TrimPrefix(addr, "unix:")
+		c.URL = "http://sbrbroker"
+		c.HTTP.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+	case !strings.Contains(addr, "://"):
+		c.URL = "http://" + addr
+	}
+	return c
+}
+
+func (c *Client) logger() srvc.Logger {
+	if c.Logger == nil {
+		return srvc.DiscardLogger
+	}
+	return c.Logger
+}
+
+// post v as JSON to path, decoding a 200 response into out.
+func (c *Client) post(ctx context.Context, path string, v, out interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HolderHeader, c.Holder)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, out)
+}
+
+// decodeResponse into out, mapping broker errors back to srvc errors.
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if resp.StatusCode == http.StatusOK {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	e := errorResponse{}
+	_ = json.NewDecoder(resp.Body).Decode(&e)
+	switch resp.StatusCode {
+	case http.StatusServiceUnavailable:
+		return srvc.ErrPoolClosed
+	case http.StatusGatewayTimeout:
+		return srvc.ErrorPickTimeout{Err: context.DeadlineExceeded}
+	}
+	return fmt.Errorf("broker %s: %s %s", resp.Request.URL.Path, resp.Status, e.Error)
+}
+
+// PickContext leases a session from the broker, waiting until one is available or ctx is done; returns ErrorPickTimeout on deadline or cancel, ErrPoolClosed when the broker pool is shut down.
+func (c *Client) PickContext(ctx context.Context) (srvc.Session, error) {
+	started := time.Now()
+	path := "/pick"
+	if dl, ok := ctx.Deadline(); ok {
+		path += "?wait=" + url.QueryEscape(time.Until(dl).String())
+	}
+	l := lease{}
+	err := c.post(ctx, path, struct{}{}, &l)
+	var timeout srvc.ErrorPickTimeout
+	switch {
+	case ctx.Err() != nil:
+		return srvc.Session{}, srvc.ErrorPickTimeout{Waited: time.Since(started), Err: ctx.Err()}
+	case errors.As(err, &timeout):
+		timeout.Waited = time.Since(started)
+		return srvc.Session{}, timeout
+	case err != nil:
+		return srvc.Session{}, err
+	}
+	return l.session(), nil
+}
+
+// Pick leases a session from the broker, blocking until one is available. Returns an empty Session when the broker cannot be reached or is shut down, see PickContext for the error.
+func (c *Client) Pick() srvc.Session {
+	for {
+		sess, err := c.PickContext(context.Background())
+		var timeout srvc.ErrorPickTimeout
+		if errors.As(err, &timeout) {
+			//broker MaxWait, keep waiting
+			continue
+		}
+		if err != nil {
+			c.logger().Error("broker pick", srvc.LogKeyError, err)
+		}
+		return sess
+	}
+}
+
+// Put session back into the broker pool.
+func (c *Client) Put(sess srvc.Session) {
+	c.put(putRequest{ID: sess.ID})
+}
+
+// PutDirty puts session back into the broker pool with SessionPool.PutDirty.
+func (c *Client) PutDirty(sess srvc.Session) {
+	c.put(putRequest{ID: sess.ID, Dirty: true})
+}
+
+func (c *Client) put(req putRequest) {
+	//returning a session must not be cut short by the caller
+	if err := c.post(context.Background(), "/put", req, nil); err != nil {
+		c.logger().Error("broker put", srvc.LogKeySessionID, req.ID, srvc.LogKeyError, err)
+	}
+}
+
+// Do leases a session from the broker and runs fn with it, see SessionPool.Do: a session Sabre reports dead is replaced by the broker and fn retried once. The session is always returned, even if fn panics; a failed fn is put back with PutDirty when the broker pool has IgnoreOnFailure.
+func (c *Client) Do(ctx context.Context, fn func(srvc.Session) error) error {
+	sess, err := c.PickContext(ctx)
+	if err != nil {
+		return err
+	}
+	failed, dead := true, false //until fn returns nil, covers panics
+	defer func() {
+		c.put(putRequest{ID: sess.ID, Failed: failed, Dead: dead})
+	}()
+
+	err = fn(sess)
+	if !srvc.IsSessionDead(err) {
+		failed = err != nil
+		return err
+	}
+	l := lease{}
+	if rerr := c.post(context.Background(), "/replace", putRequest{ID: sess.ID}, &l); rerr != nil {
+		c.logger().Error("broker replace", srvc.LogKeySessionID, sess.ID, srvc.LogKeyError, rerr)
+		return err
+	}
+	sess = l.session()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	err = fn(sess)
+	failed = err != nil
+	dead = srvc.IsSessionDead(err)
+	return err
+}
+
+// Stats of the broker pool.
+func (c *Client) Stats(ctx context.Context) (srvc.PoolStatsJSON, error) {
+	st := srvc.PoolStatsJSON{}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+"/stats", nil)
+	if err != nil {
+		return st, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return st, err
+	}
+	defer resp.Body.Close()
+	err = decodeResponse(resp, &st)
+	return st, err
+}
@@ -0,0 +1,265 @@
+/*
+Package broker shares one srvc.SessionPool between processes. A broker (see cmd/sbrbroker) owns the pool and hands out leased sessions to clients over a unix socket or local HTTP, so several workers on the same PCC stay within the Sabre session allotment:
+
+	//broker
+	pool := srvc.NewPool(expire, conf, cycle, 10)
+	_ = pool.Populate()
+	go pool.Keepalive()
+	l, _ := net.Listen("unix", "/run/sbrbroker.sock")
+	_ = http.Serve(l, broker.NewServer(pool))
+
+	//worker
+	c := broker.NewClient("unix:/run/sbrbroker.sock")
+	err := c.Do(ctx, func(sess srvc.Session) error {...})
+
+Client implements the same Pick/Put/Do contract as srvc.SessionPool (see Pool). Sessions of a client that dies while holding them are reclaimed by the pool when SessionPool.MaxLease is set.
+*/
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ailgroup/sbrweb/soap/srvc"
+)
+
+// DefaultMaxWait bounds how long the broker holds a pick request open waiting for a session, when Server.MaxWait is not set.
+const DefaultMaxWait = 30 * time.Second
+
+// HolderHeader names the client holding a leased session, shown in SessionPool.Leases.
+const HolderHeader = "X-Sbrbroker-Holder"
+
+// Pool is the Pick/Put/Do contract of srvc.SessionPool; Client implements it so workers can switch between an in process pool and the broker.
+type Pool interface {
+	Pick() srvc.Session
+	PickContext(ctx context.Context) (srvc.Session, error)
+	Put(sess srvc.Session)
+	PutDirty(sess srvc.Session)
+	Do(ctx context.Context, fn func(srvc.Session) error) error
+}
+
+var (
+	_ Pool = (*srvc.SessionPool)(nil)
+	_ Pool = (*Client)(nil)
+)
+
+// lease is a session as handed to clients.
+type lease struct {
+	ID          string    `json:"id"`
+	Token       string    `json:"token"`
+	OK          bool      `json:"ok"`
+	TimeStarted time.Time `json:"started"`
+	ExpireTime  time.Time `json:"expire"`
+}
+
+func leaseFrom(sess srvc.Session) lease {
+	return lease{
+		ID:          sess.ID,
+		Token:       sess.BinSecTokCached,
+		OK:          sess.OK,
+		TimeStarted: sess.TimeStarted,
+		ExpireTime:  sess.ExpireTime,
+	}
+}
+
+func (l lease) session() srvc.Session {
+	return srvc.Session{
+		ID:              l.ID,
+		BinSecTokCached: l.Token,
+		OK:              l.OK,
+		TimeStarted:     l.TimeStarted,
+		ExpireTime:      l.ExpireTime,
+	}
+}
+
+// putRequest returns a leased session by ID.
+type putRequest struct {
+	ID     string `json:"id"`
+	Dirty  bool   `json:"dirty,omitempty"`  //PutDirty
+	Failed bool   `json:"failed,omitempty"` //Do failed, PutDirty when the pool has IgnoreOnFailure
+	Dead   bool   `json:"dead,omitempty"`   //Do retry found the session dead too, let keepalive heal it
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+/*
+Server is an http.Handler serving Pool to broker clients:
+
+	POST /pick     lease a session, ?wait=5s bounds the wait (never over MaxWait)
+	POST /put      return a leased session
+	POST /replace  replace a leased session Sabre reports dead, see SessionPool.Replace; ?wait as for /pick when its lease was reclaimed
+	GET  /stats    pool stats
+
+Sessions only leave the broker for the duration of a lease and are put back by ID, clients cannot hand the pool tokens of their own.
+*/
+type Server struct {
+	Pool    *srvc.SessionPool
+	MaxWait time.Duration //bound on a single pick, DefaultMaxWait when 0
+	Logger  srvc.Logger   //optional, Pool.Logger when nil, nothing is logged when both are nil
+
+	mu     sync.Mutex
+	leased map[string]srvc.Session
+	mux    *http.ServeMux
+}
+
+// NewServer for pool; populate the pool and start its keepalive before serving.
+func NewServer(pool *srvc.SessionPool) *Server {
+	s := &Server{
+		Pool:   pool,
+		leased: make(map[string]srvc.Session),
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/pick", s.pick)
+	s.mux.HandleFunc("/put", s.put)
+	s.mux.HandleFunc("/replace", s.replace)
+	s.mux.HandleFunc("/stats", s.stats)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) logger() srvc.Logger {
+	switch {
+	case s.Logger != nil:
+		return s.Logger
+	case s.Pool.Logger != nil:
+		return s.Pool.Logger
+	}
+	return srvc.DiscardLogger
+}
+
+// track sess as leased to a client until the client puts it back. Sessions the pool reclaimed (see SessionPool.MaxLease) stay tracked: returning them lets the pool close them on Sabre.
+func (s *Server) track(sess srvc.Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leased[sess.ID] = sess
+}
+
+// take leased session id, false if the broker did not lease it.
+func (s *Server) take(id string) (srvc.Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.leased[id]
+	delete(s.leased, id)
+	return sess, ok
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// pickContext for r, labeled with the client holder and bounded by ?wait and MaxWait.
+func (s *Server) pickContext(r *http.Request) (context.Context, context.CancelFunc) {
+	wait := s.MaxWait
+	if wait <= 0 {
+		wait = DefaultMaxWait
+	}
+	if d, err := time.ParseDuration(r.URL.Query().Get("wait")); err == nil && d < wait {
+		wait = d
+	}
+	holder := r.Header.Get(HolderHeader)
+	if holder == "" {
+		holder = "broker " + r.RemoteAddr
+	}
+	return context.WithTimeout(srvc.WithLeaseHolder(r.Context(), holder), wait)
+}
+
+// writeLease answers with sess picked for r, or the pick error.
+func (s *Server) writeLease(w http.ResponseWriter, r *http.Request, sess srvc.Session, err error) {
+	var timeout srvc.ErrorPickTimeout
+	switch {
+	case errors.Is(err, srvc.ErrPoolClosed):
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	case errors.As(err, &timeout):
+		writeError(w, http.StatusGatewayTimeout, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if r.Context().Err() != nil {
+		//client went away while waiting
+		s.logger().Info("broker client gone before lease was sent, putting session back", srvc.LogKeySessionID, sess.ID, "holder", r.Header.Get(HolderHeader))
+		s.Pool.Put(sess)
+		return
+	}
+	s.track(sess)
+	writeJSON(w, http.StatusOK, leaseFrom(sess))
+}
+
+func (s *Server) pick(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+	ctx, cancel := s.pickContext(r)
+	defer cancel()
+	sess, err := s.Pool.PickContext(ctx)
+	s.writeLease(w, r, sess, err)
+}
+
+// readPut decodes a putRequest and takes its session.
+func (s *Server) readPut(w http.ResponseWriter, r *http.Request) (putRequest, srvc.Session, bool) {
+	req := putRequest{}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return req, srvc.Session{}, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return req, srvc.Session{}, false
+	}
+	sess, ok := s.take(req.ID)
+	if !ok {
+		s.logger().Warn("broker put of session not leased from this broker", srvc.LogKeySessionID, req.ID, "path", r.URL.Path)
+		writeError(w, http.StatusNotFound, errors.New("session '"+req.ID+"' not leased from this broker"))
+		return req, srvc.Session{}, false
+	}
+	return req, sess, true
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request) {
+	req, sess, ok := s.readPut(w, r)
+	if !ok {
+		return
+	}
+	if req.Dead {
+		sess.OK = false
+	}
+	if req.Dirty || (req.Failed && s.Pool.IgnoreOnFailure) {
+		s.Pool.PutDirty(sess)
+	} else {
+		s.Pool.Put(sess)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) replace(w http.ResponseWriter, r *http.Request) {
+	_, dead, ok := s.readPut(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := s.pickContext(r)
+	defer cancel()
+	sess, err := s.Pool.Replace(ctx, dead)
+	s.writeLease(w, r, sess, err)
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Pool.Stats().JSON())
+}
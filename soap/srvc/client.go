@@ -0,0 +1,245 @@
+package srvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+// ServiceTypeSabreXML is the eb:Service type attribute used by most Sabre session based services.
+const ServiceTypeSabreXML = "sabreXML"
+
+// CreateSessionHeader builds the message header and binary security token header shared by every session based Sabre service; service and action identify the Sabre service being requested.
+func CreateSessionHeader(c *SessionConf, binsec string, service ServiceElem, action string) SessionHeader {
+	return SessionHeader{
+		MessageHeader: MessageHeader{
+			MustUnderstand: SabreMustUnderstand,
+			EbVersion:      SabreEBVersion,
+			From:           FromElem{PartyID: CreatePartyID(c.From, PartyIDTypeURN)},
+			To:             ToElem{PartyID: CreatePartyID(SabreToBase, PartyIDTypeURN)},
+			CPAID:          c.PCC,
+			ConversationID: c.Convid,
+			Service:        service,
+			Action:         action,
+			MessageData: MessageDataElem{
+				MessageID: GenerateMessageID(),
+				Timestamp: SabreTimeNowFmt(),
+			},
+		},
+		Security: Security{
+			XMLNSWsseBase:       BaseWsse,
+			XMLNSWsu:            BaseWsuNameSpace,
+			BinarySecurityToken: binsec,
+		},
+	}
+}
+
+// ResultChecker is implemented by response payloads that carry Sabre application results (warnings, errors, NotProcessed statuses); Operation.Call returns ResultErr when it is non nil.
+type ResultChecker interface {
+	ResultErr() error
+}
+
+// ApplicationResults of the STL (services.sabre.com) LLS payloads, e.g. IgnoreTransactionRS and ContextChangeRS.
+type ApplicationResults struct {
+	Status string `xml:"status,attr"`
+	Errors []struct {
+		Type     string   `xml:"type,attr"`
+		Messages []string `xml:"SystemSpecificResults>Message"`
+	} `xml:"Error"`
+}
+
+// ResultErr for ResultChecker, anything other than a Complete status without errors is a sbrerr.ErrorSabreResult.
+func (r ApplicationResults) ResultErr() error {
+	if r.Status == sbrerr.StatusComplete() && len(r.Errors) == 0 {
+		return nil
+	}
+	var msg string
+	for i, e := range r.Errors {
+		msg += fmt.Sprintf("Error%d:Type-%s:Msg|%v", i, e.Type, e.Messages)
+	}
+	return sbrerr.NewErrorSabreResult(msg, sbrerr.SabreEngineStatusCode(r.Status))
+}
+
+// Client holds what every session based Sabre operation needs: the service url, the session configuration, and an optional Transport (DefaultTransport when nil).
+type Client struct {
+	ServiceURL string
+	Conf       *SessionConf
+	Transport  *Transport
+}
+
+// NewClient returns a Client for conf.ServiceURL.
+func NewClient(conf *SessionConf, t *Transport) *Client {
+	return &Client{
+		ServiceURL: conf.ServiceURL,
+		Conf:       conf,
+		Transport:  t,
+	}
+}
+
+// context returns ctx carrying the client Transport when one is configured.
+func (c *Client) context(ctx context.Context) context.Context {
+	if c.Transport != nil {
+		return WithTransport(ctx, c.Transport)
+	}
+	return ctx
+}
+
+// RequestBody wraps a typed request payload in the soap body; RQ must define its own XMLName.
+type RequestBody[RQ any] struct {
+	XMLName xml.Name `xml:"soap-env:Body"`
+	Payload RQ
+}
+
+// Request is a typed soap envelope for any session based operation.
+type Request[RQ any] struct {
+	Envelope
+	Header SessionHeader
+	Body   RequestBody[RQ]
+}
+
+// Response is a typed soap envelope; the first unmatched element of the body is parsed into Payload, a soap fault into Fault.
+type Response[RS any] struct {
+	Envelope EnvelopeUnMarsh
+	Header   SessionHeaderUnmarsh
+	Body     struct {
+		Payload RS `xml:",any"`
+		Fault   SOAPFault
+	}
+}
+
+// Operation describes a Sabre SOAP service with typed request RQ and response RS payloads. Adding a new Sabre service is a matter of declaring the payload types and an Operation:
+//
+//	var queueCountOp = srvc.NewOperation[QueueCountRQ, QueueCountRS]("QueueCountLLSRQ", "QueueCountLLSRQ")
+//	resp, err := queueCountOp.Call(ctx, client, binsec, QueueCountRQ{...})
+type Operation[RQ any, RS any] struct {
+	Service    ServiceElem
+	Action     string
+	AppMessage string //sbrerr application message, defaults to 'Error Call::Action'
+}
+
+// NewOperation returns Operation for service and action with the sabreXML service type.
+func NewOperation[RQ any, RS any](service, action string) Operation[RQ, RS] {
+	return Operation[RQ, RS]{
+		Service:    ServiceElem{Value: service, Type: ServiceTypeSabreXML},
+		Action:     action,
+		AppMessage: "Error Call::" + action,
+	}
+}
+
+// Header is the session header for the operation service and action.
+func (op Operation[RQ, RS]) Header(c *SessionConf, binsec string) SessionHeader {
+	return CreateSessionHeader(c, binsec, op.Service, op.Action)
+}
+
+// Build the typed request envelope for payload.
+func (op Operation[RQ, RS]) Build(c *SessionConf, binsec string, payload RQ) Request[RQ] {
+	return Request[RQ]{
+		Envelope: CreateEnvelope(),
+		Header:   op.Header(c, binsec),
+		Body:     RequestBody[RQ]{Payload: payload},
+	}
+}
+
+// Call builds, marshals, posts, and unmarshals the operation using client c with session token binsec. Errors follow the Call* conventions: sbrerr.ErrorSabreService for network and body problems, sbrerr.ErrorSabreXML for parsing, sbrerr.ErrorSoapFault for faults, and ResultErr when RS implements ResultChecker.
+func (op Operation[RQ, RS]) Call(ctx context.Context, c *Client, binsec string, payload RQ) (Response[RS], error) {
+	return op.post(c.context(ctx), c.ServiceURL, op.Build(c.Conf, binsec, payload))
+}
+
+// post the typed envelope req to serviceURL through the Transport on ctx, see Call.
+func (op Operation[RQ, RS]) post(ctx context.Context, serviceURL string, req Request[RQ]) (Response[RS], error) {
+	return send[Response[RS]](ctx, serviceURL, op.AppMessage, req)
+}
+
+/*
+Send posts req, a complete request envelope, to serviceURL through the Transport on ctx and parses the reply into the response envelope RS. It is for services that declare their own envelope types rather than payloads, as htlsp and itin do:
+
+	var hotelAvailOp = srvc.NewOperation[HotelAvailRequest, HotelAvailResponse]("OTA_HotelAvailRQ", "OTA_HotelAvailLLSRQ")
+	resp, err := hotelAvailOp.Send(ctx, serviceURL, req)
+
+Errors are those of Call: RS reports its soap fault by implementing FaultChecker and its application results by implementing ResultChecker, and keeps network and parse errors when it embeds CallErrors.
+*/
+func (op Operation[RQ, RS]) Send(ctx context.Context, serviceURL string, req RQ) (RS, error) {
+	return send[RS](ctx, serviceURL, op.AppMessage, req)
+}
+
+// FaultChecker is implemented by response envelopes carrying a soap fault; Send returns Fault().Format() when the fault is not Ok.
+type FaultChecker interface {
+	Fault() SOAPFault
+}
+
+// Fault for FaultChecker
+func (r Response[RS]) Fault() SOAPFault {
+	return r.Body.Fault
+}
+
+// ResultErr for ResultChecker, the ResultErr of Payload when it implements ResultChecker.
+func (r Response[RS]) ResultErr() error {
+	if rc, ok := any(r.Body.Payload).(ResultChecker); ok {
+		return rc.ResultErr()
+	}
+	return nil
+}
+
+// CallErrors is embedded in response envelopes that keep the network and parse errors of their Call, see Send.
+type CallErrors struct {
+	ErrorSabreService sbrerr.ErrorSabreService
+	ErrorSabreXML     sbrerr.ErrorSabreXML
+}
+
+// setCallError keeps err on the envelope when it is a network or parse error.
+func (e *CallErrors) setCallError(err error) {
+	switch err := err.(type) {
+	case sbrerr.ErrorSabreService:
+		e.ErrorSabreService = err
+	case sbrerr.ErrorSabreXML:
+		e.ErrorSabreXML = err
+	}
+}
+
+// send is the one marshal, post, unmarshal and fault check sequence behind Call and Send; appMessage is the sbrerr application message of every error.
+func send[RS any](ctx context.Context, serviceURL, appMessage string, req any) (RS, error) {
+	var resp RS
+	err := exchange(ctx, serviceURL, appMessage, req, &resp)
+	if ce, ok := any(&resp).(interface{ setCallError(error) }); ok && err != nil {
+		ce.setCallError(err)
+	}
+	return resp, err
+}
+
+func exchange(ctx context.Context, serviceURL, appMessage string, req, resp any) error {
+	byteReq, err := xml.Marshal(req)
+	if err != nil {
+		return sbrerr.NewErrorSabreXML(err.Error(), appMessage, sbrerr.BadParse)
+	}
+
+	//post payload
+	httpResp, err := Post(ctx, serviceURL, byteReq)
+	if err != nil {
+		return sbrerr.NewErrorSabreService(err.Error(), appMessage, sbrerr.BadService)
+	}
+	// parse payload body into []byte buffer from net Response.ReadCloser
+	bodyBuffer := new(bytes.Buffer)
+	_, err = io.Copy(bodyBuffer, httpResp.Body)
+	httpResp.Body.Close()
+	if err != nil {
+		return sbrerr.NewErrorSabreService(err.Error(), appMessage, sbrerr.BadParse)
+	}
+
+	err = xml.Unmarshal(bodyBuffer.Bytes(), resp)
+	if err != nil {
+		return sbrerr.NewErrorSabreXML(err.Error(), appMessage, sbrerr.BadParse)
+	}
+	if fc, ok := resp.(FaultChecker); ok && !fc.Fault().Ok() {
+		return fc.Fault().Format()
+	}
+	if rc, ok := resp.(ResultChecker); ok {
+		if err := rc.ResultErr(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package srvc
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+type sampleOpRQ struct {
+	XMLName xml.Name `xml:"QueueCountRQ"`
+	Version string   `xml:"Version,attr"`
+}
+
+type sampleOpRS struct {
+	XMLName xml.Name `xml:"QueueCountRS"`
+	Status  string   `xml:"status,attr"`
+}
+
+// ResultErr for sampleOpRS so we can test the ResultChecker hook
+func (r sampleOpRS) ResultErr() error {
+	if r.Status != "Complete" {
+		return sbrerr.NewErrorSabreResult(r.Status, sbrerr.NotProcessed)
+	}
+	return nil
+}
+
+var (
+	sampleOp = NewOperation[sampleOpRQ, sampleOpRS]("QueueCountLLSRQ", "QueueCountLLSRQ")
+
+	sampleOpResponse = []byte(`<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="2.0.0" soap-env:mustUnderstand="1"><eb:CPAId>7TZA</eb:CPAId><eb:ConversationId>fds8789h|dev@z.com</eb:ConversationId><eb:Action>QueueCountLLSRS</eb:Action></eb:MessageHeader></soap-env:Header><soap-env:Body><QueueCountRS status="%s"/></soap-env:Body></soap-env:Envelope>`)
+)
+
+func serverOp(status string, got *string) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				b, _ := io.ReadAll(rq.Body)
+				if got != nil {
+					*got = string(b)
+				}
+				_, _ = rs.Write([]byte(strings.Replace(string(sampleOpResponse), "%s", status, 1)))
+			},
+		),
+	)
+}
+
+func TestCreateSessionHeaderMarshal(t *testing.T) {
+	h := CreateSessionHeader(sampleSessionConf, samplebinsectoken, ServiceElem{Value: "QueueCountLLSRQ", Type: ServiceTypeSabreXML}, "QueueCountLLSRQ")
+	b, err := xml.Marshal(h)
+	if err != nil {
+		t.Error("Error marshal session header", err)
+	}
+	for _, want := range []string{
+		`<eb:Service eb:type="sabreXML">QueueCountLLSRQ</eb:Service>`,
+		`<eb:Action>QueueCountLLSRQ</eb:Action>`,
+		`<eb:CPAId>7TZA</eb:CPAId>`,
+		`<wsse:BinarySecurityToken>` + samplebinsectoken + `</wsse:BinarySecurityToken>`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("Session header expect to contain: %s\n got: %s", want, b)
+		}
+	}
+}
+
+func TestOperationCallSuccess(t *testing.T) {
+	var got string
+	srv := serverOp("Complete", &got)
+	defer srv.Close()
+	sampleSessionConf.ServiceURL = srv.URL
+	c := NewClient(sampleSessionConf, nil)
+
+	resp, err := sampleOp.Call(context.Background(), c, samplebinsectoken, sampleOpRQ{Version: "2.2.1"})
+	if err != nil {
+		t.Error("Error making operation call", err)
+	}
+	if resp.Body.Payload.Status != "Complete" {
+		t.Errorf("Body.Payload.Status expect: %s, got: %s", "Complete", resp.Body.Payload.Status)
+	}
+	if resp.Header.MessageHeader.Action != "QueueCountLLSRS" {
+		t.Errorf("Header.MessageHeader.Action expect: %s, got: %s", "QueueCountLLSRS", resp.Header.MessageHeader.Action)
+	}
+	if !strings.Contains(got, `<soap-env:Body><QueueCountRQ Version="2.2.1"></QueueCountRQ></soap-env:Body>`) {
+		t.Errorf("Request body not as expected, got: %s", got)
+	}
+}
+
+func TestOperationCallResultErr(t *testing.T) {
+	srv := serverOp("NotProcessed", nil)
+	defer srv.Close()
+	sampleSessionConf.ServiceURL = srv.URL
+	c := NewClient(sampleSessionConf, nil)
+
+	_, err := sampleOp.Call(context.Background(), c, samplebinsectoken, sampleOpRQ{})
+	var resErr sbrerr.ErrorSabreResult
+	if !errors.As(err, &resErr) {
+		t.Fatalf("Expect sbrerr.ErrorSabreResult, got: %T %v", err, err)
+	}
+	if resErr.Code != sbrerr.NotProcessed {
+		t.Errorf("ErrorSabreResult.Code expect: %d, got: %d", sbrerr.NotProcessed, resErr.Code)
+	}
+}
+
+func TestOperationCallFault(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCloseRSInvalid.URL
+	c := NewClient(sampleSessionConf, nil)
+	_, err := sampleOp.Call(context.Background(), c, samplebinsectoken, sampleOpRQ{})
+	var fault sbrerr.ErrorSoapFault
+	if !errors.As(err, &fault) {
+		t.Fatalf("Expect sbrerr.ErrorSoapFault, got: %T %v", err, err)
+	}
+	if fault.FaultCode != sampleSessionInvalidTokenFaultCode {
+		t.Errorf("ErrorSoapFault.FaultCode expect: %s, got: %s", sampleSessionInvalidTokenFaultCode, fault.FaultCode)
+	}
+}
+
+func TestOperationCallErrors(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverDown.URL
+	_, err := sampleOp.Call(context.Background(), NewClient(sampleSessionConf, nil), samplebinsectoken, sampleOpRQ{})
+	var svcErr sbrerr.ErrorSabreService
+	if !errors.As(err, &svcErr) || svcErr.Code != sbrerr.BadService {
+		t.Errorf("Expect sbrerr.ErrorSabreService BadService, got: %T %v", err, err)
+	}
+	if svcErr.AppMessage != "Error Call::QueueCountLLSRQ" {
+		t.Errorf("AppMessage expect: %s, got: %s", "Error Call::QueueCountLLSRQ", svcErr.AppMessage)
+	}
+
+	sampleSessionConf.ServiceURL = serverBadBody.URL
+	_, err = sampleOp.Call(context.Background(), NewClient(sampleSessionConf, nil), samplebinsectoken, sampleOpRQ{})
+	var xmlErr sbrerr.ErrorSabreXML
+	if !errors.As(err, &xmlErr) || xmlErr.Code != sbrerr.BadParse {
+		t.Errorf("Expect sbrerr.ErrorSabreXML BadParse, got: %T %v", err, err)
+	}
+}
+
+// sampleEnvelopeRS is a full response envelope as declared by htlsp and itin
+type sampleEnvelopeRS struct {
+	Envelope EnvelopeUnMarsh
+	Header   SessionHeaderUnmarsh
+	Body     struct {
+		QueueCountRS sampleOpRS
+		Fault        SOAPFault
+	}
+	CallErrors
+}
+
+func (r sampleEnvelopeRS) Fault() SOAPFault { return r.Body.Fault }
+func (r sampleEnvelopeRS) ResultErr() error { return r.Body.QueueCountRS.ResultErr() }
+
+func TestOperationSend(t *testing.T) {
+	op := Operation[Request[sampleOpRQ], sampleEnvelopeRS]{Service: sampleOp.Service, Action: sampleOp.Action, AppMessage: sampleOp.AppMessage}
+	req := sampleOp.Build(sampleSessionConf, samplebinsectoken, sampleOpRQ{Version: "2.2.1"})
+
+	srv := serverOp("Complete", nil)
+	defer srv.Close()
+	resp, err := op.Send(context.Background(), srv.URL, req)
+	if err != nil || resp.Body.QueueCountRS.Status != "Complete" {
+		t.Errorf("Send expect: Complete, got: %v %+v", err, resp.Body)
+	}
+
+	srvNP := serverOp("NotProcessed", nil)
+	defer srvNP.Close()
+	var resErr sbrerr.ErrorSabreResult
+	if _, err = op.Send(context.Background(), srvNP.URL, req); !errors.As(err, &resErr) {
+		t.Errorf("Send NotProcessed expect: sbrerr.ErrorSabreResult, got: %T %v", err, err)
+	}
+
+	var fault sbrerr.ErrorSoapFault
+	if _, err = op.Send(context.Background(), serverCloseRSInvalid.URL, req); !errors.As(err, &fault) || fault.FaultCode != sampleSessionInvalidTokenFaultCode {
+		t.Errorf("Send fault expect: %s, got: %T %v", sampleSessionInvalidTokenFaultCode, err, err)
+	}
+
+	resp, err = op.Send(context.Background(), serverDown.URL, req)
+	if err == nil || resp.ErrorSabreService.Code != sbrerr.BadService || resp.ErrorSabreService.AppMessage != sampleOp.AppMessage {
+		t.Errorf("Send network error expect: ErrorSabreService kept on response, got: %v %+v", err, resp.ErrorSabreService)
+	}
+	resp, err = op.Send(context.Background(), serverBadBody.URL, req)
+	if err == nil || resp.ErrorSabreXML.Code != sbrerr.BadParse {
+		t.Errorf("Send bad body expect: ErrorSabreXML kept on response, got: %v %+v", err, resp.ErrorSabreXML)
+	}
+}
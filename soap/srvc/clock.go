@@ -0,0 +1,106 @@
+package srvc
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock tells time for a SessionPool, see SessionPool.Clock. A fake clock drives session expiry, keepalive cycles, lease timeouts and pick waits in tests without sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer returned by Clock.AfterFunc; *time.Timer satisfies it.
+type Timer interface {
+	Stop() bool
+}
+
+// SystemClock is the wall clock, used when SessionPool.Clock is nil.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// lockedRand makes a rand.Source safe for the concurrent pool goroutines.
+type lockedRand struct {
+	mu  sync.Mutex
+	src rand.Source
+	rnd *rand.Rand
+}
+
+func (l *lockedRand) Int63() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.Int63()
+}
+
+func (l *lockedRand) Seed(seed int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.src.Seed(seed)
+}
+
+func (l *lockedRand) perm(n int) []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rnd.Perm(n)
+}
+
+func (l *lockedRand) intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rnd.Intn(n)
+}
+
+func newLockedRand(src rand.Source) *lockedRand {
+	return &lockedRand{src: src, rnd: rand.New(src)}
+}
+
+// clock helper to return a non nil Clock
+func (p *SessionPool) clock() Clock {
+	if p.Clock == nil {
+		return SystemClock
+	}
+	return p.Clock
+}
+
+// now on the pool clock
+func (p *SessionPool) now() time.Time {
+	return p.clock().Now()
+}
+
+// random source for the pool, Rand or seeded from the clock on first use.
+func (p *SessionPool) random() *lockedRand {
+	p.randOnce.Do(func() {
+		src := p.Rand
+		if src == nil {
+			src = rand.NewSource(p.now().UnixNano())
+		}
+		p.rnd = newLockedRand(src)
+	})
+	return p.rnd
+}
+
+// expireTime for a session validated at now, between Expire.Min and Expire.Max minutes.
+func (p *SessionPool) expireTime(now time.Time) time.Time {
+	return now.Add(time.Minute * time.Duration(randomInt(p.random(), p.Expire.Min, p.Expire.Max)))
+}
+
+// generateSessionID is GenerateSessionID on the pool clock and random source.
+func (p *SessionPool) generateSessionID() string {
+	return randString(p.random(), 3) + p.now().Format(".999")
+}
+
+// generateKeepAliveID is an ID an easy to find and parse in the logs,
+// attached to every new call on Keepalive; returns format 'kid:tXury|0220-17:12'
+func (p *SessionPool) generateKeepAliveID() string {
+	return "kid:" + randString(p.random(), 5) + "|" + p.now().Format("0102-15:04")
+}
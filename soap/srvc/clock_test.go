@@ -0,0 +1,325 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+var actionReg = regexp.MustCompile(`<eb:Action>([^<]*)</eb:Action>`)
+
+// fakeClock only moves on Advance, firing timers that come due.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock   *fakeClock
+	at      time.Time
+	f       func()
+	ch      chan time.Time
+	stopped bool
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	was := !t.stopped
+	t.stopped = true
+	return was
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.add(&fakeTimer{clock: c, at: c.Now().Add(d), ch: ch})
+	return ch
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, at: c.Now().Add(d), f: f}
+	c.add(t)
+	return t
+}
+
+func (c *fakeClock) add(t *fakeTimer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timers = append(c.timers, t)
+}
+
+// waiting timers not yet fired or stopped
+func (c *fakeClock) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.timers {
+		if !t.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+// Advance moves time by d and fires due timers in order.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	due, pending := []*fakeTimer{}, []*fakeTimer{}
+	for _, t := range c.timers {
+		switch {
+		case t.stopped:
+		case !t.at.After(c.now):
+			t.stopped = true
+			due = append(due, t)
+		default:
+			pending = append(pending, t)
+		}
+	}
+	c.timers = pending
+	now := c.now
+	c.mu.Unlock()
+	for _, t := range due {
+		if t.f != nil {
+			t.f()
+		} else {
+			t.ch <- now
+		}
+	}
+}
+
+// serverCountActions answers create and validate, counting requests by action.
+func serverCountActions() (*httptest.Server, func(string) int) {
+	var mu sync.Mutex
+	counts := map[string]int{}
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				body, _ := io.ReadAll(rq.Body)
+				action := ""
+				if m := actionReg.FindSubmatch(body); m != nil {
+					action = string(m[1])
+				}
+				mu.Lock()
+				counts[action]++
+				mu.Unlock()
+				if action == "SessionValidateRQ" {
+					_, _ = rs.Write(sampleSessionValidateRespSuccess)
+					return
+				}
+				_, _ = rs.Write(sampleSessionSuccessResponse)
+			},
+		),
+	)
+	return ts, func(action string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts[action]
+	}
+}
+
+func clockPool(url string, clk Clock, seed int64, size int) *SessionPool {
+	conf := *sampleSessionConf
+	conf.ServiceURL = url
+	p := NewPool(sampleExpireScheme, &conf, cycleEvery, size)
+	p.Clock = clk
+	p.Rand = rand.NewSource(seed)
+	return p
+}
+
+func TestSessionPoolClockExpiry(t *testing.T) {
+	ts, count := serverCountActions()
+	defer ts.Close()
+	clk := newFakeClock()
+	p := clockPool(ts.URL, clk, 1, 3)
+	p.refreshMod = 1000 //no refresh in this test
+	_ = p.Populate()
+	defer p.Close()
+
+	start := clk.Now()
+	for i := 0; i < 3; i++ {
+		sess := <-p.Sessions
+		if !sess.TimeStarted.Equal(start) {
+			t.Errorf("TimeStarted expect: %v, got: %v", start, sess.TimeStarted)
+		}
+		if sess.ExpireTime.Before(start.Add(3*time.Minute)) || sess.ExpireTime.After(start.Add(14*time.Minute)) {
+			t.Errorf("ExpireTime expect: within 3-14 minutes of %v, got: %v", start, sess.ExpireTime)
+		}
+		p.Sessions <- sess
+	}
+
+	p.RangeKeepalive("kid:test")
+	if n := count("SessionValidateRQ"); n != 0 {
+		t.Errorf("SessionValidateRQ before expiry expect: 0, got: %d", n)
+	}
+
+	clk.Advance(15 * time.Minute)
+	p.RangeKeepalive("kid:test")
+	if n := count("SessionValidateRQ"); n != 3 {
+		t.Errorf("SessionValidateRQ after expiry expect: 3, got: %d", n)
+	}
+	now := clk.Now()
+	for i := 0; i < 3; i++ {
+		sess := <-p.Sessions
+		if !sess.TimeValidated.Equal(now) || !sess.ExpireTime.After(now.Add(2*time.Minute)) {
+			t.Errorf("validated session expect: TimeValidated %v and new ExpireTime, got: %v %v", now, sess.TimeValidated, sess.ExpireTime)
+		}
+		p.Sessions <- sess
+	}
+}
+
+func TestSessionPoolRandDeterministic(t *testing.T) {
+	run := func() ([]Session, int) {
+		ts, count := serverCountActions()
+		defer ts.Close()
+		clk := newFakeClock()
+		p := clockPool(ts.URL, clk, 42, 6)
+		p.refreshMod = 1 //every session is a refresh candidate
+		_ = p.Populate()
+		p.RangeKeepalive("kid:test")
+		sessions := []Session{}
+		for len(p.Sessions) > 0 {
+			sessions = append(sessions, <-p.Sessions)
+		}
+		return sessions, count("SessionCreateRQ")
+	}
+	first, firstCreates := run()
+	second, secondCreates := run()
+	if firstCreates != secondCreates || firstCreates == 6 {
+		t.Errorf("refreshed sessions expect: same number and some, got: %d and %d creates", firstCreates, secondCreates)
+	}
+	if len(first) != len(second) {
+		t.Fatalf("sessions expect: same size, got: %d and %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].ID != second[i].ID || !first[i].ExpireTime.Equal(second[i].ExpireTime) {
+			t.Errorf("session %d expect: %s %v, got: %s %v", i, first[i].ID, first[i].ExpireTime, second[i].ID, second[i].ExpireTime)
+		}
+	}
+}
+
+func TestSessionPoolLeaseClock(t *testing.T) {
+	clk := newFakeClock()
+	p := clockPool(serverCreateRQ.URL, clk, 1, 1)
+	_ = p.Populate()
+	lease, err := p.Lease(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatalf("Lease expect: nil, got: %v", err)
+	}
+	if !lease.ExpiresAt().Equal(clk.Now().Add(time.Minute)) {
+		t.Errorf("ExpiresAt expect: %v, got: %v", clk.Now().Add(time.Minute), lease.ExpiresAt())
+	}
+	clk.Advance(30 * time.Second)
+	if err = lease.Extend(time.Minute); err != nil {
+		t.Fatalf("Extend expect: nil, got: %v", err)
+	}
+	clk.Advance(45 * time.Second)
+	if _, ok := p.LookupLease(lease.ID); !ok {
+		t.Error("LookupLease after extend expect: held, got: released")
+	}
+	clk.Advance(30 * time.Second)
+	if _, ok := p.LookupLease(lease.ID); ok {
+		t.Error("LookupLease after ttl expect: released, got: held")
+	}
+	if len(p.Sessions) != 1 {
+		t.Errorf("Sessions after lease expired expect: 1, got: %d", len(p.Sessions))
+	}
+}
+
+func TestSessionPoolPickClock(t *testing.T) {
+	clk := newFakeClock()
+	p := clockPool(serverCreateRQ.URL, clk, 1, 1)
+	p.Scale = ScaleScheme{Max: 2, PickWait: time.Minute}
+	_ = p.Populate()
+	first := p.Pick()
+	base := clk.waiting()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.PickContext(ctx)
+		done <- err
+	}()
+	if !eventually(func() bool { return clk.waiting() == base+1 }) {
+		t.Fatalf("PickWait timer expect: on pool clock, got: %d waiting", clk.waiting()-base)
+	}
+	clk.Advance(30 * time.Second)
+	cancel()
+	var pickErr ErrorPickTimeout
+	if err := <-done; !errors.As(err, &pickErr) || pickErr.Waited != 30*time.Second {
+		t.Errorf("ErrorPickTimeout.Waited expect: %v, got: %v", 30*time.Second, err)
+	}
+	if p.PoolSizeCounter() != 1 {
+		t.Errorf("PoolSizeCounter before PickWait expect: %d, got: %d", 1, p.PoolSizeCounter())
+	}
+
+	go func() {
+		sess, err := p.PickContext(context.Background())
+		p.Put(sess)
+		done <- err
+	}()
+	if !eventually(func() bool { return clk.waiting() == base+1 }) {
+		t.Fatalf("PickWait timer expect: on pool clock, got: %d waiting", clk.waiting()-base)
+	}
+	clk.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Errorf("PickContext after PickWait expect: nil, got: %v", err)
+	}
+	if p.PoolSizeCounter() != 2 {
+		t.Errorf("PoolSizeCounter after PickWait expect: %d, got: %d", 2, p.PoolSizeCounter())
+	}
+	p.Put(first)
+}
+
+func TestSessionPoolKeepaliveClock(t *testing.T) {
+	clk := newFakeClock()
+	p := clockPool(serverCreateRQ.URL, clk, 1, 1)
+	_ = p.Populate()
+	go p.Keepalive()
+	for cycle := 1; cycle <= 2; cycle++ {
+		deadline := time.Now().Add(time.Second)
+		for clk.waiting() == 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		clk.Advance(cycleEvery)
+		for p.Stats().Cycles < cycle && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if n := p.Stats().Cycles; n != cycle {
+			t.Fatalf("Cycles expect: %d, got: %d", cycle, n)
+		}
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown expect: nil, got: %v", err)
+	}
+}
+
+func TestRandomIntRange(t *testing.T) {
+	r := newLockedRand(rand.NewSource(1))
+	for _, rng := range []ExpireScheme{{Min: 3, Max: 14}, {Min: 5, Max: 5}, {Min: 5, Max: 6}, {Min: 9, Max: 1}} {
+		for i := 0; i < 20; i++ {
+			n := randomInt(r, rng.Min, rng.Max)
+			if n < rng.Min || (rng.Max >= rng.Min && n > rng.Max) {
+				t.Errorf("randomInt(%d, %d) expect: within range, got: %d", rng.Min, rng.Max, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package srvc
+
+import (
+	"context"
+	"encoding/xml"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+/*
+ContextChangeLLSRQ changes the AAA of a live session to another pseudo city code, e.g., to book on behalf of a branch PCC; it is the equivalent of the host entry "AAA<pcc>". The session keeps emulating that PCC until changed again, so SessionPool.ContextChange records it and the pool changes the session back to Conf.PCC before it is reused.
+*/
+
+// ChangeAAA element of ContextChangeRQ
+type ChangeAAA struct {
+	PseudoCityCode string `xml:"PseudoCityCode,attr"`
+}
+
+// ContextChangeRQ root element
+type ContextChangeRQ struct {
+	XMLName   xml.Name `xml:"ContextChangeRQ"`
+	XMLNS     string   `xml:"xmlns,attr"`
+	XMLXS     string   `xml:"xmlns:xs,attr"`
+	XMLXSI    string   `xml:"xmlns:xsi,attr"`
+	Version   string   `xml:"Version,attr"`
+	ChangeAAA ChangeAAA
+}
+
+// ContextChangeRS root element
+type ContextChangeRS struct {
+	XMLName    xml.Name           `xml:"ContextChangeRS"`
+	AppResults ApplicationResults `xml:"ApplicationResults"`
+	ChangeAAA  struct {
+		PseudoCityCode string `xml:"PseudoCityCode,attr"`
+	} `xml:"ChangeAAA"`
+	Text []string `xml:"Text"`
+}
+
+// ResultErr for ContextChangeRS, anything other than a Complete status without errors means the session AAA was not changed.
+func (r ContextChangeRS) ResultErr() error {
+	return r.AppResults.ResultErr()
+}
+
+// ContextChangeRequest soap envelope for ContextChangeRQ
+type ContextChangeRequest = Request[ContextChangeRQ]
+
+// ContextChangeResponse soap envelope for ContextChangeRS
+type ContextChangeResponse = Response[ContextChangeRS]
+
+var contextChangeOp = Operation[ContextChangeRQ, ContextChangeRS]{
+	Service:    ServiceElem{Value: "ContextChangeLLSRQ", Type: ServiceTypeSabreXML},
+	Action:     "ContextChangeLLSRQ",
+	AppMessage: sbrerr.ErrCallContextChange,
+}
+
+// BuildContextChangeRequest changing the AAA of session token binsec to pcc.
+func BuildContextChangeRequest(c *SessionConf, binsec, pcc string) ContextChangeRequest {
+	return contextChangeOp.Build(c, binsec, ContextChangeRQ{
+		XMLNS:     BaseWebServicesNS,
+		XMLXS:     BaseXSDNameSpace,
+		XMLXSI:    BaseXSINamespace,
+		Version:   "2.0.3",
+		ChangeAAA: ChangeAAA{PseudoCityCode: pcc},
+	})
+}
+
+// CallContextChange to execute ContextChangeRequest.
+func CallContextChange(serviceURL string, req ContextChangeRequest) (ContextChangeResponse, error) {
+	return CallContextChangeContext(context.Background(), serviceURL, req)
+}
+
+// CallContextChangeContext is CallContextChange bound to ctx for cancellation and deadlines, posting through the Transport on ctx (see WithTransport).
+func CallContextChangeContext(ctx context.Context, serviceURL string, req ContextChangeRequest) (ContextChangeResponse, error) {
+	return contextChangeOp.post(ctx, serviceURL, req)
+}
+
+// ContextChange emulates pcc on sess, a session picked from the pool, and returns it with PCC set. The pool keeps track of the emulated PCC and changes the session back to Conf.PCC when it is put back; if that fails the session is replaced. Changing to Conf.PCC ends the emulation.
+func (p *SessionPool) ContextChange(ctx context.Context, sess Session, pcc string) (Session, error) {
+	if p.Transport != nil {
+		ctx = WithTransport(ctx, p.Transport)
+	}
+	_, err := CallContextChangeContext(WithSessionID(ctx, sess.ID), p.ServiceURL, BuildContextChangeRequest(p.Conf, sess.BinSecTokCached, pcc))
+	if err != nil {
+		return sess, err
+	}
+	sess.PCC = pcc
+	if pcc == p.Conf.PCC {
+		sess.PCC = ""
+	}
+	p.mu.Lock()
+	if l, ok := p.leases[sess.ID]; ok {
+		l.PCC = sess.PCC
+		p.leases[sess.ID] = l
+	}
+	p.mu.Unlock()
+	p.logger().Info("session context changed", LogKeySessionID, sess.ID, "pcc", pcc)
+	return sess, nil
+}
+
+// emulatedPCC of sess, from the lease when the caller returns a copy from before ContextChange.
+func (p *SessionPool) emulatedPCC(sess Session) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if l, ok := p.leases[sess.ID]; ok && l.PCC != "" {
+		return l.PCC
+	}
+	return sess.PCC
+}
+
+// resetContext changes the AAA of sess back to the pool Conf.PCC.
+func (p *SessionPool) resetContext(sess Session) error {
+	_, err := CallContextChangeContext(p.sessionContext(sess), p.ServiceURL, BuildContextChangeRequest(p.Conf, sess.BinSecTokCached, p.Conf.PCC))
+	return err
+}
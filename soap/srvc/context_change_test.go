@@ -0,0 +1,161 @@
+package srvc
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+var (
+	sampleContextChangeRS = []byte(`<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="1.0" soap-env:mustUnderstand="1"><eb:From><eb:PartyId eb:type="URI">webservices.sabre.com</eb:PartyId></eb:From><eb:To><eb:PartyId eb:type="URI">www.z.com</eb:PartyId></eb:To><eb:CPAId>7TZA</eb:CPAId><eb:ConversationId>fds8789h|dev@z.com</eb:ConversationId><eb:Service eb:type="sabreXML">ContextChangeLLSRQ</eb:Service><eb:Action>ContextChangeLLSRS</eb:Action><eb:MessageData><eb:MessageId>3509162519931600322</eb:MessageId><eb:Timestamp>2018-03-08T16:39:35</eb:Timestamp><eb:RefToMessageId>mid:20180308-16:39:34.93|ODGJl</eb:RefToMessageId></eb:MessageData></eb:MessageHeader></soap-env:Header><soap-env:Body><ContextChangeRS xmlns="http://webservices.sabre.com/sabreXML/2011/10" xmlns:stl="http://services.sabre.com/STL/v01" Version="2.0.3"><stl:ApplicationResults status="Complete"><stl:Success timeStamp="2018-03-08T10:39:35-06:00"/></stl:ApplicationResults><ChangeAAA PseudoCityCode="B4T0"/><Text>B4T0.7TZA*AWS.A</Text></ContextChangeRS></soap-env:Body></soap-env:Envelope>`)
+
+	sampleContextChangeRSNotProcessed = []byte(`<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="1.0" soap-env:mustUnderstand="1"><eb:Action>ContextChangeLLSRS</eb:Action></eb:MessageHeader></soap-env:Header><soap-env:Body><ContextChangeRS xmlns="http://webservices.sabre.com/sabreXML/2011/10" xmlns:stl="http://services.sabre.com/STL/v01" Version="2.0.3"><stl:ApplicationResults status="NotProcessed"><stl:Error type="BusinessLogic" timeStamp="2018-03-08T10:39:35-06:00"><stl:SystemSpecificResults><stl:Message>NOT AUTHORIZED</stl:Message></stl:SystemSpecificResults></stl:Error></stl:ApplicationResults></ContextChangeRS></soap-env:Body></soap-env:Envelope>`)
+
+	sampleChangeAAAReg = regexp.MustCompile(`<ChangeAAA PseudoCityCode="([^"]*)">`)
+)
+
+// serverContextChange creates and closes sessions and changes context to any PCC not in refuse, recording the PCCs asked for.
+func serverContextChange(refuse string, mu *sync.Mutex, changed *[]string) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				body, _ := io.ReadAll(rq.Body)
+				switch soapAction(body) {
+				case "SessionCreateRQ":
+					_, _ = rs.Write(sampleSessionSuccessResponse)
+				case "SessionCloseRQ":
+					_, _ = rs.Write(sampleSessionCloseRespSuccess)
+				case "ContextChangeLLSRQ":
+					pcc := string(sampleChangeAAAReg.FindSubmatch(body)[1])
+					mu.Lock()
+					*changed = append(*changed, pcc)
+					mu.Unlock()
+					if pcc == refuse {
+						_, _ = rs.Write(sampleContextChangeRSNotProcessed)
+						return
+					}
+					_, _ = rs.Write(sampleContextChangeRS)
+				}
+			},
+		),
+	)
+}
+
+func TestBuildContextChangeMarshal(t *testing.T) {
+	req := BuildContextChangeRequest(sampleSessionConf, samplebinsectoken, "B4T0")
+	b, err := xml.Marshal(req)
+	if err != nil {
+		t.Error("Error marshal context change", err)
+	}
+	for _, want := range []string{
+		`<eb:Service eb:type="sabreXML">ContextChangeLLSRQ</eb:Service>`,
+		`<eb:Action>ContextChangeLLSRQ</eb:Action>`,
+		`<soap-env:Body><ContextChangeRQ xmlns="http://webservices.sabre.com/sabreXML/2011/10" xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" Version="2.0.3"><ChangeAAA PseudoCityCode="B4T0"></ChangeAAA></ContextChangeRQ></soap-env:Body>`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("ContextChange request expect to contain: %s\n got: %s", want, b)
+		}
+	}
+}
+
+func TestCallContextChange(t *testing.T) {
+	var mu sync.Mutex
+	var changed []string
+	server := serverContextChange("XXXX", &mu, &changed)
+	defer server.Close()
+
+	resp, err := CallContextChange(server.URL, BuildContextChangeRequest(sampleSessionConf, samplebinsectoken, "B4T0"))
+	if err != nil {
+		t.Error("Error making request CallContextChange", err)
+	}
+	if resp.Body.Payload.ChangeAAA.PseudoCityCode != "B4T0" {
+		t.Errorf("ChangeAAA.PseudoCityCode expect: %s, got: %s", "B4T0", resp.Body.Payload.ChangeAAA.PseudoCityCode)
+	}
+
+	_, err = CallContextChange(server.URL, BuildContextChangeRequest(sampleSessionConf, samplebinsectoken, "XXXX"))
+	var resErr sbrerr.ErrorSabreResult
+	if !errors.As(err, &resErr) {
+		t.Fatalf("Expect sbrerr.ErrorSabreResult, got: %T %v", err, err)
+	}
+	if resErr.Code != sbrerr.NotProcessed || !strings.Contains(resErr.AppMessage, "NOT AUTHORIZED") {
+		t.Errorf("ErrorSabreResult expect: %d NOT AUTHORIZED, got: %d %s", sbrerr.NotProcessed, resErr.Code, resErr.AppMessage)
+	}
+
+	_, err = CallContextChange(serverDown.URL, BuildContextChangeRequest(sampleSessionConf, samplebinsectoken, "B4T0"))
+	var svcErr sbrerr.ErrorSabreService
+	if !errors.As(err, &svcErr) || svcErr.AppMessage != sbrerr.ErrCallContextChange {
+		t.Errorf("Expect sbrerr.ErrorSabreService with AppMessage %s, got: %T %v", sbrerr.ErrCallContextChange, err, err)
+	}
+}
+
+func TestSessionPoolContextChange(t *testing.T) {
+	var mu sync.Mutex
+	var changed []string
+	server := serverContextChange("XXXX", &mu, &changed)
+	defer server.Close()
+	sampleSessionConf.ServiceURL = server.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+
+	sess := p.Pick()
+	emulating, err := p.ContextChange(context.Background(), sess, "B4T0")
+	if err != nil {
+		t.Fatal("ContextChange should not return error", err)
+	}
+	if emulating.PCC != "B4T0" || p.Leases()[0].PCC != "B4T0" {
+		t.Errorf("emulated PCC expect: %s, got session: %s lease: %s", "B4T0", emulating.PCC, p.Leases()[0].PCC)
+	}
+	if _, err := p.ContextChange(context.Background(), emulating, "XXXX"); err == nil {
+		t.Error("refused ContextChange should return error")
+	}
+	if p.Leases()[0].PCC != "B4T0" {
+		t.Errorf("emulated PCC after refused change expect: %s, got: %s", "B4T0", p.Leases()[0].PCC)
+	}
+
+	//stale copy from before ContextChange still reset from the lease
+	p.Put(sess)
+	back := p.Pick()
+	if back.ID != sess.ID || back.PCC != "" {
+		t.Errorf("reset session expect: %s at pool PCC, got: %s at '%s'", sess.ID, back.ID, back.PCC)
+	}
+	p.Put(back)
+	mu.Lock()
+	if strings.Join(changed, ",") != "B4T0,XXXX,"+samplepcc {
+		t.Errorf("ChangeAAA expect: %s, got: %v", "B4T0,XXXX,"+samplepcc, changed)
+	}
+	mu.Unlock()
+}
+
+func TestSessionPoolContextChangeResetFails(t *testing.T) {
+	var mu sync.Mutex
+	var changed []string
+	server := serverContextChange(samplepcc, &mu, &changed)
+	defer server.Close()
+	sampleSessionConf.ServiceURL = server.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+
+	sess := p.Pick()
+	sess, _ = p.ContextChange(context.Background(), sess, "B4T0")
+	p.Put(sess)
+	if len(p.Sessions) != 1 || p.PoolSizeCounter() != 1 {
+		t.Fatalf("Sessions after failed reset expect: %d, got: %d (counted %d)", 1, len(p.Sessions), p.PoolSizeCounter())
+	}
+	back := p.Pick()
+	if back.ID == sess.ID || back.PCC != "" {
+		t.Errorf("session that could not be reset should be replaced, got: %s at '%s'", back.ID, back.PCC)
+	}
+	p.Put(back)
+	if len(p.Leases()) != 0 {
+		t.Errorf("Leases after failed reset expect: %d, got: %d", 0, len(p.Leases()))
+	}
+}
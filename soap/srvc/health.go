@@ -0,0 +1,125 @@
+package srvc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// HealthOK and HealthFail are HealthReport.Status values.
+	HealthOK   = "ok"
+	HealthFail = "fail"
+	// healthRecentFaults caps HealthReport.RecentFaults.
+	healthRecentFaults = 10
+)
+
+// HealthReport is the JSON body of LivenessHandler and ReadinessHandler.
+type HealthReport struct {
+	Status           string        `json:"status"`            //HealthOK or HealthFail
+	Reasons          []string      `json:"reasons,omitempty"` //why the probe failed
+	KeepaliveRunning bool          `json:"keepalive_running"`
+	LastKeepalive    time.Time     `json:"last_keepalive"` //end of the last RangeKeepalive pass, or Keepalive start
+	BadSessions      int           `json:"bad_sessions"`
+	Stats            PoolStatsJSON `json:"stats"`
+	RecentFaults     []string      `json:"recent_faults"` //most recent soap fault errors, newest last
+}
+
+// setLastKeepalive records keepalive progress for the liveness probe.
+func (p *SessionPool) setLastKeepalive() {
+	now := p.now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastKeepalive = now
+}
+
+// keepaliveRunning true between Keepalive starting and returning.
+func (p *SessionPool) keepaliveRunning() bool {
+	p.mu.Lock()
+	done := p.keepaliveDone
+	p.mu.Unlock()
+	if done == nil {
+		return false
+	}
+	select {
+	case <-done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Health reports keepalive progress, pool stats and recent faults; Status is always HealthOK, the probes decide what fails.
+func (p *SessionPool) Health() HealthReport {
+	st := p.Stats()
+	p.mu.Lock()
+	last := p.lastKeepalive
+	faults := p.faultErrors
+	if len(faults) > healthRecentFaults {
+		faults = faults[len(faults)-healthRecentFaults:]
+	}
+	recent := make([]string, 0, len(faults))
+	for _, err := range faults {
+		recent = append(recent, err.Error())
+	}
+	p.mu.Unlock()
+	return HealthReport{
+		Status:           HealthOK,
+		KeepaliveRunning: p.keepaliveRunning(),
+		LastKeepalive:    last,
+		BadSessions:      st.Bad,
+		Stats:            st.JSON(),
+		RecentFaults:     recent,
+	}
+}
+
+/*
+LivenessHandler answers 200 while the Keepalive loop is running and has made a pass within stale, 503 otherwise; a stuck or stopped keepalive means sessions silently expire on Sabre and the process should be restarted. A stale of 0 allows 3 cycles (3*CycleEvery). The body is a HealthReport.
+
+	mux.Handle("/healthz", pool.LivenessHandler(0))
+	mux.Handle("/readyz", pool.ReadinessHandler(2))
+*/
+func (p *SessionPool) LivenessHandler(stale time.Duration) http.Handler {
+	if stale <= 0 {
+		stale = 3 * p.CycleEvery
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := p.Health()
+		if !h.KeepaliveRunning {
+			h.Reasons = append(h.Reasons, "keepalive not running")
+		} else if since := p.now().Sub(h.LastKeepalive); since > stale {
+			h.Reasons = append(h.Reasons, fmt.Sprintf("no keepalive pass for %v", since))
+		}
+		writeHealth(w, h)
+	})
+}
+
+// ReadinessHandler answers 200 when at least minOK sessions allocated to the pool are OK (leased or queued, not bad) and the circuit for ServiceURL is closed, 503 otherwise and while shutting down. The body is a HealthReport.
+func (p *SessionPool) ReadinessHandler(minOK int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := p.Health()
+		if p.stopping.Load() {
+			h.Reasons = append(h.Reasons, "pool shutting down")
+		}
+		if ok := h.Stats.Counted - h.Stats.Bad; ok < minOK {
+			h.Reasons = append(h.Reasons, fmt.Sprintf("%d OK sessions, need %d", ok, minOK))
+		}
+		if c := h.Stats.Circuit; c != "" && c != CircuitClosed.String() {
+			h.Reasons = append(h.Reasons, "circuit "+c)
+		}
+		writeHealth(w, h)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, h HealthReport) {
+	status := http.StatusOK
+	if len(h.Reasons) > 0 {
+		h.Status = HealthFail
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(h)
+}
@@ -0,0 +1,113 @@
+package srvc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, h http.Handler) (int, HealthReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type expect: application/json, got: %s", ct)
+	}
+	report := HealthReport{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("HealthReport json expect: nil, got: %v %s", err, rec.Body.String())
+	}
+	return rec.Code, report
+}
+
+func TestLivenessHandler(t *testing.T) {
+	clk := newFakeClock()
+	p := clockPool(serverCreateRQ.URL, clk, 1, 1)
+	_ = p.Populate()
+	live := p.LivenessHandler(time.Minute)
+
+	code, report := probe(t, live)
+	if code != http.StatusServiceUnavailable || report.Status != HealthFail || report.KeepaliveRunning {
+		t.Errorf("before Keepalive expect: 503 fail, got: %d %+v", code, report)
+	}
+
+	go p.Keepalive()
+	if !eventually(func() bool { return clk.waiting() > 0 }) {
+		t.Fatal("Keepalive expect: waiting on the pool clock, got: no timer")
+	}
+	code, report = probe(t, live)
+	if code != http.StatusOK || report.Status != HealthOK || !report.LastKeepalive.Equal(clk.Now()) {
+		t.Errorf("Keepalive running expect: 200 ok last keepalive %v, got: %d %+v", clk.Now(), code, report)
+	}
+
+	//cycle is 3 minutes, liveness allows 1
+	clk.Advance(2 * time.Minute)
+	code, report = probe(t, live)
+	if code != http.StatusServiceUnavailable || len(report.Reasons) != 1 || !strings.Contains(report.Reasons[0], "no keepalive pass") {
+		t.Errorf("stale keepalive expect: 503 no keepalive pass, got: %d %+v", code, report)
+	}
+	clk.Advance(time.Minute)
+	if !eventually(func() bool { return p.Stats().Cycles == 1 }) {
+		t.Fatalf("Cycles expect: %d, got: %d", 1, p.Stats().Cycles)
+	}
+	if code, report = probe(t, live); code != http.StatusOK || report.Stats.Cycles != 1 {
+		t.Errorf("after cycle expect: 200 with 1 cycle, got: %d %+v", code, report)
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown expect: nil, got: %v", err)
+	}
+	if code, _ = probe(t, live); code != http.StatusServiceUnavailable {
+		t.Errorf("after Shutdown expect: 503, got: %d", code)
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 2)
+	p.Transport = &Transport{Client: &http.Client{}, Breaker: NewCircuitBreaker(1, time.Minute)}
+	_ = p.Populate()
+	defer p.Close()
+
+	code, report := probe(t, p.ReadinessHandler(2))
+	if code != http.StatusOK || report.Stats.Counted != 2 || report.Stats.Circuit != "closed" {
+		t.Errorf("ready expect: 200 with 2 sessions and closed circuit, got: %d %+v", code, report)
+	}
+	//leased sessions still count
+	sess := p.Pick()
+	if code, _ = probe(t, p.ReadinessHandler(2)); code != http.StatusOK {
+		t.Errorf("ready with leased session expect: 200, got: %d", code)
+	}
+	p.Put(sess)
+	if code, report = probe(t, p.ReadinessHandler(3)); code != http.StatusServiceUnavailable || report.Reasons[0] != "2 OK sessions, need 3" {
+		t.Errorf("too few sessions expect: 503, got: %d %+v", code, report)
+	}
+
+	p.Transport.Breaker.record(p.ServiceURL, false)
+	code, report = probe(t, p.ReadinessHandler(2))
+	if code != http.StatusServiceUnavailable || len(report.Reasons) != 1 || report.Reasons[0] != "circuit open" {
+		t.Errorf("open circuit expect: 503 circuit open, got: %d %+v", code, report)
+	}
+}
+
+func TestReadinessHandlerFaults(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRSUnauth.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 2)
+	_ = p.Populate()
+	defer p.Close()
+
+	code, report := probe(t, p.ReadinessHandler(1))
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("bad sessions expect: 503, got: %d", code)
+	}
+	if report.BadSessions != 2 || report.Stats.Bad != 2 {
+		t.Errorf("BadSessions expect: 2, got: %d", report.BadSessions)
+	}
+	if len(report.RecentFaults) != 2 || report.Stats.LastError == "" {
+		t.Errorf("RecentFaults expect: 2 with last error, got: %+v", report)
+	}
+}
@@ -0,0 +1,81 @@
+package srvc
+
+import (
+	"context"
+	"encoding/xml"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+/*
+IgnoreTransactionLLSRQ is used to ignore the current transaction in the AAA workspace: any PNR being built or changed since the last EndTransaction is discarded. It is the equivalent of the host entry "I". Running it before a session goes back into the pool means the next caller never inherits a half built PNR (e.g., from a failed CallPNRDetails or CallHotelRes).
+*/
+
+// IgnoreTransactionRQ root element
+type IgnoreTransactionRQ struct {
+	XMLName xml.Name `xml:"IgnoreTransactionRQ"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	XMLXS   string   `xml:"xmlns:xs,attr"`
+	XMLXSI  string   `xml:"xmlns:xsi,attr"`
+	Version string   `xml:"Version,attr"`
+}
+
+// IgnoreTransactionRS root element
+type IgnoreTransactionRS struct {
+	XMLName    xml.Name           `xml:"IgnoreTransactionRS"`
+	AppResults ApplicationResults `xml:"ApplicationResults"`
+}
+
+// ResultErr for IgnoreTransactionRS, anything other than a Complete status without errors means the workspace was not ignored.
+func (r IgnoreTransactionRS) ResultErr() error {
+	return r.AppResults.ResultErr()
+}
+
+// IgnoreTransactionRequest soap envelope for IgnoreTransactionRQ
+type IgnoreTransactionRequest = Request[IgnoreTransactionRQ]
+
+// IgnoreTransactionResponse soap envelope for IgnoreTransactionRS
+type IgnoreTransactionResponse = Response[IgnoreTransactionRS]
+
+var ignoreTransactionOp = Operation[IgnoreTransactionRQ, IgnoreTransactionRS]{
+	Service:    ServiceElem{Value: "IgnoreTransactionLLSRQ", Type: ServiceTypeSabreXML},
+	Action:     "IgnoreTransactionLLSRQ",
+	AppMessage: sbrerr.ErrCallIgnoreTransaction,
+}
+
+// BuildIgnoreTransactionRequest for session token binsec
+func BuildIgnoreTransactionRequest(c *SessionConf, binsec string) IgnoreTransactionRequest {
+	return ignoreTransactionOp.Build(c, binsec, IgnoreTransactionRQ{
+		XMLNS:   BaseWebServicesNS,
+		XMLXS:   BaseXSDNameSpace,
+		XMLXSI:  BaseXSINamespace,
+		Version: "2.0.0",
+	})
+}
+
+// CallIgnoreTransaction to execute IgnoreTransactionRequest, discarding the current transaction in the session AAA workspace.
+func CallIgnoreTransaction(serviceURL string, req IgnoreTransactionRequest) (IgnoreTransactionResponse, error) {
+	return CallIgnoreTransactionContext(context.Background(), serviceURL, req)
+}
+
+// CallIgnoreTransactionContext is CallIgnoreTransaction bound to ctx for cancellation and deadlines, posting through the Transport on ctx (see WithTransport).
+func CallIgnoreTransactionContext(ctx context.Context, serviceURL string, req IgnoreTransactionRequest) (IgnoreTransactionResponse, error) {
+	return ignoreTransactionOp.post(ctx, serviceURL, req)
+}
+
+// ignoreTransaction cleans the AAA workspace of sess.
+func (p *SessionPool) ignoreTransaction(sess Session) error {
+	_, err := CallIgnoreTransactionContext(p.sessionContext(sess), p.ServiceURL, BuildIgnoreTransactionRequest(p.Conf, sess.BinSecTokCached))
+	return err
+}
+
+// PutDirty puts sess back into the pool after a failed or partial workflow. IgnoreTransactionLLSRQ runs first so the next caller gets a clean AAA workspace; if the workspace cannot be cleaned the session is closed and replaced with a new one.
+func (p *SessionPool) PutDirty(sess Session) {
+	err := p.ignoreTransaction(sess)
+	if err == nil {
+		p.put(sess, "PutDirty-"+sess.ID)
+		return
+	}
+	p.logger().Warn("ignore transaction failed, replacing session", LogKeySessionID, sess.ID, LogKeyError, err)
+	p.replaceReturned(sess, "PutDirtyReplaced-"+sess.ID)
+}
@@ -0,0 +1,196 @@
+package srvc
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+var (
+	sampleIgnoreTransactionRS = []byte(`<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="1.0" soap-env:mustUnderstand="1"><eb:From><eb:PartyId eb:type="URI">webservices.sabre.com</eb:PartyId></eb:From><eb:To><eb:PartyId eb:type="URI">www.z.com</eb:PartyId></eb:To><eb:CPAId>7TZA</eb:CPAId><eb:ConversationId>fds8789h|dev@z.com</eb:ConversationId><eb:Service eb:type="sabreXML">IgnoreTransactionLLSRQ</eb:Service><eb:Action>IgnoreTransactionLLSRS</eb:Action><eb:MessageData><eb:MessageId>3509162519931600321</eb:MessageId><eb:Timestamp>2018-03-08T16:39:35</eb:Timestamp><eb:RefToMessageId>mid:20180308-16:39:34.93|ODGJl</eb:RefToMessageId></eb:MessageData></eb:MessageHeader><wsse:Security xmlns:wsse="http://schemas.xmlsoap.org/ws/2002/12/secext"><wsse:BinarySecurityToken valueType="String" EncodingType="wsse:Base64Binary">Shared/IDL:IceSess\/SessMgr:1\.0.IDL/Common/!ICESMS\/RESE!ICESMSLB\/RES.LB!-3177016070087638144!1159666!0</wsse:BinarySecurityToken></wsse:Security></soap-env:Header><soap-env:Body><IgnoreTransactionRS xmlns="http://webservices.sabre.com/sabreXML/2011/10" xmlns:stl="http://services.sabre.com/STL/v01" Version="2.0.0"><stl:ApplicationResults status="Complete"><stl:Success timeStamp="2018-03-08T10:39:35-06:00"/></stl:ApplicationResults></IgnoreTransactionRS></soap-env:Body></soap-env:Envelope>`)
+
+	sampleIgnoreTransactionRSNotProcessed = []byte(`<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="1.0" soap-env:mustUnderstand="1"><eb:Action>IgnoreTransactionLLSRS</eb:Action></eb:MessageHeader></soap-env:Header><soap-env:Body><IgnoreTransactionRS xmlns="http://webservices.sabre.com/sabreXML/2011/10" xmlns:stl="http://services.sabre.com/STL/v01" Version="2.0.0"><stl:ApplicationResults status="NotProcessed"><stl:Error type="BusinessLogic" timeStamp="2018-03-08T10:39:35-06:00"><stl:SystemSpecificResults><stl:Message>NO TRANS AAA</stl:Message></stl:SystemSpecificResults></stl:Error></stl:ApplicationResults></IgnoreTransactionRS></soap-env:Body></soap-env:Envelope>`)
+
+	serverIgnoreRQ = httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				_, _ = rs.Write(sampleIgnoreTransactionRS)
+			},
+		),
+	)
+	serverIgnoreRSNotProcessed = httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				_, _ = rs.Write(sampleIgnoreTransactionRSNotProcessed)
+			},
+		),
+	)
+)
+
+func TestBuildIgnoreTransactionMarshal(t *testing.T) {
+	req := BuildIgnoreTransactionRequest(sampleSessionConf, samplebinsectoken)
+	b, err := xml.Marshal(req)
+	if err != nil {
+		t.Error("Error marshal ignore transaction", err)
+	}
+	for _, want := range []string{
+		`<eb:Service eb:type="sabreXML">IgnoreTransactionLLSRQ</eb:Service>`,
+		`<eb:Action>IgnoreTransactionLLSRQ</eb:Action>`,
+		`<soap-env:Body><IgnoreTransactionRQ xmlns="http://webservices.sabre.com/sabreXML/2011/10" xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" Version="2.0.0"></IgnoreTransactionRQ></soap-env:Body>`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("IgnoreTransaction request expect to contain: %s\n got: %s", want, b)
+		}
+	}
+}
+
+func TestCallIgnoreTransaction(t *testing.T) {
+	resp, err := CallIgnoreTransaction(serverIgnoreRQ.URL, BuildIgnoreTransactionRequest(sampleSessionConf, samplebinsectoken))
+	if err != nil {
+		t.Error("Error making request CallIgnoreTransaction", err)
+	}
+	if resp.Body.Payload.AppResults.Status != sbrerr.StatusComplete() {
+		t.Errorf("AppResults.Status expect: %s, got: %s", sbrerr.StatusComplete(), resp.Body.Payload.AppResults.Status)
+	}
+
+	_, err = CallIgnoreTransaction(serverIgnoreRSNotProcessed.URL, BuildIgnoreTransactionRequest(sampleSessionConf, samplebinsectoken))
+	var resErr sbrerr.ErrorSabreResult
+	if !errors.As(err, &resErr) {
+		t.Fatalf("Expect sbrerr.ErrorSabreResult, got: %T %v", err, err)
+	}
+	if resErr.Code != sbrerr.NotProcessed {
+		t.Errorf("ErrorSabreResult.Code expect: %d, got: %d", sbrerr.NotProcessed, resErr.Code)
+	}
+	if !strings.Contains(resErr.AppMessage, "NO TRANS AAA") {
+		t.Errorf("ErrorSabreResult.AppMessage should contain sabre message, got: %s", resErr.AppMessage)
+	}
+
+	_, err = CallIgnoreTransaction(serverDown.URL, BuildIgnoreTransactionRequest(sampleSessionConf, samplebinsectoken))
+	var svcErr sbrerr.ErrorSabreService
+	if !errors.As(err, &svcErr) || svcErr.AppMessage != sbrerr.ErrCallIgnoreTransaction {
+		t.Errorf("Expect sbrerr.ErrorSabreService with AppMessage %s, got: %T %v", sbrerr.ErrCallIgnoreTransaction, err, err)
+	}
+}
+
+func TestSessionPoolPutDirty(t *testing.T) {
+	var got string
+	serverIgnore := httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				b, _ := io.ReadAll(rq.Body)
+				got = string(b)
+				_, _ = rs.Write(sampleIgnoreTransactionRS)
+			},
+		),
+	)
+	defer serverIgnore.Close()
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+	p.ServiceURL = serverIgnore.URL
+
+	sess := p.Pick()
+	p.PutDirty(sess)
+	if !strings.Contains(got, "IgnoreTransactionRQ") || !strings.Contains(got, sess.BinSecTokCached) {
+		t.Errorf("PutDirty should ignore transaction for session token, got: %s", got)
+	}
+	back := p.Pick()
+	if back.ID != sess.ID {
+		t.Errorf("Clean session should go back in pool expect: %s, got: %s", sess.ID, back.ID)
+	}
+	p.Put(back)
+	if len(p.Leases()) != 0 {
+		t.Errorf("Leases after PutDirty expect: %d, got: %d", 0, len(p.Leases()))
+	}
+}
+
+func TestSessionPoolPutDirtyReplaces(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+	p.ServiceURL = serverIgnoreRSNotProcessed.URL
+
+	sess := p.Pick()
+	p.PutDirty(sess)
+	if len(p.Sessions) != 1 {
+		t.Fatalf("Sessions after PutDirty expect: %d, got: %d", 1, len(p.Sessions))
+	}
+	back := p.Pick()
+	if back.ID == sess.ID {
+		t.Error("Session that could not be cleaned should be replaced, got same ID-" + back.ID)
+	}
+	p.Put(back)
+	if len(p.Leases()) != 0 {
+		t.Errorf("Leases after PutDirty expect: %d, got: %d", 0, len(p.Leases()))
+	}
+}
+
+func TestSessionPoolDoIgnoreOnFailure(t *testing.T) {
+	calls := 0
+	serverIgnore := httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				calls++
+				_, _ = rs.Write(sampleIgnoreTransactionRS)
+			},
+		),
+	)
+	defer serverIgnore.Close()
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	p.IgnoreOnFailure = true
+	_ = p.Populate()
+	p.ServiceURL = serverIgnore.URL
+
+	_ = p.Do(context.Background(), func(sess Session) error { return nil })
+	if calls != 0 {
+		t.Errorf("IgnoreTransaction calls on success expect: %d, got: %d", 0, calls)
+	}
+	_ = p.Do(context.Background(), func(sess Session) error { return errors.New("pnr failed") })
+	if calls != 1 {
+		t.Errorf("IgnoreTransaction calls on failure expect: %d, got: %d", 1, calls)
+	}
+	if len(p.Sessions) != 1 {
+		t.Errorf("Sessions after Do expect: %d, got: %d", 1, len(p.Sessions))
+	}
+}
+
+func TestSessionPoolLeaseExpireIgnoreOnFailure(t *testing.T) {
+	ignored := make(chan struct{}, 1)
+	serverIgnore := httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				ignored <- struct{}{}
+				_, _ = rs.Write(sampleIgnoreTransactionRS)
+			},
+		),
+	)
+	defer serverIgnore.Close()
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	p.IgnoreOnFailure = true
+	_ = p.Populate()
+	p.ServiceURL = serverIgnore.URL
+
+	_, _ = p.Lease(context.Background(), 10*time.Millisecond)
+	select {
+	case <-ignored:
+	case <-time.After(time.Second):
+		t.Fatal("Expired lease should ignore transaction with IgnoreOnFailure")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sess, err := p.PickContext(ctx)
+	if err != nil {
+		t.Fatal("Expired lease session should be back in pool", err)
+	}
+	p.Put(sess)
+}
@@ -0,0 +1,119 @@
+package srvc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+)
+
+// Logger is the structured logger used by Transport and SessionPool. It follows log/slog: args are alternating key value pairs (see the LogKey* constants) or slog.Attr, so a *slog.Logger can be used as is. Nothing is logged, and nothing is written to disk, unless a Logger is configured.
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+//	pool.Transport = &srvc.Transport{Client: client, Logger: logger}
+//	pool.Logger = logger.With("pcc", conf.PCC)
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Keys of the structured fields in Transport and SessionPool log records.
+const (
+	LogKeySessionID      = "session_id"
+	LogKeyConversationID = "conversation_id"
+	LogKeyMessageID      = "message_id"
+	LogKeyAction         = "action"
+	LogKeyDuration       = "duration"
+	LogKeyStatus         = "status"
+	LogKeyError          = "error"
+	LogKeyPayload        = "payload"
+)
+
+// DiscardLogger drops everything, it is used when no Logger is configured.
+var DiscardLogger Logger = slog.New(discardHandler{})
+
+// discardHandler drops every record, slog.DiscardHandler is only in Go 1.24 and later.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
+var (
+	actionMatcher    = regexp.MustCompile(`<eb:Action>([^<]*)</eb:Action>`)
+	convIDMatcher    = regexp.MustCompile(`<eb:ConversationId>([^<]*)</eb:ConversationId>`)
+	messageIDMatcher = regexp.MustCompile(`<eb:MessageId>([^<]*)</eb:MessageId>`)
+	cpaIDMatcher     = regexp.MustCompile(`<eb:CPAId>([^<]*)</eb:CPAId>`)
+)
+
+type sessionIDKey struct{}
+
+// WithSessionID returns ctx labeled with pool session id, Transport log records for requests made with it carry LogKeySessionID.
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, id)
+}
+
+// soapAction of the request payload, empty if there is none.
+func soapAction(payload []byte) string {
+	if sub := actionMatcher.FindSubmatch(payload); sub != nil {
+		return string(sub[1])
+	}
+	return ""
+}
+
+// soapPCC of the request payload from eb:CPAId, empty if there is none.
+func soapPCC(payload []byte) string {
+	if sub := cpaIDMatcher.FindSubmatch(payload); sub != nil {
+		return string(sub[1])
+	}
+	return ""
+}
+
+// soapAttrs pulls the fields identifying a SOAP exchange out of the request payload and ctx.
+func soapAttrs(ctx context.Context, payload []byte) []any {
+	attrs := []any{}
+	if id, ok := ctx.Value(sessionIDKey{}).(string); ok && id != "" {
+		attrs = append(attrs, LogKeySessionID, id)
+	}
+	for _, m := range []struct {
+		key string
+		re  *regexp.Regexp
+	}{
+		{LogKeyAction, actionMatcher},
+		{LogKeyConversationID, convIDMatcher},
+		{LogKeyMessageID, messageIDMatcher},
+	} {
+		if sub := m.re.FindSubmatch(payload); sub != nil {
+			attrs = append(attrs, m.key, string(sub[1]))
+		}
+	}
+	return attrs
+}
+
+// loggedBody copies a response body as it is read, logging it redacted at debug level on Close.
+type loggedBody struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	log      Logger
+	redactor *Redactor
+	attrs    []any
+	closed   bool
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.log.Debug("sabre response payload", append(b.attrs[:len(b.attrs):len(b.attrs)], LogKeyPayload, string(b.redactor.Redact(b.buf.Bytes())))...)
+	}
+	return b.ReadCloser.Close()
+}
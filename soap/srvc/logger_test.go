@@ -0,0 +1,106 @@
+package srvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// captureLogger returns a debug level JSON slog.Logger and a func decoding the records written so far.
+func captureLogger() (*slog.Logger, func() []map[string]any) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return logger, func() []map[string]any {
+		records := []map[string]any{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			rec := map[string]any{}
+			if json.Unmarshal([]byte(line), &rec) == nil {
+				records = append(records, rec)
+			}
+		}
+		return records
+	}
+}
+
+func findRecord(records []map[string]any, msg string) map[string]any {
+	for _, r := range records {
+		if r["msg"] == msg {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestTransportLogger(t *testing.T) {
+	logger, records := captureLogger()
+	tr := &Transport{Client: &http.Client{}, Logger: logger}
+	ctx := WithSessionID(WithTransport(context.Background(), tr), "sess-1")
+	_, err := CallSessionCreateContext(ctx, serverCreateRQ.URL, BuildSessionCreateRequest(sampleSessionConf))
+	if err != nil {
+		t.Fatal("CallSessionCreateContext should not return error", err)
+	}
+
+	rs := records()
+	req := findRecord(rs, "sabre request")
+	if req == nil {
+		t.Fatalf("Transport should log 'sabre request', got: %v", rs)
+	}
+	expect := map[string]any{
+		LogKeySessionID:      "sess-1",
+		LogKeyAction:         "SessionCreateRQ",
+		LogKeyConversationID: sampleSessionConf.Convid,
+		LogKeyStatus:         float64(http.StatusOK),
+	}
+	for k, v := range expect {
+		if req[k] != v {
+			t.Errorf("sabre request %s expect: %v, got: %v", k, v, req[k])
+		}
+	}
+	if req[LogKeyMessageID] == nil || req[LogKeyDuration] == nil {
+		t.Errorf("sabre request should have %s and %s, got: %v", LogKeyMessageID, LogKeyDuration, req)
+	}
+	if p := findRecord(rs, "sabre request payload"); p == nil || !strings.Contains(p[LogKeyPayload].(string), "SessionCreateRQ") {
+		t.Errorf("Transport should log request payload at debug, got: %v", p)
+	}
+	if p := findRecord(rs, "sabre response payload"); p == nil || !strings.Contains(p[LogKeyPayload].(string), "SessionCreateRS") {
+		t.Errorf("Transport should log response payload at debug, got: %v", p)
+	}
+}
+
+func TestSessionPoolLogger(t *testing.T) {
+	logger, records := captureLogger()
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	p.Transport = &Transport{Client: &http.Client{}, Logger: logger}
+	_ = p.Populate()
+	sess := p.Pick()
+	p.Put(sess)
+	p.Close()
+
+	rs := records()
+	created := findRecord(rs, "session created")
+	if created == nil || created[LogKeySessionID] != sess.ID {
+		t.Errorf("session created %s expect: %s, got: %v", LogKeySessionID, sess.ID, created)
+	}
+	closed := findRecord(rs, "session closed")
+	if closed == nil || closed[LogKeySessionID] != sess.ID {
+		t.Errorf("session closed %s expect: %s, got: %v", LogKeySessionID, sess.ID, closed)
+	}
+	if r := findRecord(rs, "sabre request"); r == nil || r[LogKeyAction] != "SessionCreateRQ" {
+		t.Errorf("pool should log through Transport.Logger, got: %v", r)
+	}
+
+	//Logger on the pool wins over Transport.Logger
+	own, ownRecords := captureLogger()
+	p = NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	p.Logger = own
+	_ = p.Populate()
+	p.Close()
+	if findRecord(ownRecords(), "pool populated") == nil {
+		t.Error("pool Logger should receive session logs")
+	}
+}
@@ -0,0 +1,199 @@
+package srvc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrorUnknownPool is returned by PoolManager when no pool is registered for Key.
+type ErrorUnknownPool struct {
+	Key string
+}
+
+func (e ErrorUnknownPool) Error() string {
+	return fmt.Sprintf("no session pool for key '%s'", e.Key)
+}
+
+// ManagerStats aggregates Stats of every pool in a PoolManager.
+type ManagerStats struct {
+	Total PoolStats
+	Pools map[string]PoolStats
+}
+
+/*
+PoolManager owns session pools keyed by PCC or tenant, routing Pick and Do to the pool for a key; useful when booking on behalf of several pseudo city codes.
+
+	m := srvc.NewPoolManager()
+	_ = m.Add("7TZA", srvc.NewPool(expire, confTZA, cycle, 10))
+	_ = m.Add("AB12", srvc.NewPool(expire, confB12, cycle, 4))
+	_ = m.Populate()
+	err := m.Do(ctx, "AB12", func(sess srvc.Session) error {...})
+	err = m.Shutdown(ctx)
+*/
+type PoolManager struct {
+	mu    sync.RWMutex
+	pools map[string]*SessionPool
+}
+
+// NewPoolManager returns an empty PoolManager.
+func NewPoolManager() *PoolManager {
+	return &PoolManager{pools: make(map[string]*SessionPool)}
+}
+
+// Add pool under key; returns an error if key is already taken.
+func (m *PoolManager) Add(key string, p *SessionPool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pools[key]; ok {
+		return fmt.Errorf("session pool for key '%s' already exists", key)
+	}
+	m.pools[key] = p
+	return nil
+}
+
+// Pool registered for key, ErrorUnknownPool if there is none.
+func (m *PoolManager) Pool(key string) (*SessionPool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.pools[key]
+	if !ok {
+		return nil, ErrorUnknownPool{Key: key}
+	}
+	return p, nil
+}
+
+// Keys of all pools, sorted.
+func (m *PoolManager) Keys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.pools))
+	for k := range m.pools {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// each runs fn for every pool concurrently and waits for all of them.
+func (m *PoolManager) each(fn func(key string, p *SessionPool)) {
+	var wg sync.WaitGroup
+	for _, key := range m.Keys() {
+		p, err := m.Pool(key)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(key string, p *SessionPool) {
+			defer wg.Done()
+			fn(key, p)
+		}(key, p)
+	}
+	wg.Wait()
+}
+
+// Populate every pool concurrently; returns the first error.
+func (m *PoolManager) Populate() error {
+	var mu sync.Mutex
+	var first error
+	m.each(func(key string, p *SessionPool) {
+		if err := p.Populate(); err != nil {
+			mu.Lock()
+			if first == nil {
+				first = fmt.Errorf("populate '%s': %w", key, err)
+			}
+			mu.Unlock()
+		}
+	})
+	return first
+}
+
+// PickContext session from the pool for key, see SessionPool.PickContext.
+func (m *PoolManager) PickContext(ctx context.Context, key string) (Session, error) {
+	p, err := m.Pool(key)
+	if err != nil {
+		return Session{}, err
+	}
+	return p.pick(ctx, leaseHolder(ctx, 1), "PickContext-")
+}
+
+// Pick session from the pool for key, blocking until one is available.
+func (m *PoolManager) Pick(key string) (Session, error) {
+	p, err := m.Pool(key)
+	if err != nil {
+		return Session{}, err
+	}
+	return p.pick(context.Background(), leaseHolder(context.Background(), 1), "Pick-")
+}
+
+// Put session back into the pool for key.
+func (m *PoolManager) Put(key string, sess Session) error {
+	p, err := m.Pool(key)
+	if err != nil {
+		return err
+	}
+	p.Put(sess)
+	return nil
+}
+
+// Do runs fn with a session from the pool for key, see SessionPool.Do.
+func (m *PoolManager) Do(ctx context.Context, key string, fn func(Session) error) error {
+	p, err := m.Pool(key)
+	if err != nil {
+		return err
+	}
+	return p.Do(ctx, fn)
+}
+
+// Stats of every pool and their total; LastError is the most recent of all pools.
+func (m *PoolManager) Stats() ManagerStats {
+	ms := ManagerStats{Pools: make(map[string]PoolStats)}
+	for _, key := range m.Keys() {
+		p, err := m.Pool(key)
+		if err != nil {
+			continue
+		}
+		st := p.Stats()
+		ms.Pools[key] = st
+		ms.Total.Configured += st.Configured
+		ms.Total.Min += st.Min
+		ms.Total.Max += st.Max
+		ms.Total.Counted += st.Counted
+		ms.Total.Open += st.Open
+		ms.Total.Bad += st.Bad
+		ms.Total.Leased += st.Leased
+		ms.Total.Cycles += st.Cycles
+		ms.Total.NetworkErrors += st.NetworkErrors
+		ms.Total.FaultErrors += st.FaultErrors
+		if st.LastError != nil && st.LastErrorTime.After(ms.Total.LastErrorTime) {
+			ms.Total.LastError = st.LastError
+			ms.Total.LastErrorTime = st.LastErrorTime
+		}
+	}
+	return ms
+}
+
+// Close shuts every pool down like Shutdown, waiting at most DefaultShutdownTimeout for leased sessions (Pick, Do, Lease) to be put back; sessions still leased then are closed on Sabre too.
+func (m *PoolManager) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+	return m.Shutdown(ctx)
+}
+
+// Shutdown every pool concurrently, see SessionPool.Shutdown; returns the first error.
+func (m *PoolManager) Shutdown(ctx context.Context) error {
+	var mu sync.Mutex
+	var first error
+	m.each(func(key string, p *SessionPool) {
+		if err := p.Shutdown(ctx); err != nil {
+			mu.Lock()
+			if first == nil {
+				first = fmt.Errorf("shutdown '%s': %w", key, err)
+			}
+			mu.Unlock()
+		}
+		p.logger().Info("pool manager shut down pool", "key", key)
+	})
+	return first
+}
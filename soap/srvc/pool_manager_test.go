@@ -0,0 +1,136 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func samplePoolManager(t *testing.T) *PoolManager {
+	confA := *sampleSessionConf
+	confA.ServiceURL = serverCreateRQ.URL
+	confB := *sampleSessionConf
+	confB.PCC = "AB12"
+	confB.ServiceURL = serverDown.URL
+
+	m := NewPoolManager()
+	if err := m.Add("7TZA", NewPool(sampleExpireScheme, &confA, cycleEvery, 2)); err != nil {
+		t.Fatal("Add should not return error", err)
+	}
+	if err := m.Add("AB12", NewPool(sampleExpireScheme, &confB, cycleEvery, 1)); err != nil {
+		t.Fatal("Add should not return error", err)
+	}
+	if err := m.Populate(); err != nil {
+		t.Error("Populate should not return error", err)
+	}
+	return m
+}
+
+func TestPoolManagerRouting(t *testing.T) {
+	m := samplePoolManager(t)
+	if err := m.Add("7TZA", NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)); err == nil {
+		t.Error("Add duplicate key should return error")
+	}
+	if keys := m.Keys(); len(keys) != 2 || keys[0] != "7TZA" || keys[1] != "AB12" {
+		t.Errorf("Keys expect: %v, got: %v", []string{"7TZA", "AB12"}, keys)
+	}
+
+	sess, err := m.PickContext(context.Background(), "7TZA")
+	if err != nil {
+		t.Fatal("PickContext should not return error", err)
+	}
+	if !sess.OK {
+		t.Error("Session picked from 7TZA pool should be OK")
+	}
+	bad, _ := m.Pick("AB12")
+	if bad.OK {
+		t.Error("Session picked from AB12 pool should not be OK")
+	}
+	p, _ := m.Pool("7TZA")
+	if len(p.Sessions) != 1 {
+		t.Errorf("7TZA Sessions after Pick expect: %d, got: %d", 1, len(p.Sessions))
+	}
+	_ = m.Put("7TZA", sess)
+	_ = m.Put("AB12", bad)
+
+	called := false
+	err = m.Do(context.Background(), "7TZA", func(sess Session) error {
+		called = true
+		return nil
+	})
+	if err != nil || !called {
+		t.Errorf("Do should run fn on 7TZA pool, called: %v, err: %v", called, err)
+	}
+
+	var unknown ErrorUnknownPool
+	if _, err := m.Pick("ZZZZ"); !errors.As(err, &unknown) || unknown.Key != "ZZZZ" {
+		t.Errorf("Pick unknown key expect ErrorUnknownPool, got: %T %v", err, err)
+	}
+	if err := m.Do(context.Background(), "ZZZZ", func(Session) error { return nil }); !errors.As(err, &unknown) {
+		t.Errorf("Do unknown key expect ErrorUnknownPool, got: %T %v", err, err)
+	}
+	if err := m.Put("ZZZZ", sess); !errors.As(err, &unknown) {
+		t.Errorf("Put unknown key expect ErrorUnknownPool, got: %T %v", err, err)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("Close expect: nil, got: %v", err)
+	}
+}
+
+func TestPoolManagerStatsClose(t *testing.T) {
+	m := samplePoolManager(t)
+	sess, _ := m.Pick("7TZA")
+
+	st := m.Stats()
+	if len(st.Pools) != 2 {
+		t.Fatalf("Stats.Pools expect: %d, got: %d", 2, len(st.Pools))
+	}
+	if st.Total.Configured != 3 || st.Total.Counted != 3 {
+		t.Errorf("Stats.Total Configured/Counted expect: %d/%d, got: %d/%d", 3, 3, st.Total.Configured, st.Total.Counted)
+	}
+	if st.Total.Leased != 1 || st.Pools["7TZA"].Leased != 1 {
+		t.Errorf("Stats Leased expect: %d, got total: %d, 7TZA: %d", 1, st.Total.Leased, st.Pools["7TZA"].Leased)
+	}
+	if st.Total.Bad != 1 || st.Pools["AB12"].Bad != 1 {
+		t.Errorf("Stats Bad expect: %d, got total: %d, AB12: %d", 1, st.Total.Bad, st.Pools["AB12"].Bad)
+	}
+	if st.Total.LastError == nil {
+		t.Error("Stats.Total.LastError should come from AB12 pool")
+	}
+
+	_ = m.Put("7TZA", sess)
+	if err := m.Close(); err != nil {
+		t.Errorf("Close expect: nil, got: %v", err)
+	}
+	for _, key := range m.Keys() {
+		p, _ := m.Pool(key)
+		if p.PoolSizeCounter() != 0 {
+			t.Errorf("%s PoolSizeCounter after Close expect: %d, got: %d", key, 0, p.PoolSizeCounter())
+		}
+	}
+}
+
+func TestPoolManagerShutdownLeased(t *testing.T) {
+	m := samplePoolManager(t)
+	//never put back
+	_, _ = m.Pick("7TZA")
+	_ = m.Do(context.Background(), "AB12", func(Session) error { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := m.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "shutdown '7TZA'") {
+		t.Errorf("Shutdown with leased session expect: 7TZA deadline exceeded, got: %v", err)
+	}
+	for _, key := range m.Keys() {
+		p, _ := m.Pool(key)
+		if p.PoolSizeCounter() != 0 {
+			t.Errorf("%s PoolSizeCounter after Shutdown expect: %d, got: %d", key, 0, p.PoolSizeCounter())
+		}
+	}
+	if _, err = m.Pick("7TZA"); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Pick after Shutdown expect: %v, got: %v", ErrPoolClosed, err)
+	}
+}
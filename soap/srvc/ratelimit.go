@@ -0,0 +1,161 @@
+package srvc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Rate of a token bucket: PerSecond requests on average, up to Burst at once.
+type Rate struct {
+	PerSecond float64 //0 or less removes the limit
+	Burst     int     //bucket size, defaults to 1
+}
+
+type rateKey struct {
+	pcc    string
+	action string
+}
+
+type bucket struct {
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes one token at now and returns how long to wait before using it; tokens go negative so waiting callers queue up in order.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	burst := float64(b.rate.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate.PerSecond
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate.PerSecond * float64(time.Second))
+}
+
+/*
+RateLimiter keeps Transport under the transactions per second Sabre enforces per PCC and per action: requests take a token from the bucket of their PCC (eb:CPAId) and, if one is set, from the bucket of their PCC and action (eb:Action); when a bucket is empty Post waits for a token instead of sending a request Sabre would throttle. Waiting honors the request context.
+
+	l := srvc.NewRateLimiter()
+	l.SetPCC("7TZA", srvc.Rate{PerSecond: 20, Burst: 5})
+	l.SetAction("", "OTA_HotelAvailLLSRQ", srvc.Rate{PerSecond: 5, Burst: 5})
+	t.Limiter = l
+*/
+type RateLimiter struct {
+	mu      sync.Mutex
+	limits  map[rateKey]Rate
+	buckets map[rateKey]*bucket
+}
+
+// NewRateLimiter with no limits set.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{limits: make(map[rateKey]Rate), buckets: make(map[rateKey]*bucket)}
+}
+
+// SetPCC limits all requests for pcc to r; an empty pcc sets the limit for every PCC without its own, each PCC getting its own bucket.
+func (l *RateLimiter) SetPCC(pcc string, r Rate) {
+	l.set(rateKey{pcc: pcc}, r)
+}
+
+// SetAction limits requests for action on pcc to r, on top of the PCC limit; an empty pcc sets the limit for action on every PCC without its own, each PCC getting its own bucket.
+func (l *RateLimiter) SetAction(pcc, action string, r Rate) {
+	l.set(rateKey{pcc: pcc, action: action}, r)
+}
+
+func (l *RateLimiter) set(k rateKey, r Rate) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limits == nil {
+		l.limits = make(map[rateKey]Rate)
+		l.buckets = make(map[rateKey]*bucket)
+	}
+	if r.PerSecond <= 0 {
+		delete(l.limits, k)
+	} else {
+		l.limits[k] = r
+	}
+	//buckets pick up the new rate on next use
+	for bk := range l.buckets {
+		if bk.action == k.action && (k.pcc == "" || bk.pcc == k.pcc) {
+			delete(l.buckets, bk)
+		}
+	}
+}
+
+// bucket for pcc and action, nil when there is no limit.
+func (l *RateLimiter) bucket(pcc, action string) *bucket {
+	k := rateKey{pcc: pcc, action: action}
+	if b, ok := l.buckets[k]; ok {
+		return b
+	}
+	r, ok := l.limits[k]
+	if !ok {
+		r, ok = l.limits[rateKey{action: action}]
+	}
+	if !ok {
+		return nil
+	}
+	b := &bucket{rate: r}
+	l.buckets[k] = b
+	return b
+}
+
+// Wait blocks until a request for action on pcc is allowed or ctx is done, returning ctx.Err() in that case.
+func (l *RateLimiter) Wait(ctx context.Context, pcc, action string) error {
+	_, err := l.wait(ctx, pcc, action)
+	return err
+}
+
+// wait is Wait returning the time spent waiting.
+func (l *RateLimiter) wait(ctx context.Context, pcc, action string) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	var taken []*bucket
+	var delay time.Duration
+	buckets := []*bucket{l.bucket(pcc, "")}
+	if action != "" {
+		buckets = append(buckets, l.bucket(pcc, action))
+	}
+	for _, b := range buckets {
+		if b == nil {
+			continue
+		}
+		if d := b.reserve(now); d > delay {
+			delay = d
+		}
+		taken = append(taken, b)
+	}
+	l.mu.Unlock()
+	if delay <= 0 {
+		return 0, nil
+	}
+	wait := time.NewTimer(delay)
+	defer wait.Stop()
+	select {
+	case <-wait.C:
+		return delay, nil
+	case <-ctx.Done():
+		//not sent, give the tokens back
+		l.mu.Lock()
+		for _, b := range taken {
+			b.tokens++
+		}
+		l.mu.Unlock()
+		return 0, ctx.Err()
+	}
+}
@@ -0,0 +1,113 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// timeWaits returns how long n Waits for pcc and action take.
+func timeWaits(t *testing.T, l *RateLimiter, n int, pcc, action string) time.Duration {
+	started := time.Now()
+	for i := 0; i < n; i++ {
+		if err := l.Wait(context.Background(), pcc, action); err != nil {
+			t.Error("Wait should not return error", err)
+		}
+	}
+	return time.Since(started)
+}
+
+func TestRateLimiterPCC(t *testing.T) {
+	l := NewRateLimiter()
+	l.SetPCC("7TZA", Rate{PerSecond: 20, Burst: 2})
+
+	//burst goes through, then 1 every 50ms
+	if d := timeWaits(t, l, 2, "7TZA", "OTA_HotelAvailLLSRQ"); d > 20*time.Millisecond {
+		t.Errorf("Wait within burst expect: %s, got: %v", "no wait", d)
+	}
+	if d := timeWaits(t, l, 2, "7TZA", "HotelPropertyDescriptionLLSRQ"); d < 80*time.Millisecond {
+		t.Errorf("Wait over burst expect: >= %v, got: %v", 80*time.Millisecond, d)
+	}
+	//other pcc not limited
+	if d := timeWaits(t, l, 5, "AB12", "OTA_HotelAvailLLSRQ"); d > 20*time.Millisecond {
+		t.Errorf("Wait for unlimited PCC expect: %s, got: %v", "no wait", d)
+	}
+	//removing the limit
+	l.SetPCC("7TZA", Rate{})
+	if d := timeWaits(t, l, 5, "7TZA", "OTA_HotelAvailLLSRQ"); d > 20*time.Millisecond {
+		t.Errorf("Wait after removing limit expect: %s, got: %v", "no wait", d)
+	}
+}
+
+func TestRateLimiterAction(t *testing.T) {
+	l := NewRateLimiter()
+	l.SetAction("", "OTA_HotelAvailLLSRQ", Rate{PerSecond: 20, Burst: 1})
+
+	//each pcc has its own bucket for the default action limit
+	if d := timeWaits(t, l, 1, "7TZA", "OTA_HotelAvailLLSRQ") + timeWaits(t, l, 1, "AB12", "OTA_HotelAvailLLSRQ"); d > 20*time.Millisecond {
+		t.Errorf("Wait per PCC bucket expect: %s, got: %v", "no wait", d)
+	}
+	if d := timeWaits(t, l, 2, "7TZA", "OTA_HotelAvailLLSRQ"); d < 80*time.Millisecond {
+		t.Errorf("Wait over action rate expect: >= %v, got: %v", 80*time.Millisecond, d)
+	}
+	//other actions not limited
+	if d := timeWaits(t, l, 5, "7TZA", "GetReservationRQ"); d > 20*time.Millisecond {
+		t.Errorf("Wait for unlimited action expect: %s, got: %v", "no wait", d)
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	l := NewRateLimiter()
+	l.SetPCC("7TZA", Rate{PerSecond: 5, Burst: 1})
+	_ = l.Wait(context.Background(), "7TZA", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if err := l.Wait(ctx, "7TZA", ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait canceled expect: %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(started); d > 150*time.Millisecond {
+		t.Errorf("Wait canceled should return at deadline, took: %v", d)
+	}
+	//token given back, next wait is one interval from the first, not two
+	if d := timeWaits(t, l, 1, "7TZA", ""); d > 300*time.Millisecond {
+		t.Errorf("Wait after cancel expect: <= %v, got: %v", 300*time.Millisecond, d)
+	}
+}
+
+func TestTransportRateLimit(t *testing.T) {
+	var calls atomic.Int64
+	server := serverFailFirst(0, &calls)
+	defer server.Close()
+	l := NewRateLimiter()
+	l.SetAction(sampleSessionConf.PCC, "SessionValidateRQ", Rate{PerSecond: 20, Burst: 1})
+	tr := &Transport{Client: &http.Client{}, Limiter: l}
+	ctx := WithTransport(context.Background(), tr)
+
+	started := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := CallSessionValidateContext(ctx, server.URL, BuildSessionValidateRequest(sampleSessionConf, samplebinsectoken)); err != nil {
+			t.Error("CallSessionValidateContext should not return error", err)
+		}
+	}
+	if d := time.Since(started); d < 80*time.Millisecond {
+		t.Errorf("rate limited calls expect: >= %v, got: %v", 80*time.Millisecond, d)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("rate limited calls expect: %d, got: %d", 3, calls.Load())
+	}
+
+	//caller deadline while waiting, nothing sent
+	calls.Store(0)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, _ = CallSessionValidateContext(ctx, server.URL, BuildSessionValidateRequest(sampleSessionConf, samplebinsectoken))
+	_, err := CallSessionValidateContext(ctx, server.URL, BuildSessionValidateRequest(sampleSessionConf, samplebinsectoken))
+	if err == nil || calls.Load() > 1 {
+		t.Errorf("call past deadline expect: error and <= 1 call, got: %v and %d calls", err, calls.Load())
+	}
+}
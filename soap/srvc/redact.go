@@ -0,0 +1,152 @@
+package srvc
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// secretNames are element and attribute names always masked in full by a Redactor.
+var secretNames = []string{
+	"Password",
+	"CardSecurityCode",
+	"SecurityCode",
+	"SeriesCode",
+	"CVV",
+	"CVC",
+}
+
+var panMatcher = regexp.MustCompile(`\d{13,}`)
+
+// cardMatcher finds PaymentCard and CC_Info elements, self closing or with their content.
+var cardMatcher = regexp.MustCompile(`(?s)<(?:[\w.-]+:)?(?:PaymentCard|CC_Info)(?:\s[^>]*)?(?:/>|>.*?</(?:[\w.-]+:)?(?:PaymentCard|CC_Info)>)`)
+
+// cardNumberMatcher finds a Number attribute or element inside a card, the number is the second group.
+var cardNumberMatcher = regexp.MustCompile(`(\s(?:[\w.-]+:)?Number\s*=\s*["']|<(?:[\w.-]+:)?Number(?:\s[^>]*)?>)([^"'<]+)`)
+
+/*
+Redactor masks sensitive data in SOAP payloads before they are logged, see Transport.Redactor:
+  - card numbers, keeping the last 4 digits: PaymentCard and CC_Info Number values always (spaces, dashes and all),
+    elsewhere any 13-19 digit run passing the Luhn check, in attributes or text
+  - card security codes and passwords, see secretNames
+  - binary security tokens, keeping the trailing '!...!0' part used to tell sessions apart in logs
+  - PII elements and attributes named in PII, e.g. "Surname", "Email", "PhoneNumber"
+
+Payloads are not parsed, so masking works on malformed XML and faults too.
+*/
+type Redactor struct {
+	PII []string
+
+	once     sync.Once
+	elements *regexp.Regexp
+	attrs    *regexp.Regexp
+}
+
+// DefaultRedactor masks card data, passwords and tokens; it is used when Transport.Redactor is nil.
+var DefaultRedactor = &Redactor{}
+
+// NewRedactor masking pii element and attribute names on top of card data, passwords and tokens.
+func NewRedactor(pii ...string) *Redactor {
+	return &Redactor{PII: pii}
+}
+
+func (r *Redactor) compile() {
+	names := []string{}
+	for _, n := range append(append([]string{}, secretNames...), r.PII...) {
+		names = append(names, regexp.QuoteMeta(n))
+	}
+	alt := strings.Join(names, "|")
+	//<ns:Name attr="x">text  and  ns:Name="value"
+	r.elements = regexp.MustCompile(`(<(?:[\w.-]+:)?(?:` + alt + `)(?:\s[^>]*)?>)([^<]+)`)
+	r.attrs = regexp.MustCompile(`(\s(?:[\w.-]+:)?(?:` + alt + `)\s*=\s*)("[^"]*"|'[^']*')`)
+}
+
+// tokenMatcher finds binary security tokens, the token text is the second group.
+var tokenMatcher = regexp.MustCompile(`(<(?:[\w.-]+:)?BinarySecurityToken(?:\s[^>]*)?>)([^<]+)`)
+
+// Redact returns a copy of payload with sensitive data masked.
+func (r *Redactor) Redact(payload []byte) []byte {
+	if r == nil {
+		r = DefaultRedactor
+	}
+	r.once.Do(r.compile)
+	out := cardMatcher.ReplaceAllFunc(payload, func(card []byte) []byte {
+		return cardNumberMatcher.ReplaceAllFunc(card, func(m []byte) []byte {
+			sub := cardNumberMatcher.FindSubmatch(m)
+			return append(append([]byte{}, sub[1]...), maskCardNumber(sub[2])...)
+		})
+	})
+	out = tokenMatcher.ReplaceAllFunc(out, func(m []byte) []byte {
+		sub := tokenMatcher.FindSubmatch(m)
+		return append(append(append([]byte{}, sub[1]...), "****"...), binaryTokenMatcher.Find(sub[2])...)
+	})
+	out = r.elements.ReplaceAllFunc(out, func(m []byte) []byte {
+		sub := r.elements.FindSubmatch(m)
+		if len(bytes.TrimSpace(sub[2])) == 0 {
+			return m
+		}
+		return append(append([]byte{}, sub[1]...), "****"...)
+	})
+	out = r.attrs.ReplaceAllFunc(out, func(m []byte) []byte {
+		sub := r.attrs.FindSubmatch(m)
+		q := sub[2][0]
+		return append(append([]byte{}, sub[1]...), q, '*', '*', '*', '*', q)
+	})
+	return panMatcher.ReplaceAllFunc(out, maskPAN)
+}
+
+// maskPAN masks digits that look like a card number, keeping the last 4.
+func maskPAN(digits []byte) []byte {
+	if len(digits) > 19 || !luhn(digits) {
+		return digits
+	}
+	masked := bytes.Repeat([]byte("X"), len(digits)-4)
+	return append(masked, digits[len(digits)-4:]...)
+}
+
+// maskCardNumber masks every digit of a card Number value but the last 4, separators are kept.
+// Short values are masked in full.
+func maskCardNumber(v []byte) []byte {
+	keep := 0
+	for _, c := range v {
+		if c >= '0' && c <= '9' {
+			keep++
+		}
+	}
+	if keep > 8 {
+		keep = 4
+	} else {
+		keep = 0
+	}
+	masked := append([]byte{}, v...)
+	for i := len(masked) - 1; i >= 0; i-- {
+		if masked[i] < '0' || masked[i] > '9' {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		masked[i] = 'X'
+	}
+	return masked
+}
+
+// luhn checksum used by card numbers.
+func luhn(digits []byte) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
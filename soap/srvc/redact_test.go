@@ -0,0 +1,65 @@
+package srvc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactorRedact(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+		expect  string
+	}{
+		{"password", `<wsse:Password>PASSWORD_GOES_HER</wsse:Password>`, `<wsse:Password>****</wsse:Password>`},
+		{"token", `<wsse:BinarySecurityToken valueType="String">Shared/IDL:IceSess\/SessMgr:1\.0.IDL/Common/!ICESMS\/RESE!ICESMSLB\/RES.LB!-3177016070087638144!110012!0</wsse:BinarySecurityToken>`, `<wsse:BinarySecurityToken valueType="String">****!110012!0</wsse:BinarySecurityToken>`},
+		{"pan attr", `<PaymentCard Code="VI" ExpireDate="2019-07" Number="4111111111111111"/>`, `<PaymentCard Code="VI" ExpireDate="2019-07" Number="XXXXXXXXXXXX1111"/>`},
+		{"pan text", `<Guarantee>VI5555555555554444EXP 10 20-BOOKING</Guarantee>`, `<Guarantee>VIXXXXXXXXXXXX4444EXP 10 20-BOOKING</Guarantee>`},
+		{"cvv attr", `<PaymentCard CardSecurityCode="123" Number="4111111111111111"/>`, `<PaymentCard CardSecurityCode="****" Number="XXXXXXXXXXXX1111"/>`},
+		{"pan not luhn", `<PaymentCard Code="VI" Number="4111111111111112"/>`, `<PaymentCard Code="VI" Number="XXXXXXXXXXXX1112"/>`},
+		{"pan dashes", `<CC_Info><PaymentCard Code="MC" Number="5555-5555-5555-4444"/></CC_Info>`, `<CC_Info><PaymentCard Code="MC" Number="XXXX-XXXX-XXXX-4444"/></CC_Info>`},
+		{"pan element", `<PaymentCard Code="AX"><Number>3782 822463 10006</Number></PaymentCard>`, `<PaymentCard Code="AX"><Number>XXXX XXXXXX X0006</Number></PaymentCard>`},
+		{"not luhn", `<eb:MessageId>4379957601383660213</eb:MessageId>`, `<eb:MessageId>4379957601383660213</eb:MessageId>`},
+		{"empty", `<Password/><SeriesCode></SeriesCode>`, `<Password/><SeriesCode></SeriesCode>`},
+		{"pii off", `<Surname>Smith</Surname>`, `<Surname>Smith</Surname>`},
+	}
+	for _, c := range cases {
+		got := string(DefaultRedactor.Redact([]byte(c.payload)))
+		if got != c.expect {
+			t.Errorf("%s Redact expect: %s, got: %s", c.name, c.expect, got)
+		}
+	}
+
+	pii := NewRedactor("Surname", "Email")
+	got := string(pii.Redact([]byte(`<PersonName><Surname>Smith</Surname></PersonName><Email Type="work">a@z.com</Email><Customer Email="a@z.com"/>`)))
+	expect := `<PersonName><Surname>****</Surname></PersonName><Email Type="work">****</Email><Customer Email="****"/>`
+	if got != expect {
+		t.Errorf("PII Redact expect: %s, got: %s", expect, got)
+	}
+}
+
+func TestTransportLoggerRedacts(t *testing.T) {
+	logger, records := captureLogger()
+	tr := &Transport{Client: &http.Client{}, Logger: logger}
+	ctx := WithTransport(context.Background(), tr)
+	_, _ = CallSessionCreateContext(ctx, serverCreateRQ.URL, BuildSessionCreateRequest(sampleSessionConf))
+
+	for _, msg := range []string{"sabre request payload", "sabre response payload"} {
+		r := findRecord(records(), msg)
+		if r == nil {
+			t.Fatalf("Transport should log '%s'", msg)
+		}
+		p := r[LogKeyPayload].(string)
+		if strings.Contains(p, samplepassword) || strings.Contains(p, "ICESMS") {
+			t.Errorf("%s should be redacted, got: %s", msg, p)
+		}
+	}
+}
+
+func BenchmarkRedactorRedact(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		DefaultRedactor.Redact(sampleSessionSuccessResponse)
+	}
+}
@@ -0,0 +1,107 @@
+package srvc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// faultMatcher finds a soap fault element, Sabre answers faults with HTTP 500.
+var faultMatcher = regexp.MustCompile(`<(?:[\w.-]+:)?Fault[\s>/]`)
+
+// IdempotentActions are read only Sabre actions a RetryPolicy retries by default.
+var IdempotentActions = map[string]bool{
+	"OTA_HotelAvailLLSRQ":           true,
+	"HotelPropertyDescriptionLLSRQ": true,
+	"HotelRateDescriptionLLSRQ":     true,
+	"GetReservationRQ":              true,
+	"QueueCountLLSRQ":               true,
+	"SessionValidateRQ":             true,
+}
+
+// NoRetryActions sell, change or commit a PNR (or, like SabreCommandLLSRQ, may) and are never retried, even when listed in RetryPolicy.Idempotent: a request that timed out may still have been booked.
+var NoRetryActions = map[string]bool{
+	"OTA_HotelResLLSRQ":    true,
+	"EndTransactionLLSRQ":  true,
+	"MiscSegmentSellLLSRQ": true,
+	"PassengerDetailsRQ":   true,
+	"OTA_CancelRQ":         true,
+	"EPS_ProfileToPNRRQ":   true,
+	"SessionCreateRQ":      true,
+	"TokenCreateRQ":        true,
+	"SabreCommandLLSRQ":    true,
+}
+
+/*
+RetryPolicy retries idempotent Sabre requests that fail with a network error, an HTTP 429 or an HTTP 5xx that is not a soap fault, backing off exponentially with jitter between attempts. Set it on a Transport:
+
+	t := srvc.NewTransport(client)
+	t.Retry = &srvc.RetryPolicy{MaxAttempts: 3, BaseDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second}
+*/
+type RetryPolicy struct {
+	MaxAttempts int             //total attempts including the first, 1 or less never retries
+	BaseDelay   time.Duration   //backoff before the first retry, doubled for each one after
+	MaxDelay    time.Duration   //cap on backoff, none when 0
+	Idempotent  map[string]bool //actions safe to retry, IdempotentActions when nil
+}
+
+// attempts allowed for action; a nil policy makes one.
+func (r *RetryPolicy) attempts(action string) int {
+	if r == nil || r.MaxAttempts <= 1 || NoRetryActions[action] {
+		return 1
+	}
+	idempotent := r.Idempotent
+	if idempotent == nil {
+		idempotent = IdempotentActions
+	}
+	if !idempotent[action] {
+		return 1
+	}
+	return r.MaxAttempts
+}
+
+// backoff before retry n (1 based): BaseDelay doubled n-1 times, capped at MaxDelay, with jitter in [d/2, d].
+func (r *RetryPolicy) backoff(n int) time.Duration {
+	d := r.BaseDelay
+	for i := 1; i < n && (r.MaxDelay <= 0 || d < r.MaxDelay); i++ {
+		d *= 2
+	}
+	if r.MaxDelay > 0 && d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+/*
+failedCall true when a posted request failed in a way worth retrying and counting against the circuit breaker: network errors, HTTP 429, and HTTP 5xx without a soap fault in the body.
+Soap faults come back as HTTP 500 but are answers, not outages: retrying them cannot help and an invalid token fault must reach the session pool, which heals by creating sessions the breaker would otherwise block.
+The body of a 5xx is read to look for the fault and put back for the caller.
+*/
+func failedCall(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if resp.StatusCode < http.StatusInternalServerError {
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil {
+		return true
+	}
+	return !faultMatcher.Match(body)
+}
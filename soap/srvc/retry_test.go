@@ -0,0 +1,110 @@
+package srvc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serverFailFirst answers 503 to the first fails requests, then a successful session validate.
+func serverFailFirst(fails int64, calls *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				if calls.Add(1) <= fails {
+					rs.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = rs.Write(sampleSessionValidateRespSuccess)
+			},
+		),
+	)
+}
+
+func TestTransportRetryIdempotent(t *testing.T) {
+	var calls atomic.Int64
+	server := serverFailFirst(2, &calls)
+	defer server.Close()
+	tr := &Transport{Client: &http.Client{}, Retry: &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}}
+	ctx := WithTransport(context.Background(), tr)
+
+	_, err := CallSessionValidateContext(ctx, server.URL, BuildSessionValidateRequest(sampleSessionConf, samplebinsectoken))
+	if err != nil {
+		t.Error("SessionValidateRQ should succeed on retry", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("SessionValidateRQ attempts expect: %d, got: %d", 3, calls.Load())
+	}
+}
+
+func TestTransportRetryNotIdempotent(t *testing.T) {
+	var calls atomic.Int64
+	server := serverFailFirst(10, &calls)
+	defer server.Close()
+	tr := &Transport{Client: &http.Client{}, Retry: &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}}
+	ctx := WithTransport(context.Background(), tr)
+
+	_, _ = CallSessionCreateContext(ctx, server.URL, BuildSessionCreateRequest(sampleSessionConf))
+	if calls.Load() != 1 {
+		t.Errorf("SessionCreateRQ (no retry) attempts expect: %d, got: %d", 1, calls.Load())
+	}
+	calls.Store(0)
+	_, _ = CallIgnoreTransactionContext(ctx, server.URL, BuildIgnoreTransactionRequest(sampleSessionConf, samplebinsectoken))
+	if calls.Load() != 1 {
+		t.Errorf("IgnoreTransactionLLSRQ (not idempotent) attempts expect: %d, got: %d", 1, calls.Load())
+	}
+
+	//NoRetryActions wins over a custom Idempotent list
+	tr.Retry.Idempotent = map[string]bool{"SessionCreateRQ": true, "IgnoreTransactionLLSRQ": true}
+	calls.Store(0)
+	_, _ = CallSessionCreateContext(ctx, server.URL, BuildSessionCreateRequest(sampleSessionConf))
+	if calls.Load() != 1 {
+		t.Errorf("SessionCreateRQ listed idempotent attempts expect: %d, got: %d", 1, calls.Load())
+	}
+	calls.Store(0)
+	_, _ = CallIgnoreTransactionContext(ctx, server.URL, BuildIgnoreTransactionRequest(sampleSessionConf, samplebinsectoken))
+	if calls.Load() != 3 {
+		t.Errorf("IgnoreTransactionLLSRQ listed idempotent attempts expect: %d, got: %d", 3, calls.Load())
+	}
+}
+
+func TestTransportRetryCanceled(t *testing.T) {
+	var calls atomic.Int64
+	server := serverFailFirst(10, &calls)
+	defer server.Close()
+	tr := &Transport{Client: &http.Client{}, Retry: &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second}}
+	ctx, cancel := context.WithTimeout(WithTransport(context.Background(), tr), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := CallSessionValidateContext(ctx, server.URL, BuildSessionValidateRequest(sampleSessionConf, samplebinsectoken))
+	if err == nil {
+		t.Error("canceled retry should return error")
+	}
+	if time.Since(started) > 500*time.Millisecond || calls.Load() != 1 {
+		t.Errorf("canceled retry should stop backing off, took: %v attempts: %d", time.Since(started), calls.Load())
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	r := &RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	cases := []struct {
+		n        int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{4, 400 * time.Millisecond, 800 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+	}
+	for _, c := range cases {
+		for i := 0; i < 20; i++ {
+			if d := r.backoff(c.n); d < c.min || d > c.max {
+				t.Errorf("backoff(%d) expect in: [%v, %v], got: %v", c.n, c.min, c.max, d)
+			}
+		}
+	}
+}
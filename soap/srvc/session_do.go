@@ -0,0 +1,125 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+// deadSessionMarkers are found in fault codes, fault strings and stack traces when Sabre no longer recognizes the binary security token of a session.
+var deadSessionMarkers = []string{
+	"InvalidSecurityToken",
+	"USG_INVALID_SECURITY_TOKEN",
+	"Invalid or Expired binary security token",
+}
+
+// containsDeadMarker helper to match any dead session marker
+func containsDeadMarker(vals ...string) bool {
+	for _, v := range vals {
+		for _, m := range deadSessionMarkers {
+			if strings.Contains(v, m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SessionDead true when fault means the session token is invalid or expired on Sabre; the session cannot be used again and must be replaced.
+func (fault SOAPFault) SessionDead() bool {
+	if fault.Ok() {
+		return false
+	}
+	return containsDeadMarker(fault.Code, fault.String, fault.Detail.StackTrace)
+}
+
+// IsSessionDead classifies errors returned from Call* functions. It is true when the error is a soap fault for an invalid or expired session token.
+func IsSessionDead(err error) bool {
+	if err == nil {
+		return false
+	}
+	var fault sbrerr.ErrorSoapFault
+	if errors.As(err, &fault) {
+		return containsDeadMarker(fault.FaultCode, fault.ErrMessage, fault.StackTrace)
+	}
+	return false
+}
+
+// Do leases a session from the pool (see PickContext, a done ctx returns ErrorPickTimeout) and runs fn with it. When fn fails because Sabre reports the session dead (see IsSessionDead) the session is replaced in the pool with a newly created one and fn is retried once on the fresh session. The session handed to fn is always returned to the pool, even if fn panics; with IgnoreOnFailure it is returned with PutDirty when fn fails or panics.
+//
+//	err := pool.Do(ctx, func(sess srvc.Session) error {
+//		_, err := htlsp.CallHotelAvailContext(ctx, serviceURL, htlsp.BuildHotelAvailRequest(conf, sess.BinSecTokCached, body))
+//		return err
+//	})
+func (p *SessionPool) Do(ctx context.Context, fn func(Session) error) error {
+	sess, err := p.pick(ctx, leaseHolder(ctx, 1), "DoPick-")
+	if err != nil {
+		return err
+	}
+	failed := true //until fn returns nil, covers panics
+	put := true    //false once Replace leaves no session to put back
+	defer func() {
+		if !put {
+			return
+		}
+		if failed && p.IgnoreOnFailure {
+			p.PutDirty(sess)
+			return
+		}
+		p.Put(sess)
+	}()
+
+	err = fn(sess)
+	if !IsSessionDead(err) {
+		failed = err != nil
+		return err
+	}
+	p.logger().Warn("session dead, replacing and retry", LogKeySessionID, sess.ID, "token", SabreTokenParse(sess.BinSecTokCached), LogKeyError, err)
+	if sess, err = p.Replace(ctx, sess); err != nil {
+		//dead is closed and nothing was picked
+		put = false
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	err = fn(sess)
+	failed = err != nil
+	if IsSessionDead(err) {
+		//let keepalive heal it
+		sess.OK = false
+	}
+	return err
+}
+
+// Replace discards picked session dead (see IsSessionDead) and returns a newly created session that takes its place in the pool, leased to the same holder; put the returned session back instead of dead. Dead is closed on Sabre before the replacement is created in its PCC session slot, so a session that was not dead after all never counts twice against SetPCCSessionLimit; Sabre answers a fault for a token it no longer knows.
+// When the lease on dead was already reclaimed (see ReclaimOverdue) its replacement is in the pool: dead is closed, its PCC session slot released, and a session is picked for the caller instead, waiting until ctx is done (ErrorPickTimeout).
+func (p *SessionPool) Replace(ctx context.Context, dead Session) (Session, error) {
+	p.mu.Lock()
+	l, leased := p.leases[dead.ID]
+	delete(p.leases, dead.ID)
+	_, reclaimed := p.reclaimed[dead.ID]
+	delete(p.reclaimed, dead.ID)
+	p.mu.Unlock()
+	if reclaimed {
+		p.logger().Info("replacing session after lease was reclaimed, closing and picking", LogKeySessionID, dead.ID)
+		p.closeReclaimed(dead)
+		return p.pick(ctx, leaseHolder(ctx, 1), "ReplaceReclaimedPick-")
+	}
+
+	p.closeSession(dead)
+	sess, err := p.newSession()
+	if err != nil {
+		p.logger().Error("replacing dead session, adding bad session for keepalive to heal", LogKeySessionID, dead.ID, LogKeyError, err)
+	}
+	p.logger().Info("session replaced", LogKeySessionID, dead.ID, "replaced_by", sess.ID, "ok", sess.OK, "token", SabreTokenParse(sess.BinSecTokCached))
+	if leased {
+		l.SessionID, l.session = sess.ID, sess
+		p.mu.Lock()
+		p.leases[sess.ID] = l
+		p.mu.Unlock()
+	}
+	return sess, nil
+}
@@ -0,0 +1,281 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+var (
+	sampleDeadFault = sbrerr.ErrorSoapFault{
+		FaultCode:  sampleSessionInvalidTokenFaultCode,
+		ErrMessage: sampleSessionInvalidTokenString,
+		StackTrace: sampleSessionInvalidTokenStackTrace,
+		Code:       sbrerr.SoapFault,
+	}
+)
+
+func TestIsSessionDead(t *testing.T) {
+	cases := []struct {
+		err    error
+		expect bool
+	}{
+		{nil, false},
+		{errors.New("boom"), false},
+		{sbrerr.NewErrorSabreService("Post http://127.0.0.1", sbrerr.ErrCallHotelAvail, sbrerr.BadService), false},
+		{sbrerr.NewErrorSoapFault("Authentication failed"), false},
+		{sampleDeadFault, true},
+		{sbrerr.NewErrorSoapFault(sampleSessionInvalidTokenString), true},
+		{sbrerr.ErrorSoapFault{FaultCode: sampleSessionInvalidTokenFaultCode}, true},
+	}
+	for i, c := range cases {
+		if IsSessionDead(c.err) != c.expect {
+			t.Errorf("case %d IsSessionDead(%v) expect: %v, got: %v", i, c.err, c.expect, !c.expect)
+		}
+	}
+}
+
+func TestSOAPFaultSessionDead(t *testing.T) {
+	f := SOAPFault{}
+	if f.SessionDead() {
+		t.Error("Empty fault should not be SessionDead")
+	}
+	f.Code = sampleSessionInvalidTokenFaultCode
+	if !f.SessionDead() {
+		t.Error("Invalid token fault should be SessionDead")
+	}
+}
+
+func TestSessionPoolDoSuccess(t *testing.T) {
+	poolSize := 2
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = p.Populate()
+
+	calls := 0
+	err := p.Do(context.Background(), func(sess Session) error {
+		calls++
+		if len(p.Sessions) != poolSize-1 {
+			t.Errorf("Sessions during Do expect: %d, got: %d", poolSize-1, len(p.Sessions))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error("Do should not return error", err)
+	}
+	if calls != 1 {
+		t.Errorf("Do calls expect: %d, got: %d", 1, calls)
+	}
+	if len(p.Sessions) != poolSize {
+		t.Errorf("Sessions after Do expect: %d, got: %d", poolSize, len(p.Sessions))
+	}
+}
+
+func TestSessionPoolDoNotDeadNoRetry(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+
+	calls := 0
+	want := sbrerr.NewErrorSoapFault("Authentication failed")
+	err := p.Do(context.Background(), func(sess Session) error {
+		calls++
+		return want
+	})
+	if err != want {
+		t.Errorf("Do error expect: %v, got: %v", want, err)
+	}
+	if calls != 1 {
+		t.Errorf("Do calls expect: %d, got: %d", 1, calls)
+	}
+	if len(p.Sessions) != 1 {
+		t.Errorf("Sessions after Do expect: %d, got: %d", 1, len(p.Sessions))
+	}
+}
+
+func TestSessionPoolDoDeadRetry(t *testing.T) {
+	poolSize := 1
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = p.Populate()
+
+	ids := []string{}
+	err := p.Do(context.Background(), func(sess Session) error {
+		ids = append(ids, sess.ID)
+		if len(ids) == 1 {
+			return sampleDeadFault
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error("Do should succeed on retry", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("Do calls expect: %d, got: %d", 2, len(ids))
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("Retry should use a fresh session, both calls used ID-%s", ids[0])
+	}
+	if len(p.Sessions) != poolSize {
+		t.Errorf("Sessions after Do expect: %d, got: %d", poolSize, len(p.Sessions))
+	}
+	sess := p.Pick()
+	if sess.ID != ids[1] {
+		t.Errorf("Pool should hold replacement session expect: %s, got: %s", ids[1], sess.ID)
+	}
+	if !sess.OK {
+		t.Error("Replacement session should be OK")
+	}
+	if len(p.Leases()) != 1 || p.Leases()[0].SessionID != ids[1] {
+		t.Errorf("Lease should move to replacement session ID-%s, got: %v", ids[1], p.Leases())
+	}
+	p.Put(sess)
+	if len(p.Leases()) != 0 {
+		t.Errorf("Leases after Put expect: %d, got: %d", 0, len(p.Leases()))
+	}
+}
+
+func TestSessionPoolReplaceClosesFirst(t *testing.T) {
+	ts, count := serverCountActions()
+	defer ts.Close()
+	conf := *sampleSessionConf
+	conf.PCC = "RPLC"
+	conf.ServiceURL = ts.URL
+	SetPCCSessionLimit(conf.PCC, 1)
+	defer SetPCCSessionLimit(conf.PCC, 0)
+	p := NewPool(sampleExpireScheme, &conf, cycleEvery, 1)
+	_ = p.Populate()
+
+	dead := p.Pick()
+	sess, err := p.Replace(context.Background(), dead)
+	if err != nil {
+		t.Fatalf("Replace expect: nil, got: %v", err)
+	}
+	if count("SessionCloseRQ") != 1 || count("SessionCreateRQ") != 2 {
+		t.Errorf("Replace expect: 1 close and 2 creates, got: %d and %d", count("SessionCloseRQ"), count("SessionCreateRQ"))
+	}
+	if !sess.OK || sess.ID == dead.ID {
+		t.Errorf("Replace expect: new OK session, got: %+v", sess)
+	}
+	if n := pccOpen(conf.PCC); n != 1 {
+		t.Errorf("PCC sessions after Replace expect: 1, got: %d", n)
+	}
+	p.Put(sess)
+	p.Close()
+}
+
+func TestSessionPoolReplaceReclaimed(t *testing.T) {
+	conf := *sampleSessionConf
+	conf.PCC = "RPRC"
+	conf.ServiceURL = serverCreateRQ.URL
+	SetPCCSessionLimit(conf.PCC, 3)
+	defer SetPCCSessionLimit(conf.PCC, 0)
+	p := NewPool(sampleExpireScheme, &conf, cycleEvery, 2)
+	p.MaxLease = time.Millisecond
+	_ = p.Populate()
+
+	dead := p.Pick()
+	time.Sleep(2 * p.MaxLease)
+	if reclaimed := p.ReclaimOverdue(); len(reclaimed) != 1 {
+		t.Fatalf("ReclaimOverdue expect: 1, got: %v", reclaimed)
+	}
+	sess, err := p.Replace(context.Background(), dead)
+	if err != nil {
+		t.Fatalf("Replace reclaimed expect: nil, got: %v", err)
+	}
+	if sess.ID == dead.ID {
+		t.Errorf("Replace reclaimed expect: session from the pool, got: ID-%s", sess.ID)
+	}
+	if n := pccOpen(conf.PCC); n != 2 {
+		t.Errorf("PCC sessions after Replace reclaimed expect: 2, got: %d", n)
+	}
+	p.Put(sess)
+	if len(p.Sessions) != 2 || p.PoolSizeCounter() != 2 {
+		t.Errorf("pool after Put expect: 2 queued 2 counted, got: %d %d", len(p.Sessions), p.PoolSizeCounter())
+	}
+	if st := p.Stats(); st.Leased != 0 || st.Counted != 2 || st.Open != 2 {
+		t.Errorf("Stats after Put expect: 0 leased 2 counted 2 open, got: %+v", st)
+	}
+	if n := pccOpen(conf.PCC); n != 2 {
+		t.Errorf("PCC sessions after Put expect: 2, got: %d", n)
+	}
+
+	//nothing left to pick, the caller gets the pick error
+	dead = p.Pick()
+	time.Sleep(2 * p.MaxLease)
+	_ = p.ReclaimOverdue()
+	first, second := p.Pick(), p.Pick()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = p.Replace(ctx, dead); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Replace reclaimed on empty pool expect: %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if n := pccOpen(conf.PCC); n != 2 {
+		t.Errorf("PCC sessions after failed Replace expect: 2, got: %d", n)
+	}
+	p.Put(first)
+	p.Put(second)
+	p.Close()
+}
+
+func TestSessionPoolDoDeadTwice(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+
+	calls := 0
+	err := p.Do(context.Background(), func(sess Session) error {
+		calls++
+		return sampleDeadFault
+	})
+	if !IsSessionDead(err) {
+		t.Error("Do should return dead session error after retry", err)
+	}
+	if calls != 2 {
+		t.Errorf("Do calls expect: %d, got: %d", 2, calls)
+	}
+	sess := p.Pick()
+	if sess.OK {
+		t.Error("Session dead after retry should be returned not OK for keepalive to heal")
+	}
+}
+
+func TestSessionPoolDoContextCanceled(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+	sess := p.Pick()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := p.Do(ctx, func(sess Session) error {
+		t.Error("fn should not be called on canceled context with empty pool")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Do error expect: %v, got: %v", context.Canceled, err)
+	}
+	var pickErr ErrorPickTimeout
+	if !errors.As(err, &pickErr) {
+		t.Errorf("Do error expect ErrorPickTimeout, got: %T", err)
+	}
+	p.Put(sess)
+}
+
+func TestSessionPoolDoPanicPutsBack(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+	func() {
+		defer func() { _ = recover() }()
+		_ = p.Do(context.Background(), func(sess Session) error {
+			panic("boom")
+		})
+	}()
+	if len(p.Sessions) != 1 {
+		t.Errorf("Sessions after panic in Do expect: %d, got: %d", 1, len(p.Sessions))
+	}
+}
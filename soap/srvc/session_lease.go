@@ -0,0 +1,344 @@
+package srvc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrLeaseReleased is returned when extending a Lease that was released or has expired.
+var ErrLeaseReleased = errors.New("session lease released or expired")
+
+// ErrorPickTimeout is returned by PickContext when ctx is done before a session could be picked from an exhausted pool. It unwraps to the context error, so errors.Is(err, context.DeadlineExceeded) works as expected.
+type ErrorPickTimeout struct {
+	Waited time.Duration
+	Stats  PoolStats
+	Err    error
+}
+
+func (e ErrorPickTimeout) Error() string {
+	return fmt.Sprintf("pick session: %v after waiting %v, configured=%d leased=%d open=%d", e.Err, e.Waited, e.Stats.Configured, e.Stats.Leased, e.Stats.Open)
+}
+
+// Unwrap returns the context error.
+func (e ErrorPickTimeout) Unwrap() error {
+	return e.Err
+}
+
+// LeaseInfo records who picked a session and since when.
+type LeaseInfo struct {
+	SessionID string
+	Holder    string
+	Since     time.Time
+	PCC       string  //emulated after ContextChange, empty while at the pool PCC
+	session   Session //token to close on Shutdown
+}
+
+type leaseHolderKey struct{}
+
+// WithLeaseHolder returns ctx labeled with holder; sessions picked with the returned context are recorded as held by holder (e.g., handler name or request id) instead of the calling function.
+func WithLeaseHolder(ctx context.Context, holder string) context.Context {
+	return context.WithValue(ctx, leaseHolderKey{}, holder)
+}
+
+// leaseHolder label from ctx, otherwise the function, file and line skip frames above the caller.
+func leaseHolder(ctx context.Context, skip int) string {
+	if h, ok := ctx.Value(leaseHolderKey{}).(string); ok && h != "" {
+		return h
+	}
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	name := "unknown"
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name = fn.Name()
+	}
+	return fmt.Sprintf("%s %s:%d", name, filepath.Base(file), line)
+}
+
+// PickContext picks a session from the buffered queue, waiting until one is put back or ctx is done. On deadline or cancel it returns ErrorPickTimeout. The session is recorded in the lease registry (see Leases) until Put.
+func (p *SessionPool) PickContext(ctx context.Context) (Session, error) {
+	return p.pick(ctx, leaseHolder(ctx, 1), "PickContext-")
+}
+
+// pick session for holder, reporting with report prefix. Waiting Scale.PickWait for a session scales the pool up; both waits are on the pool clock.
+func (p *SessionPool) pick(ctx context.Context, holder, report string) (Session, error) {
+	if p.stopping.Load() {
+		return Session{}, ErrPoolClosed
+	}
+	started := p.now()
+	var waited <-chan struct{}
+	if p.Scale.PickWait > 0 && p.canScaleUp() {
+		ch := make(chan struct{})
+		t := p.clock().AfterFunc(p.Scale.PickWait, func() { close(ch) })
+		defer t.Stop()
+		waited = ch
+	}
+	for {
+		select {
+		case sess, ok := <-p.Sessions:
+			if !ok {
+				return Session{}, ErrPoolClosed
+			}
+			p.registerLease(sess, holder)
+			p.logReport(report + sess.ID)
+			p.checkUtilization()
+			return sess, nil
+		case <-waited:
+			waited = nil
+			p.scaleUpFor("PickWait")
+		case <-ctx.Done():
+			err := ErrorPickTimeout{Waited: p.now().Sub(started), Stats: p.Stats(), Err: ctx.Err()}
+			p.logger().Warn("pick timeout", "holder", holder, LogKeyError, err)
+			return Session{}, err
+		}
+	}
+}
+
+// registerLease records sess as held by holder, starting now.
+func (p *SessionPool) registerLease(sess Session, holder string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.leases == nil {
+		p.leases = make(map[string]LeaseInfo)
+	}
+	p.leases[sess.ID] = LeaseInfo{SessionID: sess.ID, Holder: holder, Since: p.now(), session: sess}
+}
+
+// releaseLease removes the lease for session id; reclaimed is true when the lease was forcibly reclaimed before the session was returned.
+func (p *SessionPool) releaseLease(id string) (reclaimed bool) {
+	_, reclaimed = p.takeLease(id)
+	return reclaimed
+}
+
+// takeLease removes the lease for session id; held is true when the lease was still registered, reclaimed when it was forcibly reclaimed.
+func (p *SessionPool) takeLease(id string) (held, reclaimed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.leases[id]; ok {
+		delete(p.leases, id)
+		return true, false
+	}
+	if _, ok := p.reclaimed[id]; ok {
+		delete(p.reclaimed, id)
+		return false, true
+	}
+	return false, false
+}
+
+// Leases lists sessions currently picked from the pool, oldest first.
+func (p *SessionPool) Leases() []LeaseInfo {
+	p.mu.Lock()
+	leases := make([]LeaseInfo, 0, len(p.leases))
+	for _, l := range p.leases {
+		leases = append(leases, l)
+	}
+	p.mu.Unlock()
+	sort.Slice(leases, func(i, j int) bool { return leases[i].Since.Before(leases[j].Since) })
+	return leases
+}
+
+// OverdueLeases lists leases held longer than MaxLease, oldest first. Nothing is overdue when MaxLease is 0.
+func (p *SessionPool) OverdueLeases() []LeaseInfo {
+	if p.MaxLease <= 0 {
+		return nil
+	}
+	overdue := []LeaseInfo{}
+	for _, l := range p.Leases() {
+		if p.now().Sub(l.Since) > p.MaxLease {
+			overdue = append(overdue, l)
+		}
+	}
+	return overdue
+}
+
+// ReclaimOverdue forcibly reclaims leases held longer than MaxLease: each overdue session is replaced in the pool with a newly created session so the pool keeps its size. The reclaimed session stays open on Sabre, so it takes a PCC session slot of its own (see SetPCCSessionLimit) until the holder finally calls Put with it and it is closed on Sabre instead of returned to the pool; leases are not reclaimed while the PCC is at its limit. Returns the leases reclaimed.
+func (p *SessionPool) ReclaimOverdue() []LeaseInfo {
+	reclaimed := []LeaseInfo{}
+	for _, l := range p.OverdueLeases() {
+		if !acquirePCCSession(p.Conf.PCC) {
+			p.logger().Warn("lease reclaim deferred, session limit reached for PCC", "pcc", p.Conf.PCC, LogKeySessionID, l.SessionID, "holder", l.Holder)
+			break
+		}
+		p.mu.Lock()
+		_, ok := p.leases[l.SessionID]
+		if ok {
+			delete(p.leases, l.SessionID)
+			if p.reclaimed == nil {
+				p.reclaimed = make(map[string]Session)
+			}
+			p.reclaimed[l.SessionID] = l.session
+		}
+		p.mu.Unlock()
+		//put back while we were looking
+		if !ok {
+			releasePCCSession(p.Conf.PCC)
+			continue
+		}
+		sess, err := p.newSession()
+		if err != nil {
+			p.logger().Error("replacing reclaimed session, adding bad session for keepalive to heal", LogKeySessionID, l.SessionID, LogKeyError, err)
+		}
+		p.logger().Warn("lease reclaimed", LogKeySessionID, l.SessionID, "holder", l.Holder, "held", p.now().Sub(l.Since), "replaced_by", sess.ID)
+		p.requeue(sess)
+		reclaimed = append(reclaimed, l)
+	}
+	return reclaimed
+}
+
+// closeReclaimed closes sess, returned after its lease was reclaimed, and frees the PCC session slot it took on ReclaimOverdue.
+func (p *SessionPool) closeReclaimed(sess Session) {
+	p.closeSession(sess)
+	releasePCCSession(p.Conf.PCC)
+}
+
+// closeSession closes sess on Sabre, used for sessions no longer part of the pool.
+func (p *SessionPool) closeSession(sess Session) {
+	if sess.BinSecTokCached == "" {
+		return
+	}
+	closeRS, err := CallSessionCloseContext(p.sessionContext(sess), p.ServiceURL, BuildSessionCloseRequest(p.Conf, sess.BinSecTokCached))
+	if err != nil {
+		p.addNetworkError(err)
+	}
+	p.logger().Info("session closed", LogKeySessionID, sess.ID, LogKeyStatus, closeRS.Body.SessionCloseRS.Status, "token", SabreTokenParse(sess.BinSecTokCached))
+}
+
+/*
+Lease is a sticky hold on one pool session for multi step flows that must stay on the same AAA workspace, e.g., hotel search -> property description -> sell. The session is out of the pool until Release, or until the lease expires when it is not extended in time (user leaves browser window open, no more requests coming in on the api). Store Lease.ID in the web session and find the lease again on the next request:
+
+	lease, err := pool.Lease(ctx, 5*time.Minute)
+	webSession.Set("sabre_lease", lease.ID)
+	...
+	lease, ok := pool.LookupLease(webSession.Get("sabre_lease"))
+	if !ok {
+		//expired, start the flow over
+	}
+	_ = lease.Extend(5 * time.Minute)
+	...
+	lease.Release()
+
+Leases are recorded in the lease registry with holder 'lease:ID', so MaxLease shorter than the ttl will reclaim them on keepalive.
+*/
+type Lease struct {
+	ID      string
+	Session Session
+	pool    *SessionPool
+	mu      sync.Mutex
+	expires time.Time
+	timer   Timer
+	done    bool
+}
+
+// GenerateLeaseID returns a random, hard to guess, lease ID safe to hand out in cookies.
+func GenerateLeaseID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Lease picks a session (see PickContext) and holds it for ttl, see Lease type.
+func (p *SessionPool) Lease(ctx context.Context, ttl time.Duration) (*Lease, error) {
+	id := GenerateLeaseID()
+	sess, err := p.pick(ctx, "lease:"+id, "Lease-")
+	if err != nil {
+		return nil, err
+	}
+	l := &Lease{
+		ID:      id,
+		Session: sess,
+		pool:    p,
+		expires: p.now().Add(ttl),
+	}
+	//hold the lease lock so Release or expire cannot run before the timer is set
+	l.mu.Lock()
+	p.mu.Lock()
+	if p.sticky == nil {
+		p.sticky = make(map[string]*Lease)
+	}
+	p.sticky[id] = l
+	p.mu.Unlock()
+	l.timer = p.clock().AfterFunc(ttl, l.expire)
+	l.mu.Unlock()
+	p.logger().Info("session leased", LogKeySessionID, sess.ID, "lease_id", id, "expires", l.expires)
+	return l, nil
+}
+
+// LookupLease finds a lease that has not been released or expired.
+func (p *SessionPool) LookupLease(id string) (*Lease, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	l, ok := p.sticky[id]
+	return l, ok
+}
+
+// ExpiresAt when the lease is released unless extended.
+func (l *Lease) ExpiresAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expires
+}
+
+// Extend the lease for d from now; returns ErrLeaseReleased once the session is back in the pool.
+func (l *Lease) Extend(d time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return ErrLeaseReleased
+	}
+	l.timer.Stop()
+	l.expires = l.pool.now().Add(d)
+	l.timer = l.pool.clock().AfterFunc(d, l.expire)
+	return nil
+}
+
+// Release ends the lease and puts the session back into the pool. Safe to call more than once.
+func (l *Lease) Release() {
+	l.release("LeaseRelease-", false)
+}
+
+// ReleaseDirty ends the lease of an abandoned or failed flow, putting the session back with PutDirty.
+func (l *Lease) ReleaseDirty() {
+	l.release("LeaseReleaseDirty-", true)
+}
+
+// expire releases the lease when time is up.
+func (l *Lease) expire() {
+	l.mu.Lock()
+	//extended after the timer fired
+	early := l.pool.now().Before(l.expires)
+	l.mu.Unlock()
+	if early {
+		return
+	}
+	l.release("LeaseExpire-", l.pool.IgnoreOnFailure)
+}
+
+func (l *Lease) release(report string, dirty bool) {
+	l.mu.Lock()
+	if l.done {
+		l.mu.Unlock()
+		return
+	}
+	l.done = true
+	l.timer.Stop()
+	l.mu.Unlock()
+
+	l.pool.mu.Lock()
+	delete(l.pool.sticky, l.ID)
+	l.pool.mu.Unlock()
+	if dirty {
+		l.pool.logger().Info("lease released dirty", LogKeySessionID, l.Session.ID, "lease_id", l.ID, "report", report)
+		l.pool.PutDirty(l.Session)
+		return
+	}
+	l.pool.put(l.Session, report+l.Session.ID)
+}
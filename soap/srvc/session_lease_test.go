@@ -0,0 +1,230 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionPoolPickContext(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+
+	sess, err := p.PickContext(context.Background())
+	if err != nil {
+		t.Error("PickContext should not return error", err)
+	}
+	if sess.ID == "" {
+		t.Error("PickContext should return session")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.PickContext(ctx)
+	var pickErr ErrorPickTimeout
+	if !errors.As(err, &pickErr) {
+		t.Fatalf("PickContext on exhausted pool expect ErrorPickTimeout, got: %T %v", err, err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ErrorPickTimeout should unwrap to: %v, got: %v", context.DeadlineExceeded, pickErr.Err)
+	}
+	if pickErr.Waited < 20*time.Millisecond {
+		t.Errorf("ErrorPickTimeout.Waited expect at least: %v, got: %v", 20*time.Millisecond, pickErr.Waited)
+	}
+	if pickErr.Stats.Leased != 1 {
+		t.Errorf("ErrorPickTimeout.Stats.Leased expect: %d, got: %d", 1, pickErr.Stats.Leased)
+	}
+	p.Put(sess)
+}
+
+func TestSessionPoolLeases(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 2)
+	_ = p.Populate()
+
+	caller := p.Pick()
+	labeled, _ := p.PickContext(WithLeaseHolder(context.Background(), "GET /hotels"))
+	leases := p.Leases()
+	if len(leases) != 2 {
+		t.Fatalf("Leases expect: %d, got: %d", 2, len(leases))
+	}
+	if leases[0].SessionID != caller.ID {
+		t.Errorf("Leases oldest first expect: %s, got: %s", caller.ID, leases[0].SessionID)
+	}
+	if !strings.Contains(leases[0].Holder, "TestSessionPoolLeases") || !strings.Contains(leases[0].Holder, "session_lease_test.go") {
+		t.Errorf("Lease holder should be calling function, got: %s", leases[0].Holder)
+	}
+	if leases[1].Holder != "GET /hotels" {
+		t.Errorf("Lease holder expect: %s, got: %s", "GET /hotels", leases[1].Holder)
+	}
+	if p.OverdueLeases() != nil {
+		t.Error("No leases should be overdue without MaxLease")
+	}
+
+	p.Put(caller)
+	p.Put(labeled)
+	if len(p.Leases()) != 0 {
+		t.Errorf("Leases after Put expect: %d, got: %d", 0, len(p.Leases()))
+	}
+}
+
+func TestSessionPoolReclaimOverdue(t *testing.T) {
+	poolSize := 2
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	p.MaxLease = 10 * time.Millisecond
+	_ = p.Populate()
+
+	leaked := p.Pick()
+	time.Sleep(2 * p.MaxLease)
+	fresh := p.Pick()
+
+	overdue := p.OverdueLeases()
+	if len(overdue) != 1 || overdue[0].SessionID != leaked.ID {
+		t.Fatalf("OverdueLeases expect: %s, got: %v", leaked.ID, overdue)
+	}
+	reclaimed := p.ReclaimOverdue()
+	if len(reclaimed) != 1 || reclaimed[0].SessionID != leaked.ID {
+		t.Fatalf("ReclaimOverdue expect: %s, got: %v", leaked.ID, reclaimed)
+	}
+	if len(p.Sessions) != 1 {
+		t.Errorf("Replacement session should be queued expect: %d, got: %d", 1, len(p.Sessions))
+	}
+	if p.Stats().Leased != 1 {
+		t.Errorf("Stats().Leased after reclaim expect: %d, got: %d", 1, p.Stats().Leased)
+	}
+
+	//late put of reclaimed session is closed, not queued
+	p.Put(leaked)
+	if len(p.Sessions) != 1 {
+		t.Errorf("Reclaimed session should not be queued on Put expect: %d, got: %d", 1, len(p.Sessions))
+	}
+	p.Put(fresh)
+	if len(p.Sessions) != poolSize {
+		t.Errorf("Sessions expect: %d, got: %d", poolSize, len(p.Sessions))
+	}
+	if p.PoolSizeCounter() != poolSize {
+		t.Errorf("PoolSizeCounter expect: %d, got: %d", poolSize, p.PoolSizeCounter())
+	}
+}
+
+// pccOpen sessions counted against the limit of pcc
+func pccOpen(pcc string) int {
+	pccLimits.Lock()
+	defer pccLimits.Unlock()
+	return pccLimits.open[pcc]
+}
+
+func TestSessionPoolReclaimOverduePCCLimit(t *testing.T) {
+	conf := *sampleSessionConf
+	conf.PCC = "RCLM"
+	conf.ServiceURL = serverCreateRQ.URL
+	SetPCCSessionLimit(conf.PCC, 2)
+	defer SetPCCSessionLimit(conf.PCC, 0)
+	p := NewPool(sampleExpireScheme, &conf, cycleEvery, 2)
+	p.MaxLease = time.Millisecond
+	_ = p.Populate()
+
+	leaked := p.Pick()
+	time.Sleep(2 * p.MaxLease)
+	if reclaimed := p.ReclaimOverdue(); len(reclaimed) != 0 {
+		t.Errorf("ReclaimOverdue at PCC limit expect: none, got: %v", reclaimed)
+	}
+	if p.Stats().Leased != 1 || pccOpen(conf.PCC) != 2 {
+		t.Errorf("deferred reclaim expect: 1 leased 2 open, got: %d leased %d open", p.Stats().Leased, pccOpen(conf.PCC))
+	}
+
+	SetPCCSessionLimit(conf.PCC, 3)
+	if reclaimed := p.ReclaimOverdue(); len(reclaimed) != 1 {
+		t.Fatalf("ReclaimOverdue under PCC limit expect: 1, got: %v", reclaimed)
+	}
+	if n := pccOpen(conf.PCC); n != 3 {
+		t.Errorf("PCC sessions with reclaimed session still open expect: 3, got: %d", n)
+	}
+	p.Put(leaked)
+	if n := pccOpen(conf.PCC); n != 2 {
+		t.Errorf("PCC sessions after reclaimed session closed expect: 2, got: %d", n)
+	}
+
+	//reclaimed and never put back is closed on Shutdown
+	_ = p.Pick()
+	time.Sleep(2 * p.MaxLease)
+	_ = p.ReclaimOverdue()
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown expect: nil, got: %v", err)
+	}
+	if n := pccOpen(conf.PCC); n != 0 {
+		t.Errorf("PCC sessions after Shutdown expect: 0, got: %d", n)
+	}
+}
+
+func TestSessionPoolLeaseLookupRelease(t *testing.T) {
+	poolSize := 2
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = p.Populate()
+
+	lease, err := p.Lease(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatal("Lease should not return error", err)
+	}
+	if len(lease.ID) != 32 {
+		t.Errorf("Lease.ID length expect: %d, got: %d", 32, len(lease.ID))
+	}
+	if len(p.Sessions) != poolSize-1 {
+		t.Errorf("Sessions during lease expect: %d, got: %d", poolSize-1, len(p.Sessions))
+	}
+	found, ok := p.LookupLease(lease.ID)
+	if !ok || found.Session.ID != lease.Session.ID {
+		t.Errorf("LookupLease should find same session expect: %s, got: %v", lease.Session.ID, found)
+	}
+	if p.Leases()[0].Holder != "lease:"+lease.ID {
+		t.Errorf("Lease registry holder expect: %s, got: %s", "lease:"+lease.ID, p.Leases()[0].Holder)
+	}
+
+	lease.Release()
+	lease.Release()
+	if len(p.Sessions) != poolSize {
+		t.Errorf("Sessions after Release expect: %d, got: %d", poolSize, len(p.Sessions))
+	}
+	if _, ok := p.LookupLease(lease.ID); ok {
+		t.Error("LookupLease should not find released lease")
+	}
+	if err := lease.Extend(time.Minute); err != ErrLeaseReleased {
+		t.Errorf("Extend released lease expect: %v, got: %v", ErrLeaseReleased, err)
+	}
+}
+
+func TestSessionPoolLeaseExpireExtend(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+
+	ttl := 40 * time.Millisecond
+	lease, _ := p.Lease(context.Background(), ttl)
+	time.Sleep(ttl / 2)
+	if err := lease.Extend(ttl); err != nil {
+		t.Error("Extend should not return error", err)
+	}
+	time.Sleep(ttl * 3 / 4)
+	if _, ok := p.LookupLease(lease.ID); !ok {
+		t.Error("Extended lease should not have expired")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sess, err := p.PickContext(ctx)
+	if err != nil {
+		t.Fatal("Expired lease should put session back in pool", err)
+	}
+	if sess.ID != lease.Session.ID {
+		t.Errorf("Session after lease expiry expect: %s, got: %s", lease.Session.ID, sess.ID)
+	}
+	if _, ok := p.LookupLease(lease.ID); ok {
+		t.Error("LookupLease should not find expired lease")
+	}
+	p.Put(sess)
+}
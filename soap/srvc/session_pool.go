@@ -1,32 +1,27 @@
 package srvc
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var (
-	//these counts separate from session pool to prevent data races
-	NumberSessionPoolCycles = 0
-	NumberOfBadSessions     = 0
-)
-
 // Session holds sabre session data and other fields for handling in the SessionPool
 type Session struct {
-	ID               string
-	FaultError       error
-	OK               bool
-	PromisePutAfter  time.Duration  //time elapsed put back in to poo
-	PromisePut       chan bool      //put back in pool
-	PromiseSigListen chan os.Signal //end promise on signal
-	TimeValidated    time.Time
-	TimeStarted      time.Time
-	ExpireTime       time.Time
-	BinSecTokCached  string
+	ID              string
+	FaultError      error
+	OK              bool
+	TimeValidated   time.Time
+	TimeStarted     time.Time
+	ExpireTime      time.Time
+	TimeLastUsed    time.Time //last put back by a caller, for ScaleDown
+	BinSecTokCached string
+	PCC             string //emulated after SessionPool.ContextChange, empty while at the pool Conf.PCC
 }
 
 // ExpireScheme for when to expire sessions
@@ -36,20 +31,107 @@ type ExpireScheme struct {
 }
 
 // SessionPool container for pool of sessions with specs on size, cycles, counters, errors, timers, configuration, etc...
+// Counters and errors are bookkeeping owned by each pool and safe for concurrent use; read them with Stats(), PoolSizeCounter(), NetworkErrors() and FaultErrors().
 type SessionPool struct {
 	ConfigPoolSize  int
-	PoolSizeCounter int
 	refreshMod      int
 	Expire          ExpireScheme
 	CycleEvery      time.Duration
 	ServiceURL      string
-	NetworkErrors   []error
-	FaultErrors     []error
 	InitializedTime time.Time
 	Sessions        chan Session
 	ShutDown        chan os.Signal
 	Signals         []os.Signal
 	Conf            *SessionConf
+	Transport       *Transport    //optional, DefaultTransport when nil
+	MaxLease        time.Duration //sessions picked longer than this are overdue and reclaimed on keepalive, 0 never
+	IgnoreOnFailure bool          //PutDirty sessions from a failed Do or an expired Lease, see PutDirty
+	ShutdownTimeout time.Duration //Daemonize bound on Shutdown, DefaultShutdownTimeout when 0
+	Scale           ScaleScheme   //optional, dynamic sizing between Scale.Min and Scale.Max
+	Store           SessionStore  //optional, Shutdown persists sessions here and Populate reuses the ones still valid
+	Logger          Logger        //optional, structured session logs; Transport.Logger when nil, nothing is logged when both are nil
+	Clock           Clock         //optional, SystemClock when nil; set before Populate
+	Rand            rand.Source   //optional, random expire times, refresh selection and IDs; seeded from Clock when nil, set before Populate
+
+	poolSize atomic.Int64 //sessions allocated to the pool, in or out of the queue
+	cycles   atomic.Int64 //keepalive cycles run
+	scaling  atomic.Bool  //scale up in progress
+	stopping atomic.Bool  //Shutdown called, no more picks
+
+	quit          chan struct{} //closed by Shutdown to stop keepalive
+	keepaliveDone chan struct{} //closed when Keepalive returns
+	closeMu       sync.RWMutex  //guards closed and sending on Sessions
+	closed        bool          //Sessions closed
+
+	mu            sync.Mutex //guards fields below
+	badSessions   int
+	leases        map[string]LeaseInfo //picked sessions by ID
+	reclaimed     map[string]Session   //sessions reclaimed while leased, still open on Sabre
+	sticky        map[string]*Lease    //sticky leases by Lease.ID
+	networkErrors []error
+	faultErrors   []error
+	lastError     error
+	lastErrorTime time.Time
+	lastKeepalive time.Time //see Health
+
+	randOnce sync.Once
+	rnd      *lockedRand
+}
+
+// PoolStats is a snapshot of SessionPool bookkeeping at the time Stats() was called.
+type PoolStats struct {
+	Configured    int       //ConfigPoolSize
+	Min           int       //lower bound when scaling
+	Max           int       //upper bound when scaling
+	Counted       int       //sessions allocated to the pool
+	Open          int       //sessions queued and not bad
+	Bad           int       //sessions known to be bad, waiting on keepalive to heal them
+	Leased        int       //sessions picked and not yet put back
+	Cycles        int       //keepalive cycles run
+	NetworkErrors int       //count of network errors collected
+	FaultErrors   int       //count of soap faults collected
+	LastError     error     //most recent network or fault error, nil if none
+	LastErrorTime time.Time //when LastError was recorded
+	Circuit       string    //circuit breaker state for ServiceURL (closed, open, half-open), empty without a Transport.Breaker
+}
+
+// PoolStatsJSON is PoolStats for JSON, served by the health probes and the broker.
+type PoolStatsJSON struct {
+	Configured    int       `json:"configured"`
+	Min           int       `json:"min"`
+	Max           int       `json:"max"`
+	Counted       int       `json:"counted"`
+	Open          int       `json:"open"`
+	Bad           int       `json:"bad"`
+	Leased        int       `json:"leased"`
+	Cycles        int       `json:"cycles"`
+	NetworkErrors int       `json:"network_errors"`
+	FaultErrors   int       `json:"fault_errors"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time"`
+	Circuit       string    `json:"circuit,omitempty"`
+}
+
+// JSON copy of st, LastError as its message.
+func (st PoolStats) JSON() PoolStatsJSON {
+	js := PoolStatsJSON{
+		Configured:    st.Configured,
+		Min:           st.Min,
+		Max:           st.Max,
+		Counted:       st.Counted,
+		Open:          st.Open,
+		Bad:           st.Bad,
+		Leased:        st.Leased,
+		Cycles:        st.Cycles,
+		NetworkErrors: st.NetworkErrors,
+		FaultErrors:   st.FaultErrors,
+		LastErrorTime: st.LastErrorTime,
+		Circuit:       st.Circuit,
+	}
+	if st.LastError != nil {
+		js.LastError = st.LastError.Error()
+	}
+	return js
 }
 
 func findMod(total int) int {
@@ -77,9 +159,111 @@ func NewPool(expire ExpireScheme, cred *SessionConf, cycle time.Duration, size i
 		Expire:          expire,
 		Conf:            cred,
 		Signals:         sig,
+		leases:          make(map[string]LeaseInfo),
+		reclaimed:       make(map[string]Session),
+		sticky:          make(map[string]*Lease),
 	}
 }
 
+// callContext returns a context carrying the pool Transport for session service calls.
+func (p *SessionPool) callContext() context.Context {
+	ctx := context.Background()
+	if p.Transport != nil {
+		ctx = WithTransport(ctx, p.Transport)
+	}
+	return ctx
+}
+
+// logger for session logs, see Logger field.
+func (p *SessionPool) logger() Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	return p.transport().logger()
+}
+
+// transport session calls go through, see callContext.
+func (p *SessionPool) transport() *Transport {
+	if p.Transport != nil {
+		return p.Transport
+	}
+	return DefaultTransport
+}
+
+// sessionContext is callContext labeled with the session ID for Transport logs.
+func (p *SessionPool) sessionContext(sess Session) context.Context {
+	return WithSessionID(p.callContext(), sess.ID)
+}
+
+// PoolSizeCounter number of sessions allocated to the pool, whether queued or picked.
+func (p *SessionPool) PoolSizeCounter() int {
+	return int(p.poolSize.Load())
+}
+
+// NetworkErrors returns a copy of network errors collected by the pool.
+func (p *SessionPool) NetworkErrors() []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]error{}, p.networkErrors...)
+}
+
+// FaultErrors returns a copy of soap fault errors collected by the pool.
+func (p *SessionPool) FaultErrors() []error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]error{}, p.faultErrors...)
+}
+
+// Stats returns a snapshot of the pool counters, safe to call from any goroutine.
+func (p *SessionPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := PoolStats{
+		Configured:    p.ConfigPoolSize,
+		Min:           p.minSize(),
+		Max:           p.maxSize(),
+		Counted:       p.PoolSizeCounter(),
+		Bad:           p.badSessions,
+		Leased:        len(p.leases),
+		Cycles:        int(p.cycles.Load()),
+		NetworkErrors: len(p.networkErrors),
+		FaultErrors:   len(p.faultErrors),
+		LastError:     p.lastError,
+		LastErrorTime: p.lastErrorTime,
+	}
+	st.Open = len(p.Sessions) - p.badSessions
+	if st.Open < 0 {
+		st.Open = 0
+	}
+	if b := p.transport().Breaker; b != nil {
+		st.Circuit = b.State(p.ServiceURL).String()
+	}
+	return st
+}
+
+// addNetworkError collect err as a network error and the last error.
+func (p *SessionPool) addNetworkError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.networkErrors = append(p.networkErrors, err)
+	p.lastError, p.lastErrorTime = err, p.now()
+}
+
+// addFaultError collect err as a soap fault error and the last error.
+func (p *SessionPool) addFaultError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.faultErrors = append(p.faultErrors, err)
+	p.lastError, p.lastErrorTime = err, p.now()
+}
+
+// setLastError record err as the last error without collecting it.
+func (p *SessionPool) setLastError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastError, p.lastErrorTime = err, p.now()
+}
+
 // GenerateSessionID for small easy to find ids in logs; returns format 'PGC.346'
 func GenerateSessionID() string {
 	randStr := randStringBytesMaskImprSrc(3)
@@ -91,10 +275,20 @@ func GenerateSessionID() string {
 // This is used to better randomize expiration times of sessions
 // fitting within a min and max time range.
 func RandomInt(min, max int) int {
+	return randomInt(newLockedRand(rand.NewSource(time.Now().UnixNano())), min, max)
+}
+
+// randomInt is RandomInt drawing from r; ranges narrower than 3 fall back to an index in [0,n).
+func randomInt(r *lockedRand, min, max int) int {
+	if max < min {
+		return min
+	}
 	//make random index, with Permute [0,n] for size min-max+1
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	idx := max - min + 1
-	p := r.Perm(idx)
+	if idx < 3 {
+		return min + r.intn(idx)
+	}
+	p := r.perm(idx)
 	//make key from position idx-3 of permuted slice
 	key := p[idx-3]
 	a := make([]int, idx)
@@ -112,13 +306,13 @@ func (p *SessionPool) newSession() (Session, error) {
 	var err error
 	var ok bool = true
 	createRQ := BuildSessionCreateRequest(p.Conf)
-	createRS, err := CallSessionCreate(p.ServiceURL, createRQ)
+	createRS, err := CallSessionCreateContext(p.callContext(), p.ServiceURL, createRQ)
 	if err != nil {
 		// create is special, we still want to put crappy sessions into the buffer because RangeKeepAlive will eventually heal them
 		ok = false
-		p.NetworkErrors = append(p.NetworkErrors, err)
+		p.addNetworkError(err)
 	}
-	now := time.Now()
+	now := p.now()
 	var faultErr error
 	fc := createRS.Body.Fault.Code
 	if fc != "" {
@@ -126,7 +320,7 @@ func (p *SessionPool) newSession() (Session, error) {
 		st := createRS.Body.Fault.Detail.StackTrace
 		fs := createRS.Body.Fault.String
 		faultErr = fmt.Errorf("%s-%s: %s", fs, fc, st)
-		p.FaultErrors = append(p.FaultErrors, faultErr)
+		p.addFaultError(faultErr)
 	}
 
 	// still want to fill buffer even if we get a fault from Sabre
@@ -135,18 +329,15 @@ func (p *SessionPool) newSession() (Session, error) {
 	// filling it with bad sessions. Instead, accept bad sessions, and let the
 	// cleanup will clear out faulted sessions later).
 	sess := Session{
-		ID:               GenerateSessionID(),
-		BinSecTokCached:  createRS.Header.Security.BinarySecurityToken.Value,
-		TimeStarted:      now,
-		TimeValidated:    now,
-		ExpireTime:       now.Add(time.Minute * time.Duration(RandomInt(p.Expire.Min, p.Expire.Max))),
-		FaultError:       faultErr,
-		OK:               ok,
-		PromisePutAfter:  time.Duration(5 * time.Minute),
-		PromisePut:       make(chan bool),
-		PromiseSigListen: make(chan os.Signal, 1),
-	}
-	signal.Notify(sess.PromiseSigListen, p.Signals...)
+		ID:              p.generateSessionID(),
+		BinSecTokCached: createRS.Header.Security.BinarySecurityToken.Value,
+		TimeStarted:     now,
+		TimeValidated:   now,
+		ExpireTime:      p.expireTime(now),
+		TimeLastUsed:    now,
+		FaultError:      faultErr,
+		OK:              ok,
+	}
 	var status string
 	if createRS.Body.SessionCreateRS.Status == "" {
 		status = "NO CREATE"
@@ -154,42 +345,36 @@ func (p *SessionPool) newSession() (Session, error) {
 	} else {
 		status = createRS.Body.SessionCreateRS.Status
 	}
-	logSession.Printf(
-		"ID-%s OK=%v Create Status='%s' Expirey='%s' for token=%s",
-		sess.ID,
-		sess.OK,
-		status,
-		sess.ExpireTime,
-		SabreTokenParse(sess.BinSecTokCached),
-	)
-	countBadSessions(sess.OK, sess.ID, p.ConfigPoolSize)
+	p.logger().Info("session created", LogKeySessionID, sess.ID, "ok", sess.OK, LogKeyStatus, status, "expires", sess.ExpireTime, "token", SabreTokenParse(sess.BinSecTokCached))
+	p.countBadSessions(sess.OK)
 	return sess, err
 }
 
 func (p *SessionPool) refreshSession(sess Session) {
-	logSession.Printf("RefreshSession ID-%s ", sess.ID)
+	p.logger().Info("refresh session", LogKeySessionID, sess.ID)
 	//if session was created while network was down its not going to have this and won't exist on sabre side... no use closing what does not exist, just try re-creating
 	if sess.BinSecTokCached != "" {
-		logSession.Printf("BinSecToken valid for close ID-%s ", sess.ID)
 		closeRQ := BuildSessionCloseRequest(p.Conf, sess.BinSecTokCached)
-		_, err := CallSessionClose(p.ServiceURL, closeRQ)
+		_, err := CallSessionCloseContext(p.sessionContext(sess), p.ServiceURL, closeRQ)
 		if err != nil {
-			fmt.Println(err)
+			p.logger().Warn("close before refresh", LogKeySessionID, sess.ID, LogKeyError, err)
 		}
 	}
 	s, _ := p.newSession()
-	p.Sessions <- s
+	p.requeue(s)
 }
 
-func countBadSessions(sessOK bool, id string, configuredPoolSize int) {
+func (p *SessionPool) countBadSessions(sessOK bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if !sessOK {
-		if NumberOfBadSessions >= configuredPoolSize {
+		if p.badSessions >= p.maxSize() {
 			//don't count any higher
 			return
 		}
-		NumberOfBadSessions++
-	} else if NumberOfBadSessions >= 1 { //if count 1 likely THIS is last/only bad session
-		NumberOfBadSessions--
+		p.badSessions++
+	} else if p.badSessions >= 1 { //if count 1 likely THIS is last/only bad session
+		p.badSessions--
 	}
 }
 
@@ -202,151 +387,192 @@ func countBadSessions(sessOK bool, id string, configuredPoolSize int) {
 // service goes down, or we are over session limit...).
 // Under these conditions we don't want to block or repeatedly attempt to populate;
 // instead, accept a bad session and let the keepalive cleanup bad sessions later.
+// With a Store, sessions persisted by the last Shutdown are validated and reused first,
+// new sessions are only created for the missing slots.
 func (p *SessionPool) Populate() error {
 	var err error
 	var ok bool
-	p.Sessions = make(chan Session, p.ConfigPoolSize) //buffered channel blocks!
-	for i := 0; i < p.ConfigPoolSize; i++ {
+	p.Sessions = make(chan Session, p.maxSize()) //buffered channel blocks! room to scale up to max
+	for i := p.restoreSessions(); i < p.ConfigPoolSize; i++ {
+		if !acquirePCCSession(p.Conf.PCC) {
+			p.logger().Warn("populate stopped, session limit reached for PCC", "pcc", p.Conf.PCC, "created", i)
+			break
+		}
 		sess, err := p.newSession()
 		if err != nil {
-			logSession.Printf("ERROR %v for attempt=%d, adding bad session, KeepAlive will heal it...", err, i)
+			p.logger().Error("populate, adding bad session for keepalive to heal", "attempt", i, LogKeyError, err)
 		}
 		p.Sessions <- sess
-		p.PoolSizeCounter++
+		p.poolSize.Add(1)
 	}
-	ok = ((p.PoolSizeCounter == len(p.Sessions)) && (p.ConfigPoolSize == len(p.Sessions)) && (len(p.NetworkErrors) != len(p.Sessions)))
+	ok = ((p.PoolSizeCounter() == len(p.Sessions)) && (p.ConfigPoolSize == len(p.Sessions)) && (len(p.NetworkErrors()) != len(p.Sessions)))
 	//close blocking channel, message, return error
 	if p.ConfigPoolSize == 0 {
 		err = fmt.Errorf("You have not allowed any sessions to be created, check PoolSizeCounter on SessionPool; closing SessionPool for now.")
 		// p.NetworkErrors = append(p.NetworkErrors, err)
 		//this closes it so the app can be shutdown(don't want to leave an empty buffered channel open because it will forever block). It does not ever allow the pool to be populated again... Since ConfigPoolSize is user defined this may be the best way
-		close(p.Sessions)
+		p.markClosed()
 		//Is it really OK? it's not blocking and that is good, but ...
 		ok = false
 	}
-	logSession.Printf("Create PoolSizeCounter=%d, Create OK=%v. NetworkErrors=%v, FaultErrors=%v", p.PoolSizeCounter, ok, p.NetworkErrors, p.FaultErrors)
+	p.logger().Info("pool populated", "size", p.PoolSizeCounter(), "ok", ok, "network_errors", len(p.NetworkErrors()), "fault_errors", len(p.FaultErrors()))
 	return err
 }
 
-// Pick session from buffered queue, returns the Session.
+// Pick session from buffered queue, returns the Session. Pick blocks until a session is available, see PickContext to bound the wait.
 func (p *SessionPool) Pick() Session {
-	sess := <-p.Sessions
-	p.logReport("Pick-" + sess.ID)
+	sess, _ := p.pick(context.Background(), leaseHolder(context.Background(), 1), "Pick-")
 	return sess
 }
 
 // Put session back onto the buffered queue.
 func (p *SessionPool) Put(sess Session) {
-	p.Sessions <- sess
-	p.logReport("Put-" + sess.ID)
+	p.put(sess, "Put-"+sess.ID)
+}
+
+// put session back on the queue, releasing its lease, and report it. A session whose lease was reclaimed has already been replaced in the pool, it is closed instead.
+func (p *SessionPool) put(sess Session, ctx string) {
+	if pcc := p.emulatedPCC(sess); pcc != "" {
+		if err := p.resetContext(sess); err != nil {
+			p.logger().Warn("context change back to pool PCC failed, replacing session", LogKeySessionID, sess.ID, "pcc", pcc, LogKeyError, err)
+			p.replaceReturned(sess, "ContextResetReplaced-"+sess.ID)
+			return
+		}
+		sess.PCC = ""
+	}
+	held, reclaimed := p.takeLease(sess.ID)
+	if reclaimed {
+		p.logger().Info("session returned after lease was reclaimed, closing", LogKeySessionID, sess.ID)
+		p.closeReclaimed(sess)
+		return
+	}
+	sess.TimeLastUsed = p.now()
+	if !p.enqueue(sess) {
+		if !held {
+			//Shutdown already closed it with the other leased sessions
+			p.logger().Info("session returned after shutdown, dropped", LogKeySessionID, sess.ID)
+			return
+		}
+		p.logger().Info("session returned during shutdown, closing", LogKeySessionID, sess.ID)
+		p.closeSession(sess)
+		p.poolSize.Add(-1)
+		releasePCCSession(p.Conf.PCC)
+		return
+	}
+	p.logReport(ctx)
+}
+
+// replaceReturned closes sess, returned by a caller in a state the next caller must not get, and adds a new session in its place.
+func (p *SessionPool) replaceReturned(sess Session, report string) {
+	if p.releaseLease(sess.ID) {
+		//reclaimed, replacement already in the pool
+		p.closeReclaimed(sess)
+		return
+	}
+	p.refreshSession(sess)
+	p.logReport(report)
 }
 
 // logReport helper to log info about session pool
-func (p SessionPool) logReport(ctx string) {
-	configured := p.ConfigPoolSize
-	count := p.PoolSizeCounter
-	open := len(p.Sessions) - NumberOfBadSessions
-	notOpen := (count - open)
-	logSession.Printf("[%s] CONFIGURED=%d, COUNTED=%d, BAD=%d, OPEN=%d, NOT_OPEN=%d, CYCLES=%d, STABLE=%v", ctx, configured, count, NumberOfBadSessions, open, notOpen, NumberSessionPoolCycles, (count == (open + notOpen)))
-}
-
-// RangeKeepalive pulls session out of the pool, checks if expire time is over current time,
-// and if so it validates the session against Sabre (which forces Sabre to extend the lifetime)
-// and we reset the expire time, placing session back into the pool. Otherwise
-// we place session back into the pool leaving the expire time untouched.
+func (p *SessionPool) logReport(ctx string) {
+	st := p.Stats()
+	notOpen := (st.Counted - st.Open)
+	p.logger().Debug("pool report", "report", ctx, "configured", st.Configured, "counted", st.Counted, "bad", st.Bad, "open", st.Open, "leased", st.Leased, "not_open", notOpen, "cycles", st.Cycles, "stable", st.Counted == (st.Open+notOpen))
+}
+
+// RangeKeepalive makes one pass over sessions queued in the pool. Sessions are taken
+// off the queue one at a time with a non-blocking receive: fresh sessions are put back
+// immediately and only a session due for validation or refresh is held out while Sabre
+// is called, so Pick callers never wait behind more than one session. Sessions leased
+// by callers are not waited on; they are kept alive on a later cycle.
 func (p *SessionPool) RangeKeepalive(keepaliveID string) {
+	defer p.setLastKeepalive()
+	seen := make(map[string]bool)
 	breaker := len(p.Sessions)
-	counter := 0
-	for sess := range p.Sessions {
-		//counter==breaker: no looping in the range indefinitely. 1 pass of buffer size is enough
-		if counter == breaker {
-			p.Sessions <- sess
+	for counter := 1; counter <= breaker; counter++ {
+		var sess Session
+		select {
+		case sess = <-p.Sessions:
+		default:
+			//everything is leased, nothing to keep alive this cycle
 			return
 		}
-		counter++
-		// keeping the pool "fresh": semi-randomly pick session, close, create new, put in pool
-		if counter%p.refreshMod == 0 {
-			if time.Since(sess.TimeValidated)%3 == 0 {
-				logSession.Printf("ID-%s select for refresh...\n", sess.ID)
-				p.refreshSession(sess)
-				continue
-			}
+		//fresh sessions cycle to the back of the queue, don't visit twice
+		if seen[sess.ID] {
+			p.requeue(sess)
+			continue
+		}
+		seen[sess.ID] = true
+		p.keepaliveSession(sess, counter, keepaliveID)
+	}
+}
+
+// keepaliveSession checks if expire time is over current time, and if so it validates
+// the session against Sabre (which forces Sabre to extend the lifetime) and we reset the
+// expire time, placing session back into the pool. Otherwise we place session back into
+// the pool leaving the expire time untouched. Every counter%refreshMod session has a one
+// in three chance (drawn from Rand) to be closed and replaced with a new one to keep the pool fresh.
+func (p *SessionPool) keepaliveSession(sess Session, counter int, keepaliveID string) {
+	// keeping the pool "fresh": semi-randomly pick session, close, create new, put in pool
+	if counter%p.refreshMod == 0 {
+		if p.random().intn(3) == 0 {
+			p.logger().Debug("session selected for refresh", LogKeySessionID, sess.ID)
+			p.refreshSession(sess)
+			return
+		}
+	}
+	//time to expire and/or try to recover from bad state
+	now := p.now()
+	if now.After(sess.ExpireTime) || !sess.OK {
+		validateRQ := BuildSessionValidateRequest(p.Conf, sess.BinSecTokCached)
+		validateRS, err := CallSessionValidateContext(p.sessionContext(sess), p.ServiceURL, validateRQ)
+		if err != nil {
+			//if network error, log and continue. We'll update the queue item with a new expire and allow it to cycle through again. The session may still be valid and useable even if the session validate endpoint is down. Even if it is no longer valid, we don't want to dequeue the pool becuase if sabre is totally down we will end up with an empty queue that will block forever. If Sabre is down they are down, a nothing we can do, so we just go forward as usual and self-repair as Sabre services come back online.
+			p.logger().Warn("keepalive validate", LogKeySessionID, sess.ID, LogKeyError, err)
 		}
-		//time to expire and/or try to recover from bad state
-		if time.Now().After(sess.ExpireTime) || !sess.OK {
-			validateRQ := BuildSessionValidateRequest(p.Conf, sess.BinSecTokCached)
-			validateRS, err := CallSessionValidate(p.ServiceURL, validateRQ)
+		if validateRS.Header.MessageHeader.Action == StatusErrorRS {
+			msg := fmt.Sprintf(
+				"%s %s, %s, %s",
+				StatusErrorRS,
+				validateRS.Body.Fault.String,
+				validateRS.Body.Fault.Code,
+				validateRS.Body.Fault.Detail.StackTrace,
+			)
+			p.logger().Warn("keepalive validate fault, replacing session", LogKeySessionID, sess.ID, LogKeyAction, validateRS.Header.MessageHeader.Action, LogKeyError, msg)
+			newSess, err := p.newSession()
 			if err != nil {
-				//if network error, log and continue. We'll update the queue item with a new expire and allow it to cycle through again. The session may still be valid and useable even if the session validate endpoint is down. Even if it is no longer valid, we don't want to dequeue the pool becuase if sabre is totally down we will end up with an empty queue that will block forever. If Sabre is down they are down, a nothing we can do, so we just go forward as usual and self-repair as Sabre services come back online.
-				logSession.Print(err)
-			}
-			if validateRS.Header.MessageHeader.Action == StatusErrorRS {
-				msg := fmt.Sprintf(
-					"%s %s, %s, %s",
-					StatusErrorRS,
-					validateRS.Body.Fault.String,
-					validateRS.Body.Fault.Code,
-					validateRS.Body.Fault.Detail.StackTrace,
-				)
-				logSession.Printf("FAULT='%s', %s\n", validateRS.Header.MessageHeader.Action, msg)
-				newSess, err := p.newSession()
-				if err != nil {
-					logSession.Printf("Network ERROR for ID=%s, expire and retry", newSess.ID)
-					newSess.ExpireTime = time.Now().Add(time.Second * 30)
-				}
-				logSession.Printf("ID-%s OK=%v NewSession-%s token=%s\n",
-					newSess.ID,
-					newSess.OK,
-					keepaliveID,
-					SabreTokenParse(newSess.BinSecTokCached),
-				)
-				//kill sess::Session  already pulled off queue, GC will pick it up...
-				countBadSessions(newSess.OK, newSess.ID, p.ConfigPoolSize)
-				p.Sessions <- newSess
-				continue
+				p.logger().Error("keepalive replacing session, expire and retry", LogKeySessionID, newSess.ID, LogKeyError, err)
+				newSess.ExpireTime = p.now().Add(time.Second * 30)
 			}
-			//reset expire, validated time, binary token (these shouldn't change but update anyway)
-			sess.ExpireTime = time.Now().Add(time.Minute * time.Duration(RandomInt(p.Expire.Min, p.Expire.Max)))
-			sess.TimeValidated = time.Now()
-			sess.BinSecTokCached = validateRS.Header.Security.BinarySecurityToken.Value
-			logSession.Printf(
-				"ID-%s UPDATED-%s-%s token=%s AliveFor=%.2f(mins) Next ExpireIn=%.2f(mins)\n",
-				sess.ID,
-				keepaliveID,
-				validateRS.Header.MessageHeader.Action,
-				SabreTokenParse(sess.BinSecTokCached),
-				time.Since(sess.TimeStarted).Minutes(),
-				time.Until(sess.ExpireTime).Minutes(),
-			)
-			//put session back on queue
-			countBadSessions(sess.OK, sess.ID, p.ConfigPoolSize)
-			p.Sessions <- sess
-		} else {
-			//put session back on queue
-			p.Sessions <- sess
-			logSession.Printf("ID-%s OK=%v VALIDATE-%s token=%s ExpiresIn=%.2f(mins)\n",
-				sess.ID,
-				sess.OK,
-				keepaliveID,
-				SabreTokenParse(sess.BinSecTokCached),
-				time.Until(sess.ExpireTime).Minutes(),
-			)
+			p.logger().Info("keepalive new session", LogKeySessionID, newSess.ID, "replaced", sess.ID, "ok", newSess.OK, "keepalive_id", keepaliveID, "token", SabreTokenParse(newSess.BinSecTokCached))
+			//kill sess::Session  already pulled off queue, GC will pick it up...
+			p.countBadSessions(newSess.OK)
+			p.requeue(newSess)
+			return
 		}
+		//reset expire, validated time, binary token (these shouldn't change but update anyway)
+		now = p.now()
+		sess.ExpireTime = p.expireTime(now)
+		sess.TimeValidated = now
+		sess.BinSecTokCached = validateRS.Header.Security.BinarySecurityToken.Value
+		p.logger().Info("keepalive validated", LogKeySessionID, sess.ID, "keepalive_id", keepaliveID, LogKeyAction, validateRS.Header.MessageHeader.Action, "token", SabreTokenParse(sess.BinSecTokCached), "alive_for", now.Sub(sess.TimeStarted), "expires_in", sess.ExpireTime.Sub(now))
+		//put session back on queue
+		p.countBadSessions(sess.OK)
+		p.requeue(sess)
+	} else {
+		//put session back on queue
+		p.requeue(sess)
+		p.logger().Debug("keepalive checked", LogKeySessionID, sess.ID, "ok", sess.OK, "keepalive_id", keepaliveID, "token", SabreTokenParse(sess.BinSecTokCached), "expires_in", sess.ExpireTime.Sub(now))
 	}
 }
 
-// generateKeepAliveID is an ID an easy to find and parse in the logs,
-// attached to every new call on Keepalive; returns format 'kid:tXury|0220-17:12'
-func generateKeepAliveID() string {
-	randStr := randStringBytesMaskImprSrc(5)
-	nowtime := time.Now().Format("0102-15:04")
-	return "kid:" + randStr + "|" + nowtime
-}
-
 /*
-Daemonize initializes and populates new session pool.
+Daemonize initializes and populates new session pool, runs Keepalive, and on
+one of the pool Signals runs Shutdown bounded by ShutdownTimeout.
 Accepts a waitgroup and variadic args signal handler for
 graceful shutdown sessions in a valid Sabre transaction.
+Servers that manage their own signals should call Populate, go Keepalive(),
+and Shutdown(ctx) directly instead.
 	Example:
 		func up(pool *srvc.SessionPool) {
 			var wg sync.WaitGroup
@@ -369,79 +595,58 @@ graceful shutdown sessions in a valid Sabre transaction.
 		}
 */
 func (p *SessionPool) Daemonize(wg *sync.WaitGroup) {
+	defer wg.Done()
 	//initialize the shutdown channel and notify for signals we care about
 	p.ShutDown = make(chan os.Signal, 1)
 	signal.Notify(p.ShutDown, p.Signals...)
+	defer signal.Stop(p.ShutDown)
 	//	signal.Notify(p.ShutDown, os.Interrupt, syscall.SIGTERM)
 
 	err := p.Populate()
 	if err != nil {
 		// let this play out... session pool should eventually self-heal
-		fmt.Printf("Error popluating session pool %v\n", err)
+		p.logger().Error("daemonize populate", LogKeyError, err)
 	}
 	//begin keepalive
-	p.Keepalive()
-	for {
-		<-p.ShutDown
-		wg.Done()
-		return
-	}
-}
+	go p.Keepalive()
+	sig := <-p.ShutDown
+	p.logger().Info("daemonize shutting down", "signal", sig.String())
 
-/*
-Promise allows us to puts a session into a number of channels that guarantee it will be closed. This is used in cases where its better to keep making subsequent requests to Sabre using the same session but accross different handlers, api endpoints, or html pages. There are three promise struct fields that allow us manage the Promise: PromisePut is boolean and allows the user to force put the session back into the pool, effectively ending the promise; the other two are automated with one acting as a timeout (e.g., user leaves browser window open, no more requests coming in on the api), while the other listens for shutdown signals on the pool and makes sure the channel is put back into the pool before Close() on the pool is called.; the last two are run in a goroutine
-NOTE: Promise is an advanced useage feature created specficially to run HOT* (hotel display), which must use the exact session meta data AND must guarantee no other session uses that meta-data. So we essentially lock out the channel associated with that meta-data for subsequent use. The danger here is that if you do not manage the Promise well you can exhaust you session pool, blocking all other requests until the Promise times out; do not do this.
-These are set on new session creation
-	sess.PromisePutAfter = time.Duration(5 * time.Minute)
-	sess.PromisePut = make(chan bool)
-	sess.PromiseSigListen = chan os.Signal
-*/
-func (sess *Session) Promise(p *SessionPool) {
-	msg := fmt.Sprintf(
-		"Promise-%s in (%.2f minutes) at '%s'",
-		sess.ID,
-		sess.PromisePutAfter.Minutes(),
-		time.Now().Add(sess.PromisePutAfter),
-	)
-	p.logReport(msg)
-
-	select {
-	case <-sess.PromisePut:
-		p.Sessions <- *sess
-		p.logReport("PromisePut-" + sess.ID)
-	//shutdown signal means session goes back for Close()
-	case <-sess.PromiseSigListen:
-		p.Sessions <- *sess
-		p.logReport("PromisePutSignal-" + sess.ID)
-		return
-	//time elapsed put back in to pool
-	case <-time.After(sess.PromisePutAfter):
-		p.Sessions <- *sess
-		p.logReport("PromisePutAfter-" + sess.ID)
+	timeout := p.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != nil {
+		p.logger().Error("daemonize shutdown", LogKeyError, err)
 	}
 }
 
 // Keepalive cycles through the pool periodically, running RangeKeepalive.
 // This means they are guaranteed to be valid and the pool to be correct size.
-// Listens for p.Shutdown, which is an os.Signal, on match will Close SessionPool and close(p.Shutdown) channel for program end.
+// Blocks until Shutdown is called, run it in a goroutine.
 func (p *SessionPool) Keepalive() {
-	started := time.Now()
-	keepAliveID := generateKeepAliveID()
-	logSession.Printf("Starting KEEPALIVE...%v refresh modulo: %d for total: %d", keepAliveID, p.refreshMod, len(p.Sessions))
+	quit, done := p.keepaliveChans()
+	defer close(done)
+	started := p.now()
+	p.setLastKeepalive()
+	keepAliveID := p.generateKeepAliveID()
+	p.logger().Info("keepalive started", "keepalive_id", keepAliveID, "refresh_mod", p.refreshMod, "size", len(p.Sessions))
 	p.logReport(keepAliveID + "-KeepAlive")
 	for {
 		select {
-		case <-time.After(p.CycleEvery):
-			NumberSessionPoolCycles++
+		case <-p.clock().After(p.CycleEvery):
+			p.cycles.Add(1)
 			p.RangeKeepalive(keepAliveID)
-			logSession.Printf("KEEPALIVE run(InMin=%.2f, InHour=%.2f)", time.Since(started).Minutes(), time.Since(started).Hours())
+			for _, l := range p.ReclaimOverdue() {
+				p.logger().Warn("keepalive reclaimed lease", LogKeySessionID, l.SessionID, "holder", l.Holder)
+			}
+			p.ScaleDown()
+			p.logger().Info("keepalive cycle", "keepalive_id", keepAliveID, "running", p.now().Sub(started))
 			p.logReport(keepAliveID + "-KeepAlive")
-		case <-p.ShutDown:
-			logSession.Println("KEEPALIVE shutdown, total lifetime:", time.Since(started))
-			fmt.Println("\n-> Deamonize -> Close")
-			p.Close() //close sabre sessions
-			fmt.Println("Close -> close(Shutdown)")
-			close(p.ShutDown) //shutdown session pool
+		case <-quit:
+			p.logger().Info("keepalive stopped", "keepalive_id", keepAliveID, "lifetime", p.now().Sub(started))
 			return
 		}
 	}
@@ -449,11 +654,12 @@ func (p *SessionPool) Keepalive() {
 
 // Close down all sessions gracefull and valid on Sabre.
 func (p *SessionPool) Close() {
-	p.NetworkErrors, p.FaultErrors = p.loopOverPool([]error{}, []error{})
-
-	logSession.Printf("Closing report... PoolSizeCounter=%d, Busy=%d, Queuesize=%d, NetworkErrors=%v,  FaultErrors=%v", p.PoolSizeCounter, (p.PoolSizeCounter - len(p.Sessions)), len(p.Sessions), p.NetworkErrors, p.FaultErrors)
+	networkErrors, faultErrors := p.loopOverPool([]error{}, []error{})
+	p.mu.Lock()
+	p.networkErrors, p.faultErrors = networkErrors, faultErrors
+	p.mu.Unlock()
 
-	logSession.Println("Close SessionPool complete")
+	p.logger().Info("pool closed", "size", p.PoolSizeCounter(), "busy", p.PoolSizeCounter()-len(p.Sessions), "queued", len(p.Sessions), "network_errors", len(networkErrors), "fault_errors", len(faultErrors))
 }
 
 // TODO refactor this so its easy to just close one session so we can recreate a new one...
@@ -464,24 +670,28 @@ func (p *SessionPool) loopOverPool(networkErrors, faultErrors []error) ([]error,
 		for sessChan := range p.Sessions {
 			//jsut make sure noting is holding on to a promise
 			closeRQ := BuildSessionCloseRequest(p.Conf, sessChan.BinSecTokCached)
-			closeRS, err := CallSessionClose(p.ServiceURL, closeRQ)
+			closeRS, err := CallSessionCloseContext(p.sessionContext(sessChan), p.ServiceURL, closeRQ)
 
 			if err != nil {
 				networkErrors = append(networkErrors, err)
+				p.setLastError(err)
 			}
 
 			fc := closeRS.Body.Fault.Code
 			if fc != "" {
 				st := closeRS.Body.Fault.Detail.StackTrace
 				fs := closeRS.Body.Fault.String
-				faultErrors = append(faultErrors, fmt.Errorf("%s-%s: %s", fs, fc, st))
+				faultErr := fmt.Errorf("%s-%s: %s", fs, fc, st)
+				faultErrors = append(faultErrors, faultErr)
+				p.setLastError(faultErr)
 			}
-			p.PoolSizeCounter--
-			logSession.Printf("ID-%s Close Status='%s' for token='%s'", sessChan.ID, closeRS.Body.SessionCloseRS.Status, SabreTokenParse(closeRS.Header.Security.BinarySecurityToken.Value))
+			size := p.poolSize.Add(-1)
+			releasePCCSession(p.Conf.PCC)
+			p.logger().Info("session closed", LogKeySessionID, sessChan.ID, LogKeyStatus, closeRS.Body.SessionCloseRS.Status, "token", SabreTokenParse(closeRS.Header.Security.BinarySecurityToken.Value))
 
 			//only after we close the actual number of sessions allocated
-			if p.PoolSizeCounter == 0 {
-				close(p.Sessions)
+			if size == 0 {
+				p.markClosed()
 			}
 		}
 	}
@@ -1,6 +1,9 @@
 package srvc
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 )
@@ -28,14 +31,14 @@ func TestSessionPoolNewCounters(t *testing.T) {
 	if p.ConfigPoolSize != poolSize {
 		t.Errorf("ConfigPoolSize should be same as user defined value. expect: %d, got: %d", poolSize, p.ConfigPoolSize)
 	}
-	if p.ConfigPoolSize == p.PoolSizeCounter {
-		t.Errorf("ConfigPoolSize: %d should equal PoolSizeCounter: %d", p.ConfigPoolSize, p.PoolSizeCounter)
+	if p.ConfigPoolSize == p.PoolSizeCounter() {
+		t.Errorf("ConfigPoolSize: %d should equal PoolSizeCounter: %d", p.ConfigPoolSize, p.PoolSizeCounter())
 	}
 	if len(p.Sessions) == poolSize {
 		t.Errorf("Sessions should not equal user defined value on NewPool(). expect: %d, got: %d", 0, len(p.Sessions))
 	}
-	if (p.PoolSizeCounter - len(p.Sessions)) != 0 {
-		t.Errorf("Open Sessions expect: %d, got: %d", 0, (p.PoolSizeCounter - len(p.Sessions)))
+	if (p.PoolSizeCounter() - len(p.Sessions)) != 0 {
+		t.Errorf("Open Sessions expect: %d, got: %d", 0, (p.PoolSizeCounter() - len(p.Sessions)))
 	}
 }
 
@@ -49,7 +52,7 @@ func TestSessionPoolZeroPoolSize(t *testing.T) {
 	if len(p.Sessions) != 0 {
 		t.Error("0 ConfigPoolSize should have 0 Sessions")
 	}
-	if p.PoolSizeCounter != 0 {
+	if p.PoolSizeCounter() != 0 {
 		t.Error("0 ConfigPoolSize should have 0 PoolSizeCounter")
 	}
 	sess := p.Pick()
@@ -66,28 +69,28 @@ func TestSessionPoolPopluateServerDown(t *testing.T) {
 	if err != nil {
 		t.Error("Bad Populate should not return error:", err)
 	}
-	if len(p.NetworkErrors) == 0 {
-		t.Error("Bad Populate should have network errors:", p.NetworkErrors)
+	if len(p.NetworkErrors()) == 0 {
+		t.Error("Bad Populate should have network errors:", p.NetworkErrors())
 	}
 	if len(p.Sessions) != poolSize {
 		t.Errorf("Expect %d sessions when server down, got (len.Sessions)=%d", 0, len(p.Sessions))
 	}
-	if NumberOfBadSessions != poolSize {
-		t.Errorf("NumberOfBadSessions expect: %d, got: %d", 0, NumberOfBadSessions)
+	if p.Stats().Bad != poolSize {
+		t.Errorf("Stats().Bad expect: %d, got: %d", poolSize, p.Stats().Bad)
 	}
-	if len(p.NetworkErrors) <= 0 {
-		t.Errorf("Expect NetworkErrors, got: %v", p.NetworkErrors)
+	if len(p.NetworkErrors()) <= 0 {
+		t.Errorf("Expect NetworkErrors, got: %v", p.NetworkErrors())
 	}
-	if p.PoolSizeCounter != poolSize {
-		t.Error("PoolSizeCounter should == poolSize since we expect them to heal:", p.PoolSizeCounter)
+	if p.PoolSizeCounter() != poolSize {
+		t.Error("PoolSizeCounter should == poolSize since we expect them to heal:", p.PoolSizeCounter())
 	}
 	if len(p.Sessions) != poolSize {
 		t.Error("SessionPool size should == poolSize since we expect them to heal:", len(p.Sessions))
 	}
 
 	sess := p.Pick()
-	if (p.PoolSizeCounter - len(p.Sessions)) != 1 {
-		t.Errorf("Sessions should be missing 1. expect: %d, got: %d", 1, (p.PoolSizeCounter - len(p.Sessions)))
+	if (p.PoolSizeCounter() - len(p.Sessions)) != 1 {
+		t.Errorf("Sessions should be missing 1. expect: %d, got: %d", 1, (p.PoolSizeCounter() - len(p.Sessions)))
 	}
 	if sess.ID == "" {
 		t.Errorf("Pick bad session from bad queue should still have id; expect: %s, got: %s", sess.ID, "")
@@ -108,10 +111,10 @@ func TestSessionPoolPopluateBadBody(t *testing.T) {
 	if err != nil {
 		t.Error("Bad Populate should not return error:", err)
 	}
-	if len(p.NetworkErrors) == 0 {
-		t.Error("Bad Populate should have network errors:", p.NetworkErrors)
+	if len(p.NetworkErrors()) == 0 {
+		t.Error("Bad Populate should have network errors:", p.NetworkErrors())
 	}
-	if p.PoolSizeCounter != poolSize {
+	if p.PoolSizeCounter() != poolSize {
 		t.Error("PoolSizeCounter should == poolSize since we expect them to heal")
 	}
 }
@@ -123,7 +126,7 @@ func TestSessionPoolCloseOnDownServer(t *testing.T) {
 	//reroute to unavailable server
 	p.ServiceURL = serverDown.URL
 	p.Close()
-	if len(p.NetworkErrors) <= 0 {
+	if len(p.NetworkErrors()) <= 0 {
 		t.Fail()
 	}
 }
@@ -133,10 +136,10 @@ func TestSessionPoolPopluateUnauth(t *testing.T) {
 	sampleSessionConf.ServiceURL = serverCreateRSUnauth.URL
 	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
 	_ = p.Populate()
-	if len(p.NetworkErrors) != 0 {
-		t.Error("Network errors should be 0:", p.NetworkErrors)
+	if len(p.NetworkErrors()) != 0 {
+		t.Error("Network errors should be 0:", p.NetworkErrors())
 	}
-	if p.PoolSizeCounter != poolSize {
+	if p.PoolSizeCounter() != poolSize {
 		t.Error("PoolSizeCounter should be more than zero even with SOAP Fault")
 	}
 	sess := p.Pick()
@@ -146,7 +149,7 @@ func TestSessionPoolPopluateUnauth(t *testing.T) {
 	if sess.FaultError.Error() != sampleSessionPoolMsgNoAuth {
 		t.Errorf("Session Soap Error should be nasty string. expect: %s, got: %s", sampleSessionPoolMsgNoAuth, sess.FaultError)
 	}
-	if len(p.FaultErrors) != poolSize {
+	if len(p.FaultErrors()) != poolSize {
 		t.Error("SessionPool fault errors shoudl exist")
 	}
 }
@@ -156,25 +159,25 @@ func TestSessionPoolCloseInvalidToken(t *testing.T) {
 	sampleSessionConf.ServiceURL = serverCreateRQ.URL
 	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
 	_ = p.Populate()
-	if len(p.NetworkErrors) != 0 {
-		t.Error("Network errors should be 0:", p.NetworkErrors)
+	if len(p.NetworkErrors()) != 0 {
+		t.Error("Network errors should be 0:", p.NetworkErrors())
 	}
-	if p.PoolSizeCounter != poolSize {
+	if p.PoolSizeCounter() != poolSize {
 		t.Error("PoolSizeCounter should be more than zero even with SOAP Fault")
 	}
 	//reroute serivce to server with close invalid response...
 	p.ServiceURL = serverCloseRSInvalid.URL
 	p.Close()
-	if len(p.FaultErrors) != poolSize {
-		t.Error("FaultErrors should exist:", p.FaultErrors)
+	if len(p.FaultErrors()) != poolSize {
+		t.Error("FaultErrors should exist:", p.FaultErrors())
 	}
-	if len(p.NetworkErrors) != 0 {
+	if len(p.NetworkErrors()) != 0 {
 		t.Error("NetworkErrors should not exist")
 	}
-	if p.PoolSizeCounter != 0 {
+	if p.PoolSizeCounter() != 0 {
 		t.Error("PoolSizeCounter should be more than zero even with SOAP Fault")
 	}
-	if len(p.FaultErrors) != poolSize {
+	if len(p.FaultErrors()) != poolSize {
 		t.Error("SessionPool fault errors shoudl exist")
 	}
 }
@@ -184,8 +187,8 @@ func TestSessionPoolPopluatePickPut(t *testing.T) {
 	sampleSessionConf.ServiceURL = serverCreateRQ.URL
 	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
 	_ = p.Populate()
-	if poolSize != p.PoolSizeCounter {
-		t.Errorf("given poolSize: %d should equal PoolSizeCounter on Populate: %d", poolSize, p.PoolSizeCounter)
+	if poolSize != p.PoolSizeCounter() {
+		t.Errorf("given poolSize: %d should equal PoolSizeCounter on Populate: %d", poolSize, p.PoolSizeCounter())
 	}
 	if len(p.Sessions) != poolSize {
 		t.Errorf("Sessions expect: %d, got: %d", poolSize, len(p.Sessions))
@@ -198,23 +201,23 @@ func TestSessionPoolPopluatePickPut(t *testing.T) {
 		if len(p.Sessions) != expectOpen {
 			t.Errorf("Open Sessions after Pick() expect: %d, got: %d", expectOpen, len(p.Sessions))
 		}
-		if p.PoolSizeCounter != poolSize {
-			t.Errorf("AoowPoolSize after Pick() expect: %d, got: %d", poolSize, p.PoolSizeCounter)
+		if p.PoolSizeCounter() != poolSize {
+			t.Errorf("AoowPoolSize after Pick() expect: %d, got: %d", poolSize, p.PoolSizeCounter())
 		}
-		if (p.PoolSizeCounter - len(p.Sessions)) != i {
-			t.Errorf("Busy Sessions after Pick() expect: %d, got: %d", i, (p.PoolSizeCounter - len(p.Sessions)))
+		if (p.PoolSizeCounter() - len(p.Sessions)) != i {
+			t.Errorf("Busy Sessions after Pick() expect: %d, got: %d", i, (p.PoolSizeCounter() - len(p.Sessions)))
 		}
 	}
 	for num, sess := range sessions {
 		if len(p.Sessions) != num {
 			t.Errorf("Open Sessions for Put(sess) expect: %d, got: %d", num, len(p.Sessions))
 		}
-		if p.PoolSizeCounter != poolSize {
-			t.Errorf("PoolSizeCounter for Put(sess) expect: %d, got: %d", poolSize, p.PoolSizeCounter)
+		if p.PoolSizeCounter() != poolSize {
+			t.Errorf("PoolSizeCounter for Put(sess) expect: %d, got: %d", poolSize, p.PoolSizeCounter())
 		}
 		expectBusy := len(sessions) - num
-		if (p.PoolSizeCounter - len(p.Sessions)) != expectBusy {
-			t.Errorf("Busy Sessions for Put(sess) expect: %d, got: %d", expectBusy, (p.PoolSizeCounter - len(p.Sessions)))
+		if (p.PoolSizeCounter() - len(p.Sessions)) != expectBusy {
+			t.Errorf("Busy Sessions for Put(sess) expect: %d, got: %d", expectBusy, (p.PoolSizeCounter() - len(p.Sessions)))
 		}
 		p.Put(sess)
 	}
@@ -231,18 +234,18 @@ func TestSessionPoolBlocking(t *testing.T) {
 		sessions = append(sessions, p.Pick())
 		expectOpen := (poolSize - i)
 		if len(p.Sessions) != expectOpen {
-			t.Errorf("Open Sessions after Pick() expect: %d, got: %d", expectOpen, (p.PoolSizeCounter - len(p.Sessions)))
+			t.Errorf("Open Sessions after Pick() expect: %d, got: %d", expectOpen, (p.PoolSizeCounter() - len(p.Sessions)))
 		}
-		if p.PoolSizeCounter != poolSize {
-			t.Errorf("PoolSizeCounter after Put(sess) expect: %d, got: %d", poolSize, p.PoolSizeCounter)
+		if p.PoolSizeCounter() != poolSize {
+			t.Errorf("PoolSizeCounter after Put(sess) expect: %d, got: %d", poolSize, p.PoolSizeCounter())
 		}
 	}
 
 	// setup blocking requests to the session pool
-	bg := []Session{}
-	go func(b []Session, pool *SessionPool) {
+	bg := make(chan Session, blockingSize)
+	go func(b chan Session, pool *SessionPool) {
 		for i := 1; i <= blockingSize; i++ {
-			bg = append(bg, pool.Pick())
+			b <- pool.Pick()
 		}
 	}(bg, p)
 
@@ -250,8 +253,8 @@ func TestSessionPoolBlocking(t *testing.T) {
 	if len(p.Sessions) != 0 {
 		t.Errorf("Open Sessions after all Pick() and we have blocking requests expect: %d, got: %d", 0, len(p.Sessions))
 	}
-	if (p.PoolSizeCounter - len(p.Sessions)) != poolSize {
-		t.Errorf("Busy Sessions after all Pick() and we have blocking requests expect: %d, got: %d", poolSize, (p.PoolSizeCounter - len(p.Sessions)))
+	if (p.PoolSizeCounter() - len(p.Sessions)) != poolSize {
+		t.Errorf("Busy Sessions after all Pick() and we have blocking requests expect: %d, got: %d", poolSize, (p.PoolSizeCounter() - len(p.Sessions)))
 	}
 
 	//put back busy sessions with requests waiting
@@ -268,37 +271,37 @@ func TestSessionPoolBlocking(t *testing.T) {
 		t.Errorf("Open Sessions after busy Put() back and blocking requests handled: %d, got: %d", 2, len(p.Sessions))
 	}
 	// expect all to still be busy
-	if (p.PoolSizeCounter - len(p.Sessions)) != blockingSize {
-		t.Errorf("Busy Sessions after busy Put() back and blocking requests handled: %d, got: %d", blockingSize, (p.PoolSizeCounter - len(p.Sessions)))
+	if (p.PoolSizeCounter() - len(p.Sessions)) != blockingSize {
+		t.Errorf("Busy Sessions after busy Put() back and blocking requests handled: %d, got: %d", blockingSize, (p.PoolSizeCounter() - len(p.Sessions)))
 	}
-	if p.PoolSizeCounter != poolSize {
-		t.Errorf("PoolSizeCounter after handling blocked requests expect: %d, got: %d", poolSize, p.PoolSizeCounter)
+	if p.PoolSizeCounter() != poolSize {
+		t.Errorf("PoolSizeCounter after handling blocked requests expect: %d, got: %d", poolSize, p.PoolSizeCounter())
 	}
 	if len(bg) != blockingSize {
 		t.Errorf("Waiting requests have now been handled, should be same number as blockingSize expect: %d, got: %d", blockingSize, len(bg))
 	}
 
 	//put back busy backgrounded sessions
-	for _, bgsess := range bg {
-		p.Put(bgsess)
+	for i := 1; i <= blockingSize; i++ {
+		p.Put(<-bg)
 	}
 	if len(p.Sessions) != poolSize {
 		t.Errorf("Open Sessions after all requests handled: %d, got: %d", poolSize, len(p.Sessions))
 	}
 	// expect all to still be busy
-	if (p.PoolSizeCounter - len(p.Sessions)) != 0 {
-		t.Errorf("Busy Sessions after all requests handled: %d, got: %d", 0, (p.PoolSizeCounter - len(p.Sessions)))
+	if (p.PoolSizeCounter() - len(p.Sessions)) != 0 {
+		t.Errorf("Busy Sessions after all requests handled: %d, got: %d", 0, (p.PoolSizeCounter() - len(p.Sessions)))
 	}
-	if p.PoolSizeCounter != poolSize {
-		t.Errorf("PoolSizeCounter after all requests handled: %d, got: %d", poolSize, p.PoolSizeCounter)
+	if p.PoolSizeCounter() != poolSize {
+		t.Errorf("PoolSizeCounter after all requests handled: %d, got: %d", poolSize, p.PoolSizeCounter())
 	}
 }
 
 func TestSessionPoolSafeBlocking(t *testing.T) {
 	poolSize := 3
 	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
-	if p.ConfigPoolSize == p.PoolSizeCounter {
-		t.Errorf("ConfigPoolSize: %d not equal PoolSizeCounter: %d", p.ConfigPoolSize, p.PoolSizeCounter)
+	if p.ConfigPoolSize == p.PoolSizeCounter() {
+		t.Errorf("ConfigPoolSize: %d not equal PoolSizeCounter: %d", p.ConfigPoolSize, p.PoolSizeCounter())
 	}
 
 	p.ServiceURL = serverDown.URL
@@ -306,19 +309,19 @@ func TestSessionPoolSafeBlocking(t *testing.T) {
 	if err != nil {
 		t.Error("Populate pool with sessions from down server should not return error", err)
 	}
-	if len(p.NetworkErrors) == 0 {
-		t.Error("Network errors should exist:", p.NetworkErrors)
+	if len(p.NetworkErrors()) == 0 {
+		t.Error("Network errors should exist:", p.NetworkErrors())
 	}
-	if p.ConfigPoolSize != p.PoolSizeCounter {
-		t.Errorf("ConfigPoolSize: %d not equal PoolSizeCounter: %d AFTER server goes down", p.ConfigPoolSize, p.PoolSizeCounter)
+	if p.ConfigPoolSize != p.PoolSizeCounter() {
+		t.Errorf("ConfigPoolSize: %d not equal PoolSizeCounter: %d AFTER server goes down", p.ConfigPoolSize, p.PoolSizeCounter())
 	}
-	if NumberOfBadSessions != poolSize {
-		t.Errorf("NumberOfBadSessions expect: %d, got: %d", 0, NumberOfBadSessions)
+	if p.Stats().Bad != poolSize {
+		t.Errorf("Stats().Bad expect: %d, got: %d", poolSize, p.Stats().Bad)
 	}
 
 	sess := p.Pick()
-	if NumberOfBadSessions != poolSize {
-		t.Errorf("NumberOfBadSessions expect: %d, got: %d", 0, NumberOfBadSessions)
+	if p.Stats().Bad != poolSize {
+		t.Errorf("Stats().Bad expect: %d, got: %d", poolSize, p.Stats().Bad)
 	}
 	// if no safe blocking this will never be reached
 	// fact that you get here is a success for safe blocking!
@@ -326,8 +329,8 @@ func TestSessionPoolSafeBlocking(t *testing.T) {
 	if sess == s {
 		t.Error("Bad Session should not be empty:", sess)
 	}
-	if (p.PoolSizeCounter - len(p.Sessions)) != 1 {
-		t.Errorf("Sessions should be missing 1. expect: %d, got: %d", 1, (p.PoolSizeCounter - len(p.Sessions)))
+	if (p.PoolSizeCounter() - len(p.Sessions)) != 1 {
+		t.Errorf("Sessions should be missing 1. expect: %d, got: %d", 1, (p.PoolSizeCounter() - len(p.Sessions)))
 	}
 	if sess.ID == "" {
 		t.Errorf("Pick bad session from bad queue should still have id; expect: %s, got: %s", sess.ID, "")
@@ -340,10 +343,220 @@ func TestSessionPoolSafeBlocking(t *testing.T) {
 	}
 
 	p.Put(sess)
-	if (p.PoolSizeCounter - len(p.Sessions)) != 0 {
-		t.Errorf("PoolSizeCounter and Sessions should be same. expect: %d, got: %d", 0, (p.PoolSizeCounter - len(p.Sessions)))
+	if (p.PoolSizeCounter() - len(p.Sessions)) != 0 {
+		t.Errorf("PoolSizeCounter and Sessions should be same. expect: %d, got: %d", 0, (p.PoolSizeCounter() - len(p.Sessions)))
 	}
-	if NumberOfBadSessions != poolSize {
-		t.Errorf("NumberOfBadSessions expect: %d, got: %d", 0, NumberOfBadSessions)
+	if p.Stats().Bad != poolSize {
+		t.Errorf("Stats().Bad expect: %d, got: %d", poolSize, p.Stats().Bad)
 	}
 }
+
+func TestSessionPoolStatsPerPool(t *testing.T) {
+	poolSize := 2
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	good := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = good.Populate()
+	sampleSessionConf.ServiceURL = serverDown.URL
+	bad := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = bad.Populate()
+
+	st := good.Stats()
+	if st.Bad != 0 {
+		t.Errorf("good Stats().Bad expect: %d, got: %d", 0, st.Bad)
+	}
+	if st.Open != poolSize {
+		t.Errorf("good Stats().Open expect: %d, got: %d", poolSize, st.Open)
+	}
+	if st.LastError != nil {
+		t.Error("good Stats().LastError should be nil:", st.LastError)
+	}
+	st = bad.Stats()
+	if st.Bad != poolSize {
+		t.Errorf("bad Stats().Bad expect: %d, got: %d", poolSize, st.Bad)
+	}
+	if st.Open != 0 {
+		t.Errorf("bad Stats().Open expect: %d, got: %d", 0, st.Open)
+	}
+	if st.NetworkErrors != poolSize {
+		t.Errorf("bad Stats().NetworkErrors expect: %d, got: %d", poolSize, st.NetworkErrors)
+	}
+	if st.LastError == nil || st.LastErrorTime.IsZero() {
+		t.Error("bad Stats().LastError should be set")
+	}
+
+	sess := good.Pick()
+	if good.Stats().Leased != 1 {
+		t.Errorf("Stats().Leased after Pick() expect: %d, got: %d", 1, good.Stats().Leased)
+	}
+	if bad.Stats().Leased != 0 {
+		t.Errorf("other pool Stats().Leased expect: %d, got: %d", 0, bad.Stats().Leased)
+	}
+	good.Put(sess)
+	if good.Stats().Leased != 0 {
+		t.Errorf("Stats().Leased after Put() expect: %d, got: %d", 0, good.Stats().Leased)
+	}
+}
+
+func TestSessionPoolConcurrentStats(t *testing.T) {
+	poolSize := 3
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = p.Populate()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			p.Put(p.Pick())
+		}()
+		go func() {
+			defer wg.Done()
+			_ = p.Stats()
+			_ = p.NetworkErrors()
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.cycles.Add(1)
+		p.RangeKeepalive("kid:test")
+	}()
+	wg.Wait()
+
+	st := p.Stats()
+	if st.Leased != 0 {
+		t.Errorf("Stats().Leased expect: %d, got: %d", 0, st.Leased)
+	}
+	if st.Counted != poolSize {
+		t.Errorf("Stats().Counted expect: %d, got: %d", poolSize, st.Counted)
+	}
+	if st.Cycles != 1 {
+		t.Errorf("Stats().Cycles expect: %d, got: %d", 1, st.Cycles)
+	}
+}
+
+// serverValidateSlow answers session validate after delay, simulating slow Sabre during keepalive
+func serverValidateSlow(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				time.Sleep(delay)
+				_, _ = rs.Write(sampleSessionValidateRespSuccess)
+			},
+		),
+	)
+}
+
+// expirePool marks every queued session as expired so keepalive validates each of them
+func expirePool(p *SessionPool) {
+	for i := len(p.Sessions); i > 0; i-- {
+		sess := <-p.Sessions
+		sess.ExpireTime = time.Now().Add(-time.Minute)
+		p.Sessions <- sess
+	}
+}
+
+func TestSessionPoolRangeKeepaliveNonBlocking(t *testing.T) {
+	poolSize := 3
+	delay := 150 * time.Millisecond
+	slow := serverValidateSlow(delay)
+	defer slow.Close()
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = p.Populate()
+	p.ServiceURL = slow.URL
+	expirePool(p)
+
+	done := make(chan struct{})
+	go func() {
+		p.RangeKeepalive("kid:test")
+		close(done)
+	}()
+	//keepalive holds one session at a time, the rest are available
+	time.Sleep(delay / 3)
+	if len(p.Sessions) != poolSize-1 {
+		t.Errorf("Sessions during keepalive expect: %d, got: %d", poolSize-1, len(p.Sessions))
+	}
+	started := time.Now()
+	sess := p.Pick()
+	if time.Since(started) > delay/2 {
+		t.Errorf("Pick during keepalive should not wait on validate, took: %v", time.Since(started))
+	}
+	p.Put(sess)
+	<-done
+
+	if len(p.Sessions) != poolSize {
+		t.Errorf("Sessions after keepalive expect: %d, got: %d", poolSize, len(p.Sessions))
+	}
+	for i := 0; i < poolSize; i++ {
+		sess := p.Pick()
+		if !sess.ExpireTime.After(time.Now()) {
+			t.Errorf("ID-%s should have new ExpireTime after keepalive, got: %v", sess.ID, sess.ExpireTime)
+		}
+		defer p.Put(sess)
+	}
+}
+
+func TestSessionPoolRangeKeepaliveAllLeased(t *testing.T) {
+	poolSize := 2
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = p.Populate()
+	sessions := []Session{p.Pick(), p.Pick()}
+
+	done := make(chan struct{})
+	go func() {
+		p.RangeKeepalive("kid:test")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RangeKeepalive should not block when all sessions are leased")
+	}
+	for _, sess := range sessions {
+		p.Put(sess)
+	}
+}
+
+func BenchmarkSessionPoolRangeKeepaliveNonBlocking(b *testing.B) {
+	poolSize := 5
+	slow := serverValidateSlow(20 * time.Millisecond)
+	defer slow.Close()
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, poolSize)
+	_ = p.Populate()
+	p.ServiceURL = slow.URL
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				expirePool(p)
+				p.RangeKeepalive("kid:bench")
+			}
+		}
+	}()
+
+	var worst time.Duration
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		started := time.Now()
+		sess := p.Pick()
+		if d := time.Since(started); d > worst {
+			worst = d
+		}
+		p.Put(sess)
+	}
+	b.StopTimer()
+	close(stop)
+	wg.Wait()
+	b.ReportMetric(float64(worst.Microseconds()), "max-pick-us")
+}
@@ -0,0 +1,171 @@
+package srvc
+
+import (
+	"sync"
+	"time"
+)
+
+// ScaleScheme bounds and thresholds for sizing the pool on demand. The zero value keeps the pool fixed at ConfigPoolSize.
+type ScaleScheme struct {
+	Min         int           //never scale below, defaults to ConfigPoolSize
+	Max         int           //never scale above, capacity of Sessions; defaults to ConfigPoolSize
+	Step        int           //sessions added per scale up, defaults to 1
+	PickWait    time.Duration //scale up when a Pick waits this long for a session, 0 never
+	Utilization float64       //scale up when leased/allocated sessions reach this ratio (e.g., 0.8), 0 never
+	IdleAfter   time.Duration //keepalive closes sessions not used for this long, down to Min; 0 never
+}
+
+// pccLimits caps sessions open per PCC across every pool in the process, see SetPCCSessionLimit.
+var pccLimits = struct {
+	sync.Mutex
+	limit map[string]int
+	open  map[string]int
+}{
+	limit: make(map[string]int),
+	open:  make(map[string]int),
+}
+
+// SetPCCSessionLimit caps the number of sessions all pools in this process may hold open for pcc; Sabre enforces a session limit per PCC and sessions over it fail to create. Zero removes the cap.
+func SetPCCSessionLimit(pcc string, limit int) {
+	pccLimits.Lock()
+	defer pccLimits.Unlock()
+	if limit <= 0 {
+		delete(pccLimits.limit, pcc)
+		return
+	}
+	pccLimits.limit[pcc] = limit
+}
+
+// acquirePCCSession counts one more open session for pcc, false when the pcc limit is reached.
+func acquirePCCSession(pcc string) bool {
+	pccLimits.Lock()
+	defer pccLimits.Unlock()
+	if limit, ok := pccLimits.limit[pcc]; ok && pccLimits.open[pcc] >= limit {
+		return false
+	}
+	pccLimits.open[pcc]++
+	return true
+}
+
+// releasePCCSession counts one less open session for pcc.
+func releasePCCSession(pcc string) {
+	pccLimits.Lock()
+	defer pccLimits.Unlock()
+	if pccLimits.open[pcc] > 0 {
+		pccLimits.open[pcc]--
+	}
+}
+
+// minSize lower bound of the pool.
+func (p *SessionPool) minSize() int {
+	if p.Scale.Min > 0 {
+		return p.Scale.Min
+	}
+	return p.ConfigPoolSize
+}
+
+// maxSize upper bound of the pool and capacity of the Sessions channel.
+func (p *SessionPool) maxSize() int {
+	if p.Scale.Max > p.ConfigPoolSize {
+		return p.Scale.Max
+	}
+	return p.ConfigPoolSize
+}
+
+// canScaleUp true while the pool is below its upper bound.
+func (p *SessionPool) canScaleUp() bool {
+	return p.PoolSizeCounter() < p.maxSize()
+}
+
+// scaleUpFor adds Scale.Step sessions in the background, one scale up at a time.
+func (p *SessionPool) scaleUpFor(reason string) {
+	if !p.canScaleUp() || !p.scaling.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer p.scaling.Store(false)
+		step := p.Scale.Step
+		if step < 1 {
+			step = 1
+		}
+		added := p.ScaleUp(step)
+		p.logReport("ScaleUp-" + reason)
+		p.logger().Info("scaled up", "reason", reason, "added", added, "size", p.PoolSizeCounter(), "max", p.maxSize())
+	}()
+}
+
+// checkUtilization scales up when leased sessions reach Scale.Utilization of the pool.
+func (p *SessionPool) checkUtilization() {
+	if p.Scale.Utilization <= 0 {
+		return
+	}
+	st := p.Stats()
+	if st.Counted > 0 && float64(st.Leased)/float64(st.Counted) >= p.Scale.Utilization {
+		p.scaleUpFor("Utilization")
+	}
+}
+
+// ScaleUp adds up to n newly created sessions to the pool without going over Scale.Max or the PCC session limit; returns the number added.
+func (p *SessionPool) ScaleUp(n int) int {
+	added := 0
+	for added < n {
+		size := p.poolSize.Load()
+		if int(size) >= p.maxSize() {
+			break
+		}
+		if !p.poolSize.CompareAndSwap(size, size+1) {
+			continue
+		}
+		if !acquirePCCSession(p.Conf.PCC) {
+			p.poolSize.Add(-1)
+			p.logger().Warn("scale up stopped, session limit reached for PCC", "pcc", p.Conf.PCC)
+			break
+		}
+		sess, err := p.newSession()
+		if err != nil {
+			p.logger().Error("scale up, adding bad session for keepalive to heal", LogKeyError, err)
+		}
+		if !p.enqueue(sess) {
+			p.poolSize.Add(-1)
+			releasePCCSession(p.Conf.PCC)
+			p.closeSession(sess)
+			break
+		}
+		added++
+	}
+	return added
+}
+
+// ScaleDown closes sessions sitting in the pool unused for Scale.IdleAfter, never going below Scale.Min; returns the number closed. Keepalive runs it every cycle.
+func (p *SessionPool) ScaleDown() int {
+	if p.Scale.IdleAfter <= 0 {
+		return 0
+	}
+	closed := 0
+	for i, n := 0, len(p.Sessions); i < n; i++ {
+		if p.PoolSizeCounter() <= p.minSize() {
+			break
+		}
+		var sess Session
+		select {
+		case sess = <-p.Sessions:
+		default:
+			return closed
+		}
+		if p.now().Sub(sess.TimeLastUsed) < p.Scale.IdleAfter {
+			p.requeue(sess)
+			continue
+		}
+		p.poolSize.Add(-1)
+		releasePCCSession(p.Conf.PCC)
+		if !sess.OK {
+			p.countBadSessions(true)
+		}
+		p.closeSession(sess)
+		closed++
+	}
+	if closed > 0 {
+		p.logger().Info("scaled down", "closed", closed, "size", p.PoolSizeCounter(), "min", p.minSize())
+	}
+	return closed
+}
@@ -0,0 +1,118 @@
+package srvc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// eventually polls cond until it is true or a second has passed
+func eventually(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return cond()
+}
+
+func TestSessionPoolScaleUpPickWait(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	p.Scale = ScaleScheme{Max: 3, PickWait: 10 * time.Millisecond}
+	_ = p.Populate()
+	if cap(p.Sessions) != 3 {
+		t.Errorf("Sessions capacity expect: %d, got: %d", 3, cap(p.Sessions))
+	}
+
+	first := p.Pick()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	second, err := p.PickContext(ctx)
+	if err != nil {
+		t.Fatal("Waiting Pick should scale up pool", err)
+	}
+	if p.PoolSizeCounter() != 2 {
+		t.Errorf("PoolSizeCounter after scale up expect: %d, got: %d", 2, p.PoolSizeCounter())
+	}
+	st := p.Stats()
+	if st.Min != 1 || st.Max != 3 {
+		t.Errorf("Stats Min/Max expect: %d/%d, got: %d/%d", 1, 3, st.Min, st.Max)
+	}
+	p.Put(first)
+	p.Put(second)
+}
+
+func TestSessionPoolScaleUpUtilization(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 2)
+	p.Scale = ScaleScheme{Max: 4, Step: 2, Utilization: 0.5}
+	_ = p.Populate()
+
+	sess := p.Pick()
+	if !eventually(func() bool { return len(p.Sessions) == 3 }) {
+		t.Errorf("Sessions after utilization scale up expect: %d, got: %d", 3, len(p.Sessions))
+	}
+	if p.PoolSizeCounter() != 4 {
+		t.Errorf("PoolSizeCounter after utilization scale up expect: %d, got: %d", 4, p.PoolSizeCounter())
+	}
+	p.Put(sess)
+	if p.ScaleUp(1) != 0 {
+		t.Error("ScaleUp should not go over Scale.Max")
+	}
+}
+
+func TestSessionPoolScaleDown(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	p.Scale = ScaleScheme{Max: 3, IdleAfter: 50 * time.Millisecond}
+	_ = p.Populate()
+	if added := p.ScaleUp(2); added != 2 {
+		t.Fatalf("ScaleUp expect: %d, got: %d", 2, added)
+	}
+	if p.ScaleDown() != 0 {
+		t.Error("ScaleDown should not close sessions used within IdleAfter")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	busy := p.Pick()
+	p.Put(busy)
+	closed := p.ScaleDown()
+	if closed != 2 {
+		t.Errorf("ScaleDown closed expect: %d, got: %d", 2, closed)
+	}
+	if p.PoolSizeCounter() != 1 || len(p.Sessions) != 1 {
+		t.Errorf("Pool size after ScaleDown expect: %d, got: %d/%d", 1, p.PoolSizeCounter(), len(p.Sessions))
+	}
+	if sess := p.Pick(); sess.ID != busy.ID {
+		t.Errorf("ScaleDown should keep recently used session expect: %s, got: %s", busy.ID, sess.ID)
+	}
+}
+
+func TestSessionPoolPCCSessionLimit(t *testing.T) {
+	conf := *sampleSessionConf
+	conf.PCC = "LIMT"
+	conf.ServiceURL = serverCreateRQ.URL
+	SetPCCSessionLimit(conf.PCC, 2)
+	defer SetPCCSessionLimit(conf.PCC, 0)
+
+	p := NewPool(sampleExpireScheme, &conf, cycleEvery, 1)
+	p.Scale = ScaleScheme{Max: 5}
+	_ = p.Populate()
+	if added := p.ScaleUp(4); added != 1 {
+		t.Errorf("ScaleUp within PCC limit expect: %d, got: %d", 1, added)
+	}
+	other := NewPool(sampleExpireScheme, &conf, cycleEvery, 2)
+	_ = other.Populate()
+	if other.PoolSizeCounter() != 0 {
+		t.Errorf("Populate over PCC limit expect: %d, got: %d", 0, other.PoolSizeCounter())
+	}
+
+	p.Close()
+	if added := other.ScaleUp(2); added != 2 {
+		t.Errorf("ScaleUp after other pool closed expect: %d, got: %d", 2, added)
+	}
+	other.Close()
+}
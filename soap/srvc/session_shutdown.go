@@ -0,0 +1,148 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultShutdownTimeout bounds Shutdown in Daemonize when SessionPool.ShutdownTimeout is not set.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ErrPoolClosed is returned when picking from a pool that is shutting down or closed.
+var ErrPoolClosed = errors.New("session pool closed")
+
+// keepaliveChans returns the channel Shutdown closes to stop keepalive and a new channel keepalive closes when it returns.
+func (p *SessionPool) keepaliveChans() (quit, done chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.quit == nil {
+		p.quit = make(chan struct{})
+	}
+	p.keepaliveDone = make(chan struct{})
+	return p.quit, p.keepaliveDone
+}
+
+// enqueue sess unless Sessions was closed; false means sess is no longer part of the pool.
+func (p *SessionPool) enqueue(sess Session) bool {
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+	if p.closed {
+		return false
+	}
+	p.Sessions <- sess
+	return true
+}
+
+// requeue sess after keepalive or scaling; if the pool was closed meanwhile sess is closed on Sabre.
+func (p *SessionPool) requeue(sess Session) {
+	if !p.enqueue(sess) {
+		p.closeSession(sess)
+	}
+}
+
+// markClosed closes Sessions, sessions sent afterwards are not queued (see enqueue).
+func (p *SessionPool) markClosed() {
+	p.closeMu.Lock()
+	defer p.closeMu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.Sessions)
+	}
+}
+
+/*
+Shutdown gracefully shuts the pool down, it is safe to call from servers that manage their own signals:
+ 1. stops keepalive, waiting for a running cycle to finish
+ 2. waits for leased sessions (Pick, Do, Lease) to be put back
+ 3. closes every session on Sabre: queued, and leased ones that did not return in time, and reclaimed ones (see ReclaimOverdue) never put back; with a Store queued sessions are persisted instead, see Populate
+
+Waiting is bounded by ctx, closing is not; a nil error means all leases returned. Picks fail with ErrPoolClosed once Shutdown is called, leased sessions put back afterwards are dropped.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	_ = server.Shutdown(ctx)
+	err := pool.Shutdown(ctx)
+*/
+func (p *SessionPool) Shutdown(ctx context.Context) error {
+	if !p.stopping.CompareAndSwap(false, true) {
+		return ErrPoolClosed
+	}
+	started := time.Now()
+
+	//1. stop keepalive
+	p.mu.Lock()
+	if p.quit == nil {
+		p.quit = make(chan struct{})
+	}
+	close(p.quit)
+	done := p.keepaliveDone
+	p.mu.Unlock()
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+
+	//2. wait for leases
+	var err error
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	for p.Stats().Leased > 0 && err == nil {
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			err = fmt.Errorf("shutdown with %d sessions leased: %w", p.Stats().Leased, ctx.Err())
+		}
+	}
+
+	//3. close everything Sabre knows about
+	p.closeMu.Lock()
+	queued := []Session{}
+	if !p.closed && p.Sessions != nil {
+		for len(p.Sessions) > 0 {
+			queued = append(queued, <-p.Sessions)
+		}
+		close(p.Sessions)
+	}
+	p.closed = true
+	p.closeMu.Unlock()
+
+	p.mu.Lock()
+	for id, l := range p.sticky {
+		l.mu.Lock()
+		l.done = true
+		l.timer.Stop()
+		l.mu.Unlock()
+		delete(p.sticky, id)
+	}
+	leased := []Session{}
+	for id, l := range p.leases {
+		leased = append(leased, l.session)
+		delete(p.leases, id)
+	}
+	reclaimed := []Session{}
+	for id, sess := range p.reclaimed {
+		reclaimed = append(reclaimed, sess)
+		delete(p.reclaimed, id)
+	}
+	p.mu.Unlock()
+
+	closing := queued
+	if p.Store != nil {
+		closing = p.persistSessions(queued)
+	}
+	for _, sess := range append(closing, leased...) {
+		p.closeSession(sess)
+		p.poolSize.Add(-1)
+		releasePCCSession(p.Conf.PCC)
+	}
+	//reclaimed sessions never put back, not counted in the pool size
+	for _, sess := range reclaimed {
+		p.closeReclaimed(sess)
+	}
+	p.logger().Info("pool shutdown", "closed_queued", len(closing), "closed_leased", len(leased), "closed_reclaimed", len(reclaimed), LogKeyDuration, time.Since(started), LogKeyError, err)
+	return err
+}
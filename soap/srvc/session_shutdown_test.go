@@ -0,0 +1,102 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionPoolShutdownWaitsForLease(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 2)
+	_ = p.Populate()
+	go p.Keepalive()
+
+	sess := p.Pick()
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		p.Put(sess)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown expect: %v, got: %v", nil, err)
+	}
+	st := p.Stats()
+	if st.Counted != 0 || st.Leased != 0 {
+		t.Errorf("Shutdown Counted and Leased expect: 0 0, got: %d %d", st.Counted, st.Leased)
+	}
+	_, err := p.PickContext(context.Background())
+	if !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("PickContext after Shutdown expect: %v, got: %v", ErrPoolClosed, err)
+	}
+	if err := p.Shutdown(ctx); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Shutdown twice expect: %v, got: %v", ErrPoolClosed, err)
+	}
+}
+
+func TestSessionPoolShutdownDeadline(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 3)
+	_ = p.Populate()
+
+	held := p.Pick()
+	lease, err := p.Lease(context.Background(), time.Minute)
+	if err != nil {
+		t.Fatal("Lease should not return error", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = p.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown with leased sessions expect: %v, got: %v", context.DeadlineExceeded, err)
+	}
+	st := p.Stats()
+	if st.Counted != 0 || st.Leased != 0 {
+		t.Errorf("Shutdown Counted and Leased expect: 0 0, got: %d %d", st.Counted, st.Leased)
+	}
+	if _, ok := p.LookupLease(lease.ID); ok {
+		t.Error("LookupLease after Shutdown should not find lease")
+	}
+	if err := lease.Extend(time.Minute); !errors.Is(err, ErrLeaseReleased) {
+		t.Errorf("Extend after Shutdown expect: %v, got: %v", ErrLeaseReleased, err)
+	}
+
+	//late returns must not panic or count again
+	p.Put(held)
+	lease.Release()
+	if p.PoolSizeCounter() != 0 {
+		t.Errorf("PoolSizeCounter after late Put expect: %d, got: %d", 0, p.PoolSizeCounter())
+	}
+}
+
+func TestSessionPoolShutdownStopsKeepalive(t *testing.T) {
+	sampleSessionConf.ServiceURL = serverCreateRQ.URL
+	p := NewPool(sampleExpireScheme, sampleSessionConf, cycleEvery, 1)
+	_ = p.Populate()
+	done := make(chan struct{})
+	go func() {
+		p.Keepalive()
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown expect: %v, got: %v", nil, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Keepalive should return after Shutdown")
+	}
+}
+
+func TestPoolManagerShutdown(t *testing.T) {
+	m := samplePoolManager(t)
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Errorf("PoolManager.Shutdown expect: %v, got: %v", nil, err)
+	}
+	if _, err := m.Pick("7TZA"); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("PoolManager.Pick after Shutdown expect: %v, got: %v", ErrPoolClosed, err)
+	}
+}
@@ -0,0 +1,200 @@
+package srvc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SessionStore persists session tokens so a restarted process can reuse live Sabre sessions instead of creating new ones, see SessionPool.Store.
+type SessionStore interface {
+	Load() ([]Session, error)
+	Save(sessions []Session) error
+}
+
+// storedSession is what gets persisted for a Session.
+type storedSession struct {
+	ID              string    `json:"id"`
+	BinSecTokCached string    `json:"token"`
+	TimeStarted     time.Time `json:"started"`
+	ExpireTime      time.Time `json:"expire"`
+}
+
+/*
+FileStore is a SessionStore keeping tokens in a local file encrypted with AES-GCM. Binary security tokens are credentials, so the key should come from a secret store rather than config on disk; the file is written with 0600 permissions.
+
+	key, _ := hex.DecodeString(os.Getenv("SABRE_SESSION_KEY")) //32 bytes for AES-256
+	store, err := srvc.NewFileStore("/var/lib/app/sabre_sessions", key)
+	pool.Store = store
+*/
+type FileStore struct {
+	Path string
+	aead cipher.AEAD
+}
+
+// NewFileStore for path, key must be 16, 24 or 32 bytes to select AES-128, AES-192 or AES-256.
+func NewFileStore(path string, key []byte) (*FileStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{Path: path, aead: aead}, nil
+}
+
+// Load decrypts sessions from Path, none if the file does not exist.
+func (f *FileStore) Load() ([]Session, error) {
+	b, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ns := f.aead.NonceSize()
+	if len(b) < ns {
+		return nil, fmt.Errorf("session store '%s' is corrupt", f.Path)
+	}
+	plain, err := f.aead.Open(nil, b[:ns], b[ns:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("session store '%s' cannot be decrypted: %w", f.Path, err)
+	}
+	stored := []storedSession{}
+	if err := json.Unmarshal(plain, &stored); err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(stored))
+	for _, s := range stored {
+		sessions = append(sessions, Session{
+			ID:              s.ID,
+			BinSecTokCached: s.BinSecTokCached,
+			TimeStarted:     s.TimeStarted,
+			ExpireTime:      s.ExpireTime,
+		})
+	}
+	return sessions, nil
+}
+
+// Save encrypts sessions to Path, replacing whatever was saved before; the file is swapped in with a rename so a crash never leaves it half written.
+func (f *FileStore) Save(sessions []Session) error {
+	stored := make([]storedSession, 0, len(sessions))
+	for _, s := range sessions {
+		stored = append(stored, storedSession{
+			ID:              s.ID,
+			BinSecTokCached: s.BinSecTokCached,
+			TimeStarted:     s.TimeStarted,
+			ExpireTime:      s.ExpireTime,
+		})
+	}
+	plain, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(f.aead.Seal(nonce, nonce, plain, nil)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// validateSession checks sess is still alive on Sabre with SessionValidateRQ, returning it with expire and validated times reset.
+func (p *SessionPool) validateSession(sess Session) (Session, error) {
+	validateRS, err := CallSessionValidateContext(p.sessionContext(sess), p.ServiceURL, BuildSessionValidateRequest(p.Conf, sess.BinSecTokCached))
+	if err != nil {
+		p.addNetworkError(err)
+		return sess, err
+	}
+	if validateRS.Header.MessageHeader.Action == StatusErrorRS || validateRS.Body.Fault.Code != "" {
+		return sess, fmt.Errorf("%s-%s: %s", validateRS.Body.Fault.String, validateRS.Body.Fault.Code, validateRS.Body.Fault.Detail.StackTrace)
+	}
+	now := p.now()
+	sess.OK = true
+	sess.FaultError = nil
+	sess.TimeValidated = now
+	sess.TimeLastUsed = now
+	sess.ExpireTime = p.expireTime(now)
+	if tok := validateRS.Header.Security.BinarySecurityToken.Value; tok != "" {
+		sess.BinSecTokCached = tok
+	}
+	return sess, nil
+}
+
+// restoreSessions queues sessions persisted in Store that are still valid on Sabre, up to ConfigPoolSize; returns the number restored. Tokens are removed from Store once loaded so two processes never share them.
+func (p *SessionPool) restoreSessions() int {
+	if p.Store == nil {
+		return 0
+	}
+	persisted, err := p.Store.Load()
+	if err != nil {
+		p.logger().Error("loading persisted sessions, creating new ones", LogKeyError, err)
+		return 0
+	}
+	if len(persisted) == 0 {
+		return 0
+	}
+	if err := p.Store.Save(nil); err != nil {
+		p.logger().Error("clearing persisted sessions", LogKeyError, err)
+	}
+	restored := 0
+	for _, sess := range persisted {
+		sess, err := p.validateSession(sess)
+		if err != nil {
+			p.logger().Info("persisted session no longer valid", LogKeySessionID, sess.ID, LogKeyError, err)
+			continue
+		}
+		if restored >= p.ConfigPoolSize || !acquirePCCSession(p.Conf.PCC) {
+			//more persisted than we need, don't leave it open on Sabre
+			p.closeSession(sess)
+			continue
+		}
+		p.logger().Info("session restored", LogKeySessionID, sess.ID, "alive_for", p.now().Sub(sess.TimeStarted), "token", SabreTokenParse(sess.BinSecTokCached))
+		p.Sessions <- sess
+		p.poolSize.Add(1)
+		restored++
+	}
+	return restored
+}
+
+// persistSessions saves sessions to Store; returns those that could not be persisted (bad or without a token) and must be closed. When Save fails every session is returned.
+func (p *SessionPool) persistSessions(sessions []Session) []Session {
+	keep, rest := []Session{}, []Session{}
+	for _, sess := range sessions {
+		if sess.OK && sess.BinSecTokCached != "" {
+			keep = append(keep, sess)
+		} else {
+			rest = append(rest, sess)
+		}
+	}
+	if err := p.Store.Save(keep); err != nil {
+		p.logger().Error("persisting sessions, closing them", LogKeyError, err)
+		return sessions
+	}
+	for range keep {
+		p.poolSize.Add(-1)
+		releasePCCSession(p.Conf.PCC)
+	}
+	p.logger().Info("sessions persisted", "count", len(keep))
+	return rest
+}
@@ -0,0 +1,151 @@
+package srvc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var sampleStoreKey = []byte("0123456789abcdef0123456789abcdef")
+
+// serverSessionActions answers create, validate and close, counting creates; validate answers with validateRS.
+func serverSessionActions(creates *atomic.Int64, validateRS []byte) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				body := new(bytes.Buffer)
+				_, _ = body.ReadFrom(rq.Body)
+				switch {
+				case strings.Contains(body.String(), "<eb:Action>SessionValidateRQ</eb:Action>"):
+					_, _ = rs.Write(validateRS)
+				case strings.Contains(body.String(), "<eb:Action>SessionCloseRQ</eb:Action>"):
+					_, _ = rs.Write(sampleSessionCloseRespSuccess)
+				default:
+					creates.Add(1)
+					_, _ = rs.Write(sampleSessionSuccessResponse)
+				}
+			},
+		),
+	)
+}
+
+func TestFileStoreSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions")
+	store, err := NewFileStore(path, sampleStoreKey)
+	if err != nil {
+		t.Fatal("NewFileStore should not return error", err)
+	}
+	loaded, err := store.Load()
+	if err != nil || len(loaded) != 0 {
+		t.Errorf("Load without file expect: 0 <nil>, got: %d %v", len(loaded), err)
+	}
+
+	started := time.Now().Add(-time.Hour).Round(0)
+	saved := []Session{{ID: "abc", BinSecTokCached: "Shared/IDL:IceSess!110012!0", TimeStarted: started, ExpireTime: started.Add(time.Hour), OK: true}}
+	if err := store.Save(saved); err != nil {
+		t.Fatal("Save should not return error", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if bytes.Contains(raw, []byte("IceSess")) {
+		t.Error("Save should not write tokens in plain text")
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0600 {
+		t.Errorf("Save file mode expect: %v, got: %v", os.FileMode(0600), fi.Mode().Perm())
+	}
+	loaded, err = store.Load()
+	if err != nil {
+		t.Fatal("Load should not return error", err)
+	}
+	if len(loaded) != 1 || loaded[0].ID != "abc" || loaded[0].BinSecTokCached != saved[0].BinSecTokCached || !loaded[0].TimeStarted.Equal(started) {
+		t.Errorf("Load expect: %+v, got: %+v", saved, loaded)
+	}
+
+	other, _ := NewFileStore(path, bytes.Repeat([]byte("k"), 32))
+	if _, err := other.Load(); err == nil {
+		t.Error("Load with wrong key should return error")
+	}
+	if _, err := NewFileStore(path, []byte("short")); err == nil {
+		t.Error("NewFileStore with bad key size should return error")
+	}
+}
+
+func TestSessionPoolStoreRestore(t *testing.T) {
+	var creates atomic.Int64
+	server := serverSessionActions(&creates, sampleSessionValidateRespSuccess)
+	defer server.Close()
+	conf := *sampleSessionConf
+	conf.ServiceURL = server.URL
+	store, _ := NewFileStore(filepath.Join(t.TempDir(), "sessions"), sampleStoreKey)
+
+	first := NewPool(sampleExpireScheme, &conf, cycleEvery, 2)
+	first.Store = store
+	_ = first.Populate()
+	ids := map[string]bool{}
+	for _, l := range []Session{first.Pick(), first.Pick()} {
+		ids[l.ID] = true
+		first.Put(l)
+	}
+	if err := first.Shutdown(context.Background()); err != nil {
+		t.Error("Shutdown should not return error", err)
+	}
+	if persisted, _ := store.Load(); len(persisted) != 2 {
+		t.Fatalf("Shutdown persisted expect: %d, got: %d", 2, len(persisted))
+	}
+
+	creates.Store(0)
+	second := NewPool(sampleExpireScheme, &conf, cycleEvery, 3)
+	second.Store = store
+	_ = second.Populate()
+	defer second.Close()
+	if creates.Load() != 1 {
+		t.Errorf("Populate with 2 persisted of 3 creates expect: %d, got: %d", 1, creates.Load())
+	}
+	if second.PoolSizeCounter() != 3 {
+		t.Errorf("PoolSizeCounter expect: %d, got: %d", 3, second.PoolSizeCounter())
+	}
+	restored := 0
+	for i := 0; i < 3; i++ {
+		sess := second.Pick()
+		if ids[sess.ID] {
+			restored++
+			if !sess.OK || time.Until(sess.ExpireTime) <= 0 {
+				t.Errorf("restored session should be OK and not expired, got: %+v", sess)
+			}
+		}
+		defer second.Put(sess)
+	}
+	if restored != 2 {
+		t.Errorf("restored sessions expect: %d, got: %d", 2, restored)
+	}
+	if persisted, _ := store.Load(); len(persisted) != 0 {
+		t.Errorf("Populate should clear store, got: %d", len(persisted))
+	}
+}
+
+func TestSessionPoolStoreRestoreInvalid(t *testing.T) {
+	var creates atomic.Int64
+	server := serverSessionActions(&creates, sampleSessionValidateRSInvalidTokenRS)
+	defer server.Close()
+	conf := *sampleSessionConf
+	conf.ServiceURL = server.URL
+	store, _ := NewFileStore(filepath.Join(t.TempDir(), "sessions"), sampleStoreKey)
+	_ = store.Save([]Session{{ID: "dead", BinSecTokCached: "expired-token", OK: true}})
+
+	p := NewPool(sampleExpireScheme, &conf, cycleEvery, 2)
+	p.Store = store
+	_ = p.Populate()
+	defer p.Close()
+	if creates.Load() != 2 {
+		t.Errorf("Populate with invalid persisted token creates expect: %d, got: %d", 2, creates.Load())
+	}
+	if p.PoolSizeCounter() != 2 {
+		t.Errorf("PoolSizeCounter expect: %d, got: %d", 2, p.PoolSizeCounter())
+	}
+}
@@ -3,13 +3,12 @@ package srvc
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
-	"net/http"
-	"os"
 	"regexp"
 	"time"
 
@@ -53,29 +52,13 @@ const (
 )
 
 var (
-	LogSoap            = &log.Logger{}
-	logSession         = &log.Logger{}
+	// LogSoap is no longer written to by this module.
+	//
+	// Deprecated: set Transport.Logger, SOAP requests are logged with structured fields and payloads at debug level.
+	LogSoap            = log.New(io.Discard, "[sabre-soap] ", log.LstdFlags)
 	binaryTokenMatcher = regexp.MustCompile(`\!\d.*$`)
 )
 
-func init() {
-	setUpLogging()
-}
-
-func setUpLogging() {
-	soapL, err := os.Create("sabre_web_soap.log")
-	if err != nil {
-		log.Fatal("no soap log file")
-	}
-	sessL, err := os.Create("sabre_web_session.log")
-	if err != nil {
-		log.Fatal("no session log file")
-	}
-
-	LogSoap = log.New(soapL, "[sabre-soap] ", (log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile))
-	logSession = log.New(sessL, "[sabre-sess] ", (log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile))
-}
-
 // Envelope is wrapper with namespace prefix definitions for payload
 type Envelope struct {
 	XMLName    xml.Name `xml:"soap-env:Envelope"`
@@ -349,7 +332,11 @@ func SabreTimeNowFmt() string {
 
 // randStringBytesMaskImprSrc generate random string of specific length
 func randStringBytesMaskImprSrc(n int) string {
-	src := rand.NewSource(time.Now().UnixNano())
+	return randString(rand.NewSource(time.Now().UnixNano()), n)
+}
+
+// randString of length n drawn from src
+func randString(src rand.Source, n int) string {
 	b := make([]byte, n)
 	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
 	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
@@ -487,12 +474,17 @@ type SessionCreateResponse struct {
 
 // CallSessionCreate to sabre web services.
 func CallSessionCreate(serviceURL string, req SessionCreateRequest) (SessionCreateResponse, error) {
+	return CallSessionCreateContext(context.Background(), serviceURL, req)
+}
+
+// CallSessionCreateContext is CallSessionCreate bound to ctx for cancellation and deadlines, posting through the Transport on ctx (see WithTransport).
+func CallSessionCreateContext(ctx context.Context, serviceURL string, req SessionCreateRequest) (SessionCreateResponse, error) {
 	sessionResponse := SessionCreateResponse{}
 	//construct payload
 
 	byteReq, _ := xml.Marshal(req)
 	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
+	resp, err := Post(ctx, serviceURL, byteReq)
 	if err != nil {
 		return sessionResponse, sbrerr.NewErrorSabreService(err.Error(), sbrerr.ErrCallSessionCreate, sbrerr.BadService)
 	}
@@ -535,27 +527,7 @@ type SessionCloseRequest struct {
 func BuildSessionCloseRequest(c *SessionConf, binsec string) SessionCloseRequest {
 	return SessionCloseRequest{
 		Envelope: CreateEnvelope(),
-		Header: SessionHeader{
-			MessageHeader: MessageHeader{
-				MustUnderstand: SabreMustUnderstand,
-				EbVersion:      SabreEBVersion,
-				From:           FromElem{PartyID: CreatePartyID(c.From, PartyIDTypeURN)},
-				To:             ToElem{PartyID: CreatePartyID(SabreToBase, PartyIDTypeURN)},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        ServiceElem{"SessionCloseRQ", "OTA"},
-				Action:         "SessionCloseRQ",
-				MessageData: MessageDataElem{
-					MessageID: GenerateMessageID(),
-					Timestamp: SabreTimeNowFmt(),
-				},
-			},
-			Security: Security{
-				XMLNSWsseBase:       BaseWsse,
-				XMLNSWsu:            BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
+		Header:   CreateSessionHeader(c, binsec, ServiceElem{"SessionCloseRQ", "OTA"}, "SessionCloseRQ"),
 		Body: SessionCloseRQBody{
 			SessionCloseRQ: SessionCloseRQ{
 				POS: POSElem{
@@ -583,12 +555,17 @@ type SessionCloseResponse struct {
 
 // CallSessionClose to sabre web services
 func CallSessionClose(serviceURL string, e SessionCloseRequest) (SessionCloseResponse, error) {
+	return CallSessionCloseContext(context.Background(), serviceURL, e)
+}
+
+// CallSessionCloseContext is CallSessionClose bound to ctx for cancellation and deadlines, posting through the Transport on ctx (see WithTransport).
+func CallSessionCloseContext(ctx context.Context, serviceURL string, e SessionCloseRequest) (SessionCloseResponse, error) {
 	sessionResponse := SessionCloseResponse{}
 	//construct payload
 
 	byteReq, _ := xml.Marshal(e)
 	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", bytes.NewBuffer(byteReq))
+	resp, err := Post(ctx, serviceURL, byteReq)
 	if err != nil {
 		return sessionResponse, sbrerr.NewErrorSabreService(err.Error(), sbrerr.ErrCallSessionClose, sbrerr.BadService)
 
@@ -632,27 +609,7 @@ type SessionValidateRequest struct {
 func BuildSessionValidateRequest(c *SessionConf, binsec string) SessionValidateRequest {
 	return SessionValidateRequest{
 		Envelope: CreateEnvelope(),
-		Header: SessionHeader{
-			MessageHeader: MessageHeader{
-				MustUnderstand: SabreMustUnderstand,
-				EbVersion:      SabreEBVersion,
-				From:           FromElem{PartyID: CreatePartyID(c.From, PartyIDTypeURN)},
-				To:             ToElem{PartyID: CreatePartyID(SabreToBase, PartyIDTypeURN)},
-				CPAID:          c.PCC,
-				ConversationID: c.Convid,
-				Service:        ServiceElem{"SessionValidateRQ", "OTA"},
-				Action:         "SessionValidateRQ",
-				MessageData: MessageDataElem{
-					MessageID: GenerateMessageID(),
-					Timestamp: SabreTimeNowFmt(),
-				},
-			},
-			Security: Security{
-				XMLNSWsseBase:       BaseWsse,
-				XMLNSWsu:            BaseWsuNameSpace,
-				BinarySecurityToken: binsec,
-			},
-		},
+		Header:   CreateSessionHeader(c, binsec, ServiceElem{"SessionValidateRQ", "OTA"}, "SessionValidateRQ"),
 		Body: SessionValidateRQBody{
 			SessionValidateRQ: SessionValidateRQ{
 				POS: POSElem{
@@ -680,14 +637,18 @@ type SessionValidateResponse struct {
 
 // CallSessionValidate to sabre web services
 func CallSessionValidate(serviceURL string, req SessionValidateRequest) (SessionValidateResponse, error) {
+	return CallSessionValidateContext(context.Background(), serviceURL, req)
+}
+
+// CallSessionValidateContext is CallSessionValidate bound to ctx for cancellation and deadlines, posting through the Transport on ctx (see WithTransport).
+func CallSessionValidateContext(ctx context.Context, serviceURL string, req SessionValidateRequest) (SessionValidateResponse, error) {
 	sessionResponse := SessionValidateResponse{}
 	//construct payload
 
 	byteReq, _ := xml.Marshal(req)
-	buffer := bytes.NewBuffer(byteReq)
 
 	//post payload
-	resp, err := http.Post(serviceURL, "text/xml", buffer)
+	resp, err := Post(ctx, serviceURL, byteReq)
 	if err != nil {
 		return sessionResponse, sbrerr.NewErrorSabreService(err.Error(), sbrerr.ErrCallSessionValidate, sbrerr.BadService)
 	}
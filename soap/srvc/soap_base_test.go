@@ -14,7 +14,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"regexp"
 	"testing"
 
@@ -187,16 +186,6 @@ func init() {
 
 }
 
-func TestLogSetup(t *testing.T) {
-	setUpLogging()
-	if _, err := os.Stat("sabre_web_soap.log"); os.IsNotExist(err) {
-		t.Fatal("no log file for soap logging")
-	}
-	if _, err := os.Stat("sabre_web_session.log"); os.IsNotExist(err) {
-		t.Fatal("no log file for session logging")
-	}
-}
-
 func TestEnvelopeBaseMarshal(t *testing.T) {
 	envelope := Envelope{
 		XMLNSbase:  BaseNS,
@@ -1047,7 +1036,7 @@ func TestCallSessionCreateServiceNoExist(t *testing.T) {
 	if err == nil {
 		t.Error("Expect error", err)
 	}
-	m, _ := regexp.MatchString("Post http://127.0.0.1.*", err.Error())
+	m, _ := regexp.MatchString(`Post "?http://127.0.0.1.*`, err.Error())
 	if !m {
 		t.Error("Expect error to match string with url", err)
 	}
@@ -1061,7 +1050,7 @@ func TestCallSessionCloseServiceNoExist(t *testing.T) {
 	if err == nil {
 		t.Error("Expect error", err)
 	}
-	m, _ := regexp.MatchString("Post http://127.0.0.1.*", err.Error())
+	m, _ := regexp.MatchString(`Post "?http://127.0.0.1.*`, err.Error())
 	if !m {
 		t.Error("Expect error to match string with url", err)
 	}
@@ -1075,7 +1064,7 @@ func TestCallSessionValidateServiceNoExist(t *testing.T) {
 	if err == nil {
 		t.Error("Expect error", err)
 	}
-	m, _ := regexp.MatchString("Post http://127.0.0.1.*", err.Error())
+	m, _ := regexp.MatchString(`Post "?http://127.0.0.1.*`, err.Error())
 	if !m {
 		t.Error("Expect error to match string with url", err)
 	}
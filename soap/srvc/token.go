@@ -0,0 +1,224 @@
+package srvc
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+/*
+TokenCreateRQ returns a stateless ATK token. Unlike a SessionCreateRQ token it has no AAA workspace and lives for days rather than minutes, so it suits read only services (e.g., hotel availability, GetReservation) that never build a PNR; it is sent as the binary security token the same way a session token is. Use TokenSource to create, cache and refresh one.
+*/
+
+const (
+	// DefaultTokenTTL is how long Sabre keeps an ATK token valid, 7 days.
+	DefaultTokenTTL = 7 * 24 * time.Hour
+	// DefaultTokenRefresh is how long before expiry TokenSource replaces a token.
+	DefaultTokenRefresh = time.Hour
+
+	tokenCreateNS = "http://webservices.sabre.com"
+)
+
+// TokenCreateRQ root element
+type TokenCreateRQ struct {
+	XMLName xml.Name `xml:"TokenCreateRQ"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Version string   `xml:"Version,attr"`
+}
+
+// TokenCreateRS root element
+type TokenCreateRS struct {
+	XMLName xml.Name  `xml:"TokenCreateRS"`
+	Version string    `xml:"Version,attr"`
+	Success *struct{} `xml:"Success"`
+	Errors  []struct {
+		Type      string `xml:"Type,attr"`
+		ShortText string `xml:"ShortText,attr"`
+		Message   string `xml:",chardata"`
+	} `xml:"Errors>Error"`
+}
+
+// ResultErr for TokenCreateRS, anything other than Success without errors means no token was created.
+func (r TokenCreateRS) ResultErr() error {
+	if r.Success != nil && len(r.Errors) == 0 {
+		return nil
+	}
+	msg := "TokenCreateRS without Success"
+	for i, e := range r.Errors {
+		msg += fmt.Sprintf("|Error%d:Type-%s:%s:Msg|%s", i, e.Type, e.ShortText, e.Message)
+	}
+	return sbrerr.NewErrorSabreResult(msg, sbrerr.NotProcessed)
+}
+
+// TokenCreateRequest soap envelope for TokenCreateRQ
+type TokenCreateRequest = Request[TokenCreateRQ]
+
+// TokenCreateResponse soap envelope for TokenCreateRS, the token is in Header.Security.BinarySecurityToken.Value
+type TokenCreateResponse = Response[TokenCreateRS]
+
+var tokenCreateOp = Operation[TokenCreateRQ, TokenCreateRS]{
+	Service:    ServiceElem{Value: "TokenCreateRQ", Type: ServiceTypeSabreXML},
+	Action:     "TokenCreateRQ",
+	AppMessage: sbrerr.ErrCallTokenCreate,
+}
+
+// BuildTokenCreateRequest authenticated with the credentials of c, like BuildSessionCreateRequest.
+func BuildTokenCreateRequest(c *SessionConf) TokenCreateRequest {
+	req := tokenCreateOp.Build(c, "", TokenCreateRQ{
+		XMLNS:   tokenCreateNS,
+		Version: "1.0.0",
+	})
+	req.Header.Security.UserNameToken = &UsernameTokenElem{
+		Username:     c.Username,
+		Password:     c.Password,
+		Organization: c.PCC,
+		Domain:       sabreDefaultDomain,
+	}
+	return req
+}
+
+// CallTokenCreate to execute TokenCreateRequest.
+func CallTokenCreate(serviceURL string, req TokenCreateRequest) (TokenCreateResponse, error) {
+	return CallTokenCreateContext(context.Background(), serviceURL, req)
+}
+
+// CallTokenCreateContext is CallTokenCreate bound to ctx for cancellation and deadlines, posting through the Transport on ctx (see WithTransport).
+func CallTokenCreateContext(ctx context.Context, serviceURL string, req TokenCreateRequest) (TokenCreateResponse, error) {
+	return tokenCreateOp.post(ctx, serviceURL, req)
+}
+
+/*
+TokenSource creates an ATK token with TokenCreateRQ on first use, caches it, and creates a new one RefreshBefore it expires or once Sabre rejects it. It is safe for concurrent use; callers waiting on a new token share a single TokenCreateRQ and each stops waiting when its own ctx is done.
+
+	ts := srvc.NewTokenSource(conf, nil)
+	err := ts.Do(ctx, func(token string) error {
+		_, err := htlsp.CallHotelAvailContext(ctx, conf.ServiceURL, htlsp.BuildHotelAvailRequest(conf, token, body))
+		return err
+	})
+*/
+type TokenSource struct {
+	Conf          *SessionConf
+	Transport     *Transport    //optional, DefaultTransport when nil
+	TTL           time.Duration //token lifetime, DefaultTokenTTL when 0
+	RefreshBefore time.Duration //create a new token this long before expiry, DefaultTokenRefresh when 0
+
+	mu         sync.Mutex
+	token      string
+	expires    time.Time
+	refreshing *tokenRefresh //TokenCreateRQ in flight, nil when none
+}
+
+// tokenRefresh is one TokenCreateRQ shared by the callers waiting on it; token and err are set before done is closed.
+type tokenRefresh struct {
+	done    chan struct{}
+	started time.Time
+	token   string
+	err     error
+}
+
+// NewTokenSource for the credentials and ServiceURL of conf.
+func NewTokenSource(conf *SessionConf, t *Transport) *TokenSource {
+	return &TokenSource{Conf: conf, Transport: t}
+}
+
+// Token cached or newly created; the error is from TokenCreateRQ. Callers arriving while a token is created wait for it, or until their own ctx is done; when the caller creating it gives up (its ctx is done) a waiter whose ctx is not done creates the token instead.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	for {
+		s.mu.Lock()
+		refresh := s.RefreshBefore
+		if refresh <= 0 {
+			refresh = DefaultTokenRefresh
+		}
+		if s.token != "" && time.Until(s.expires) > refresh {
+			tok := s.token
+			s.mu.Unlock()
+			return tok, nil
+		}
+		r := s.refreshing
+		if r == nil {
+			r = &tokenRefresh{done: make(chan struct{})}
+			s.refreshing = r
+			s.mu.Unlock()
+			s.refresh(ctx, r)
+			return r.token, r.err
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if r.err == nil || ctx.Err() != nil || !(errors.Is(r.err, context.Canceled) || errors.Is(r.err, context.DeadlineExceeded)) {
+			return r.token, r.err
+		}
+		//the creating caller gave up, try on ours
+	}
+}
+
+// refresh creates a token with TokenCreateRQ bound to ctx, caches it, and hands the result to the callers waiting on r.
+func (s *TokenSource) refresh(ctx context.Context, r *tokenRefresh) {
+	defer func() {
+		s.mu.Lock()
+		s.refreshing = nil
+		if r.err == nil {
+			ttl := s.TTL
+			if ttl <= 0 {
+				ttl = DefaultTokenTTL
+			}
+			s.token, s.expires = r.token, r.started.Add(ttl)
+		}
+		s.mu.Unlock()
+		close(r.done)
+	}()
+	if s.Transport != nil {
+		ctx = WithTransport(ctx, s.Transport)
+	}
+	r.started = time.Now()
+	resp, err := CallTokenCreateContext(ctx, s.Conf.ServiceURL, BuildTokenCreateRequest(s.Conf))
+	if err != nil {
+		r.err = err
+		return
+	}
+	if r.token = resp.Header.Security.BinarySecurityToken.Value; r.token == "" {
+		r.err = sbrerr.NewErrorSabreResult("TokenCreateRS without BinarySecurityToken", sbrerr.NotProcessed)
+	}
+}
+
+// Expires time of the cached token, zero when there is none.
+func (s *TokenSource) Expires() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expires
+}
+
+// Invalidate the cached token if it is still token, the next Token creates a new one.
+func (s *TokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token, s.expires = "", time.Time{}
+	}
+}
+
+// Do runs fn with a token. When Sabre rejects the token (see IsSessionDead) a new one is created and fn is retried once.
+func (s *TokenSource) Do(ctx context.Context, fn func(token string) error) error {
+	tok, err := s.Token(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(tok)
+	if !IsSessionDead(err) {
+		return err
+	}
+	s.Invalidate(tok)
+	if tok, err = s.Token(ctx); err != nil {
+		return err
+	}
+	return fn(tok)
+}
@@ -0,0 +1,215 @@
+package srvc
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ailgroup/sbrweb/sbrerr"
+)
+
+var (
+	sampleTokenCreateRespSuccess = `<?xml version="1.0" encoding="UTF-8"?>
+	<soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header><eb:MessageHeader xmlns:eb="http://www.ebxml.org/namespaces/messageHeader" eb:version="1.0" soap-env:mustUnderstand="1"><eb:From><eb:PartyId eb:type="URI">webservices.sabre.com</eb:PartyId></eb:From><eb:To><eb:PartyId eb:type="URI">www.z.com</eb:PartyId></eb:To><eb:CPAId>7TZA</eb:CPAId><eb:ConversationId>fds8789h|dev@z.com</eb:ConversationId><eb:Service eb:type="sabreXML">TokenCreateRQ</eb:Service><eb:Action>TokenCreateRS</eb:Action><eb:MessageData><eb:MessageId>2184926417372810461</eb:MessageId><eb:Timestamp>2018-02-16T07:18:42</eb:Timestamp><eb:RefToMessageId>mid:20180216-07:18:42.3|14oUa</eb:RefToMessageId></eb:MessageData></eb:MessageHeader><wsse:Security xmlns:wsse="http://schemas.xmlsoap.org/ws/2002/12/secext"><wsse:BinarySecurityToken valueType="String" EncodingType="wsse:Base64Binary">%s</wsse:BinarySecurityToken></wsse:Security></soap-env:Header><soap-env:Body><TokenCreateRS xmlns="http://webservices.sabre.com" Version="1.0.0"><Success/></TokenCreateRS></soap-env:Body></soap-env:Envelope>`
+
+	sampleBinsecMatcher = regexp.MustCompile(`<wsse:BinarySecurityToken>([^<]*)</wsse:BinarySecurityToken>`)
+
+	sampleTokenCreateRespError = []byte(`<?xml version="1.0" encoding="UTF-8"?>
+	<soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header/><soap-env:Body><TokenCreateRS xmlns="http://webservices.sabre.com" Version="1.0.0"><Errors><Error Type="Application" ShortText="ERR.SWS.CLIENT.VALIDATION_FAILED">Request does not match schema</Error></Errors></TokenCreateRS></soap-env:Body></soap-env:Envelope>`)
+)
+
+// serverTokenCreate returns T1RLAQ<n> for the nth TokenCreateRQ; other requests get the invalid token fault when their token is in dead.
+func serverTokenCreate(creates *atomic.Int64, dead *sync.Map) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				body, _ := io.ReadAll(rq.Body)
+				if soapAction(body) == "TokenCreateRQ" {
+					_, _ = fmt.Fprintf(rs, sampleTokenCreateRespSuccess, fmt.Sprintf("T1RLAQ%d", creates.Add(1)))
+					return
+				}
+				sec := sampleBinsecMatcher.FindSubmatch(body)
+				if _, ok := dead.Load(string(sec[1])); ok {
+					_, _ = rs.Write(sampleSessionValidateRSInvalidTokenRS)
+					return
+				}
+				_, _ = rs.Write(sampleSessionValidateRespSuccess)
+			},
+		),
+	)
+}
+
+func TestBuildTokenCreateRequest(t *testing.T) {
+	req := BuildTokenCreateRequest(sampleSessionConf)
+	b, err := xml.Marshal(req)
+	if err != nil {
+		t.Fatal("Error marshal TokenCreateRequest", err)
+	}
+	for _, want := range [][]byte{
+		sampleSecurityRequest,
+		[]byte(`<eb:Service eb:type="sabreXML">TokenCreateRQ</eb:Service><eb:Action>TokenCreateRQ</eb:Action>`),
+		[]byte(`<TokenCreateRQ xmlns="http://webservices.sabre.com" Version="1.0.0"></TokenCreateRQ>`),
+	} {
+		if !strings.Contains(string(b), string(want)) {
+			t.Errorf("TokenCreateRequest expect to contain: %s, got: %s", want, b)
+		}
+	}
+	if strings.Contains(string(b), "BinarySecurityToken") {
+		t.Errorf("TokenCreateRequest should not have BinarySecurityToken, got: %s", b)
+	}
+}
+
+func TestCallTokenCreateError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rs http.ResponseWriter, rq *http.Request) {
+		_, _ = rs.Write(sampleTokenCreateRespError)
+	}))
+	defer server.Close()
+	_, err := CallTokenCreate(server.URL, BuildTokenCreateRequest(sampleSessionConf))
+	var res sbrerr.ErrorSabreResult
+	if !errors.As(err, &res) {
+		t.Fatalf("CallTokenCreate error expect: %T, got: %T %v", res, err, err)
+	}
+	if !strings.Contains(err.Error(), "ERR.SWS.CLIENT.VALIDATION_FAILED") {
+		t.Errorf("CallTokenCreate error expect to contain: %s, got: %v", "ERR.SWS.CLIENT.VALIDATION_FAILED", err)
+	}
+}
+
+func TestTokenSourceToken(t *testing.T) {
+	var creates atomic.Int64
+	server := serverTokenCreate(&creates, &sync.Map{})
+	defer server.Close()
+	conf := *sampleSessionConf
+	conf.ServiceURL = server.URL
+	ts := NewTokenSource(&conf, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, err := ts.Token(context.Background())
+			if err != nil || tok != "T1RLAQ1" {
+				t.Errorf("Token expect: %s, got: %s %v", "T1RLAQ1", tok, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if creates.Load() != 1 {
+		t.Errorf("TokenCreateRQ calls expect: %d, got: %d", 1, creates.Load())
+	}
+	if exp := time.Until(ts.Expires()); exp < DefaultTokenTTL-time.Minute || exp > DefaultTokenTTL {
+		t.Errorf("Expires expect about: %v, got: %v", DefaultTokenTTL, exp)
+	}
+
+	//refreshed when within RefreshBefore of expiry
+	ts.TTL, ts.RefreshBefore = time.Hour, 2*time.Hour
+	ts.Invalidate("T1RLAQ1")
+	tok, _ := ts.Token(context.Background())
+	tok2, _ := ts.Token(context.Background())
+	if tok != "T1RLAQ2" || tok2 != "T1RLAQ3" {
+		t.Errorf("Token near expiry expect: %s %s, got: %s %s", "T1RLAQ2", "T1RLAQ3", tok, tok2)
+	}
+
+	//Invalidate of an old token keeps the current one
+	ts.TTL, ts.RefreshBefore = 0, 0
+	ts.Invalidate(tok2)
+	tok, _ = ts.Token(context.Background())
+	ts.Invalidate(tok2)
+	if tok2, _ = ts.Token(context.Background()); tok != tok2 {
+		t.Errorf("Token after stale Invalidate expect: %s, got: %s", tok, tok2)
+	}
+}
+
+func TestTokenSourceTokenWaiterCanceled(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				started <- struct{}{}
+				<-release
+				_, _ = fmt.Fprintf(rs, sampleTokenCreateRespSuccess, "T1RLAQ1")
+			},
+		),
+	)
+	defer server.Close()
+	conf := *sampleSessionConf
+	conf.ServiceURL = server.URL
+	ts := NewTokenSource(&conf, nil)
+
+	done := make(chan string, 1)
+	go func() {
+		tok, _ := ts.Token(context.Background())
+		done <- tok
+	}()
+	<-started
+
+	//a waiter gives up on its own deadline while the slow TokenCreateRQ is in flight
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	if _, err := ts.Token(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiter Token expect: %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if waited := time.Since(begin); waited > time.Second {
+		t.Errorf("waiter Token expect: back on its deadline, got: after %v", waited)
+	}
+
+	close(release)
+	if tok := <-done; tok != "T1RLAQ1" {
+		t.Errorf("Token expect: %s, got: %s", "T1RLAQ1", tok)
+	}
+	if tok, err := ts.Token(context.Background()); err != nil || tok != "T1RLAQ1" {
+		t.Errorf("cached Token expect: %s, got: %s %v", "T1RLAQ1", tok, err)
+	}
+}
+
+func TestTokenSourceDo(t *testing.T) {
+	var creates atomic.Int64
+	dead := &sync.Map{}
+	server := serverTokenCreate(&creates, dead)
+	defer server.Close()
+	conf := *sampleSessionConf
+	conf.ServiceURL = server.URL
+	ts := NewTokenSource(&conf, NewTransport(&http.Client{}))
+
+	var used []string
+	call := func(tok string) error {
+		used = append(used, tok)
+		resp, err := CallSessionValidate(server.URL, BuildSessionValidateRequest(&conf, tok))
+		if err == nil && !resp.Body.Fault.Ok() {
+			err = resp.Body.Fault.Format()
+		}
+		return err
+	}
+	if err := ts.Do(context.Background(), call); err != nil {
+		t.Error("Do should not return error", err)
+	}
+	//token rejected by Sabre, new one created and fn retried
+	dead.Store("T1RLAQ1", true)
+	if err := ts.Do(context.Background(), call); err != nil {
+		t.Error("Do with rejected token should retry without error", err)
+	}
+	if strings.Join(used, ",") != "T1RLAQ1,T1RLAQ1,T1RLAQ2" {
+		t.Errorf("Do tokens expect: %s, got: %v", "T1RLAQ1,T1RLAQ1,T1RLAQ2", used)
+	}
+	//retried once only
+	dead.Store("T1RLAQ2", true)
+	dead.Store("T1RLAQ3", true)
+	if err := ts.Do(context.Background(), call); !IsSessionDead(err) {
+		t.Errorf("Do retried with rejected token expect: %s, got: %v", "session dead", err)
+	}
+	if creates.Load() != 3 {
+		t.Errorf("TokenCreateRQ calls expect: %d, got: %d", 3, creates.Load())
+	}
+}
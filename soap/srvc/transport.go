@@ -0,0 +1,150 @@
+package srvc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the overall http timeout used by DefaultTransport; a stalled Sabre request will never hang longer than this.
+	DefaultTimeout = 60 * time.Second
+	contentTypeXML = "text/xml"
+)
+
+// DefaultTransport is used by every Call* function when the context does not carry its own Transport, see WithTransport.
+var DefaultTransport = NewTransport(&http.Client{Timeout: DefaultTimeout})
+
+// transportKey is the context key for a Transport
+type transportKey struct{}
+
+// Transport posts SOAP payloads to Sabre Web Services. It wraps an http.Client so proxies, TLS settings, timeouts, and test round trippers can be injected; every request is bound to a context for cancellation.
+type Transport struct {
+	Client   *http.Client
+	Logger   Logger          //optional, logs every request with action, conversation and message ids, duration and status; payloads at debug level
+	Redactor *Redactor       //masks logged payloads, DefaultRedactor when nil
+	Retry    *RetryPolicy    //optional, retries idempotent actions, see IdempotentActions
+	Breaker  *CircuitBreaker //optional, fails fast per service URL during Sabre brownouts
+	Limiter  *RateLimiter    //optional, waits for a token per PCC and action before each request
+}
+
+// NewTransport returns a Transport for the given client; a nil client falls back to http.DefaultClient.
+func NewTransport(client *http.Client) *Transport {
+	return &Transport{Client: client}
+}
+
+// client helper to return a non nil http client
+func (t *Transport) client() *http.Client {
+	if t == nil || t.Client == nil {
+		return http.DefaultClient
+	}
+	return t.Client
+}
+
+// logger helper to return a non nil Logger
+func (t *Transport) logger() Logger {
+	if t == nil || t.Logger == nil {
+		return DiscardLogger
+	}
+	return t.Logger
+}
+
+// Post sends the payload to serviceURL as text/xml bound to ctx, retrying idempotent actions per Retry, failing fast with ErrorCircuitOpen while the Breaker circuit for serviceURL is open, and waiting on Limiter when the PCC or action is over its rate. Caller owns the response and must close the body.
+func (t *Transport) Post(ctx context.Context, serviceURL string, payload []byte) (*http.Response, error) {
+	if t == nil {
+		t = &Transport{}
+	}
+	action := soapAction(payload)
+	attempts := 1
+	if t.Retry != nil {
+		attempts = t.Retry.attempts(action)
+	}
+	started := time.Now()
+	var resp *http.Response
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = t.Breaker.allow(serviceURL); err != nil {
+			break
+		}
+		var waited time.Duration
+		if waited, err = t.Limiter.wait(ctx, soapPCC(payload), action); err != nil {
+			break
+		}
+		if waited > 0 {
+			t.logger().Debug("sabre request rate limited", append(soapAttrs(ctx, payload), "waited", waited)...)
+		}
+		resp, err = t.send(ctx, serviceURL, payload)
+		if ctx.Err() != nil {
+			//caller gave up, says nothing about Sabre
+			break
+		}
+		failed := failedCall(resp, err)
+		t.Breaker.record(serviceURL, !failed)
+		if !failed || attempt >= attempts {
+			break
+		}
+		delay := t.Retry.backoff(attempt)
+		attrs := append(soapAttrs(ctx, payload), "attempt", attempt, "delay", delay)
+		if err != nil {
+			attrs = append(attrs, LogKeyError, err)
+		} else {
+			attrs = append(attrs, LogKeyStatus, resp.StatusCode)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		t.logger().Warn("sabre request retry", attrs...)
+		wait := time.NewTimer(delay)
+		select {
+		case <-wait.C:
+			continue
+		case <-ctx.Done():
+			wait.Stop()
+		}
+		resp, err = nil, ctx.Err()
+		break
+	}
+	if t.Logger == nil {
+		return resp, err
+	}
+
+	attrs := soapAttrs(ctx, payload)
+	t.Logger.Debug("sabre request payload", append(attrs[:len(attrs):len(attrs)], LogKeyPayload, string(t.Redactor.Redact(payload)))...)
+	attrs = append(attrs, LogKeyDuration, time.Since(started))
+	if err != nil {
+		t.Logger.Error("sabre request", append(attrs, LogKeyError, err)...)
+		return resp, err
+	}
+	t.Logger.Info("sabre request", append(attrs[:len(attrs):len(attrs)], LogKeyStatus, resp.StatusCode)...)
+	resp.Body = &loggedBody{ReadCloser: resp.Body, log: t.Logger, redactor: t.Redactor, attrs: attrs}
+	return resp, nil
+}
+
+// send one attempt of Post.
+func (t *Transport) send(ctx context.Context, serviceURL string, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serviceURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentTypeXML)
+	return t.client().Do(req)
+}
+
+// WithTransport returns a copy of ctx carrying t; Call*Context functions will use it instead of DefaultTransport.
+func WithTransport(ctx context.Context, t *Transport) context.Context {
+	return context.WithValue(ctx, transportKey{}, t)
+}
+
+// TransportFrom returns the Transport carried by ctx, else DefaultTransport.
+func TransportFrom(ctx context.Context) *Transport {
+	if t, ok := ctx.Value(transportKey{}).(*Transport); ok && t != nil {
+		return t
+	}
+	return DefaultTransport
+}
+
+// Post sends payload to serviceURL using the Transport found on ctx, see TransportFrom.
+func Post(ctx context.Context, serviceURL string, payload []byte) (*http.Response, error) {
+	return TransportFrom(ctx).Post(ctx, serviceURL, payload)
+}
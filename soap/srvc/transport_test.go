@@ -0,0 +1,94 @@
+package srvc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// roundTripFunc lets tests inject a custom http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransportFromDefault(t *testing.T) {
+	if TransportFrom(context.Background()) != DefaultTransport {
+		t.Error("TransportFrom empty context should return DefaultTransport")
+	}
+	tr := NewTransport(&http.Client{})
+	ctx := WithTransport(context.Background(), tr)
+	if TransportFrom(ctx) != tr {
+		t.Error("TransportFrom should return Transport carried on context")
+	}
+}
+
+func TestTransportPostContentType(t *testing.T) {
+	var contentType, method string
+	tr := NewTransport(&http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			contentType = r.Header.Get("Content-Type")
+			method = r.Method
+			return nil, errors.New("injected")
+		}),
+	})
+	_, err := tr.Post(context.Background(), serverCreateRQ.URL, []byte(`<x/>`))
+	if err == nil {
+		t.Error("Expect error from injected round tripper")
+	}
+	if contentType != contentTypeXML {
+		t.Errorf("Content-Type expect: %s, got: %s", contentTypeXML, contentType)
+	}
+	if method != http.MethodPost {
+		t.Errorf("Method expect: %s, got: %s", http.MethodPost, method)
+	}
+}
+
+func TestCallSessionCreateContextInjectedClient(t *testing.T) {
+	called := 0
+	tr := NewTransport(&http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			called++
+			return http.DefaultTransport.RoundTrip(r)
+		}),
+	})
+	ctx := WithTransport(context.Background(), tr)
+	resp, err := CallSessionCreateContext(ctx, serverCreateRQ.URL, BuildSessionCreateRequest(sampleSessionConf))
+	if err != nil {
+		t.Error("Error making request CallSessionCreateContext", err)
+	}
+	if called != 1 {
+		t.Errorf("Injected client calls expect: %d, got: %d", 1, called)
+	}
+	if resp.Header.Security.BinarySecurityToken.Value != samplebinsectoken {
+		t.Errorf("Header.Security.BinarySecurityToken.Value expect: %s, got: %s", samplebinsectoken, resp.Header.Security.BinarySecurityToken.Value)
+	}
+}
+
+func TestCallSessionValidateContextDeadline(t *testing.T) {
+	stall := make(chan struct{})
+	serverStall := httptest.NewServer(
+		http.HandlerFunc(
+			func(rs http.ResponseWriter, rq *http.Request) {
+				<-stall
+			},
+		),
+	)
+	defer serverStall.Close()
+	defer close(stall)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := CallSessionValidateContext(ctx, serverStall.URL, BuildSessionValidateRequest(sampleSessionConf, samplebinsectoken))
+	if err == nil {
+		t.Fatal("Expect error when context deadline exceeded")
+	}
+	if time.Since(started) > 2*time.Second {
+		t.Errorf("Stalled request should return on deadline, took: %v", time.Since(started))
+	}
+}